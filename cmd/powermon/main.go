@@ -2,15 +2,24 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/rdegges/powermon/internal/metrics"
 	"github.com/rdegges/powermon/internal/power"
 	"github.com/rdegges/powermon/internal/ui"
+	"github.com/rdegges/powermon/internal/web"
 )
 
 // These variables are set at build time via ldflags
@@ -19,11 +28,66 @@ var (
 	buildTime = "unknown"
 )
 
+// calibrationPhase is the minimum length of each -calibrate phase.
+const calibrationPhase = 5 * time.Second
+
 func main() {
+	os.Exit(run())
+}
+
+// run parses flags, starts the selected output mode, and returns the process
+// exit code. Keeping this separate from main lets deferred cleanup run.
+func run() int {
 	// Parse command-line flags
 	showVersion := flag.Bool("version", false, "Show version information")
 	refreshInterval := flag.Duration("interval", 1*time.Second, "Refresh interval for power readings")
-	historyDuration := flag.Duration("history", 2*time.Minute, "How long to keep readings for the graph")
+	historyDuration := flag.Duration("history", 2*time.Minute, "How long to keep readings for stats and the graph")
+	duration := flag.Duration("duration", 0, "Exit after this long and print a summary of the run, in the UI, status line and headless modes (0 runs until quit)")
+	focus := flag.String("focus", ui.FocusAll, "Show only one component's power ("+strings.Join(ui.Focuses(), ", ")+"); needs per-component data such as macOS powermetrics under sudo")
+	graphChars := flag.String("graph-chars", ui.DefaultGraphChars, "Characters to draw the graph with ("+strings.Join(ui.GraphCharsets(), ", ")+"); ascii suits limited fonts")
+	graphScale := flag.String("graph-scale", string(ui.GraphScaleLinear), "Graph Y axis scale: linear or log")
+	graphWindow := flag.Duration("graph-window", 0, "Only plot this much recent history in the graph (0 plots all of -history)")
+	peakWindow := flag.Duration("peak-window", ui.DefaultPeakWindow, "Show the peak power within this much recent history next to the stats (0 hides it)")
+	emaAlpha := flag.Float64("ema-alpha", power.DefaultEMAAlpha, "Weight of each new reading in the moving average shown by -title-metric ema, from just above 0 (steadiest) to 1")
+	statsWindow := flag.Duration("stats-window", 0, "Only compute avg, min, max and trend over this much recent history (0 uses all of -history)")
+	maxAbsDelta := flag.Float64("max-abs-delta", 0, "Drop readings more than this many watts from the recent median (0 disables)")
+	maxRelDelta := flag.Float64("max-rel-delta", 0, "Drop readings more than this fraction from the recent median (0 disables)")
+	medianWindow := flag.Int("median", 1, "Report the median watts of the last this many reads, to smooth out noisy samples (1 disables)")
+	alertThreshold := flag.Float64("alert", 0, "Highlight power and ring the terminal bell when it rises above this many watts (0 disables)")
+	batteryHigh := flag.Float64("battery-high", ui.DefaultBatteryHighThreshold, "Color the battery percentage as high at or above this level")
+	batteryLow := flag.Float64("battery-low", ui.DefaultBatteryLowThreshold, "Color the battery percentage as low below this level")
+	statePath := flag.String("state", "", "Save the history to this file on exit and restore it on the next start")
+	clearState := flag.Bool("clear-state", false, "Make the clear key also empty the -state file, for a fresh start after restarting")
+	recordPath := flag.String("record", "", "Append every raw read, including errors, to this file as JSON lines for bug reports")
+	logPath := flag.String("log", "", "Append every reading to this CSV file")
+	metricsFile := flag.String("metrics-file", "", "Atomically write Prometheus metrics to this file after every reading")
+	prometheusAddr := flag.String("prometheus", "", "Serve Prometheus metrics at /metrics on this address (e.g. :9101)")
+	webAddr := flag.String("web", "", "Serve a self-refreshing dashboard page at / on this address (e.g. :8080)")
+	headless := flag.Bool("headless", false, "Run without the UI, only feeding -log, -metrics-file, -prometheus and -web")
+	titleMetric := flag.String("title-metric", "", "Secondary metric to show next to the title ("+strings.Join(ui.TitleMetrics(), ", ")+")")
+	startupRetries := flag.Int("startup-retries", 3, "In headless modes, -format statusline and -once, retry the first reading this many times before giving up")
+	format := flag.String("format", "tui", "Output format: tui, or statusline for one line refreshed in place (e.g. for tmux)")
+	once := flag.Bool("once", false, "Print a single reading and exit (exit code 1 if unsupported, 2 if the read fails)")
+	jsonOutput := flag.Bool("json", false, "Write readings as JSON lines to stdout instead of showing the UI")
+	keymapPath := flag.String("keymap", "", "Load key bindings from this file")
+	inputPipe := flag.String("input-pipe", "", "Read JSON-line readings pushed to this named pipe instead of the system monitor")
+	inputFormat := flag.String("input-format", power.PipeFormatJSON, "Format of the lines pushed to -input-pipe: "+strings.Join(power.PipeFormats(), ", "))
+	replayPath := flag.String("replay", "", "Replay readings from a -json, -record or -log file instead of the system monitor, one per -interval")
+	replayLoop := flag.Bool("replay-loop", false, "Start -replay over from the beginning after the last reading")
+	smoothWindow := flag.Int("smooth", 1, "Smooth the graph with a centered moving average over this many points (1 disables)")
+	highlightAvg := flag.Bool("highlight-average", false, "Color graph bars above the average differently from those below it")
+	aboveAvgColor := flag.String("above-average-color", ui.DefaultAboveAverageColor, "Color for graph bars above the average with -highlight-average")
+	belowAvgColor := flag.String("below-average-color", ui.DefaultBelowAverageColor, "Color for graph bars at or below the average with -highlight-average")
+	maxWatts := flag.Float64("max-watts", power.DefaultMaxWatts, "Clamp readings above this many watts, so a misparsed value can't wreck the graph's scale")
+	graphValue := flag.Bool("graph-value", false, "Show the latest value as text right after the graph")
+	referenceLines := flag.Bool("reference-lines", false, "Draw dashed lines across the graph at the average and the -alert threshold")
+	verboseSummary := flag.Bool("verbose-summary", false, "Print a per-minute power breakdown when the session ends")
+	noColor := flag.Bool("no-color", os.Getenv("NO_COLOR") != "", "Render the UI without colors (also enabled by the NO_COLOR environment variable)")
+	quiet := flag.Bool("quiet", false, "Hide the tip to run with sudo and show read errors as a single dim line, e.g. for screenshots")
+	calibrate := flag.Bool("calibrate", false, "Experimental: measure powermon's own power draw at startup and subtract it from readings")
+	macSampleCount := flag.Int("mac-sample-count", 1, "Number of powermetrics samples to average per reading (macOS)")
+	macStream := flag.Bool("mac-stream", false, "Keep one powermetrics process running instead of starting one per reading (macOS, needs sudo)")
+	macPowerMetric := flag.String("mac-power-metric", power.PowerMetricAdapter, "Power figure battery Macs report: adapter, system (leaves out charging) or battery (macOS)")
 
 	flag.Parse()
 
@@ -32,35 +96,603 @@ func main() {
 		if buildTime != "unknown" {
 			fmt.Printf("Built: %s\n", buildTime)
 		}
-		os.Exit(0)
+		return 0
+	}
+
+	if *titleMetric != "" && !ui.IsTitleMetric(*titleMetric) {
+		fmt.Fprintf(os.Stderr, "Error: unknown title metric %q (choose from %s)\n", *titleMetric, strings.Join(ui.TitleMetrics(), ", "))
+		return 1
+	}
+
+	if *clearState && *statePath == "" {
+		fmt.Fprintf(os.Stderr, "Error: -clear-state requires -state\n")
+		return 1
+	}
+
+	if *emaAlpha <= 0 || *emaAlpha > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -ema-alpha must be above 0 and at most 1, got %g\n", *emaAlpha)
+		return 1
+	}
+
+	if *batteryLow <= 0 || *batteryLow >= *batteryHigh || *batteryHigh > 100 {
+		fmt.Fprintf(os.Stderr, "Error: -battery-low and -battery-high must satisfy 0 < low < high <= 100, got %g and %g\n", *batteryLow, *batteryHigh)
+		return 1
+	}
+
+	if *medianWindow < 1 {
+		fmt.Fprintf(os.Stderr, "Error: -median must be at least 1, got %d\n", *medianWindow)
+		return 1
+	}
+
+	if !ui.IsFocus(*focus) {
+		fmt.Fprintf(os.Stderr, "Error: unknown focus %q (choose from %s)\n", *focus, strings.Join(ui.Focuses(), ", "))
+		return 1
+	}
+
+	if !ui.IsGraphCharset(*graphChars) {
+		fmt.Fprintf(os.Stderr, "Error: unknown graph chars %q (choose from %s)\n", *graphChars, strings.Join(ui.GraphCharsets(), ", "))
+		return 1
+	}
+
+	scale := ui.GraphScale(*graphScale)
+	if scale != ui.GraphScaleLinear && scale != ui.GraphScaleLog {
+		fmt.Fprintf(os.Stderr, "Error: unknown graph scale %q (choose from linear, log)\n", *graphScale)
+		return 1
+	}
+
+	if *format != "tui" && *format != "statusline" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (choose from tui, statusline)\n", *format)
+		return 1
+	}
+
+	var keyMap ui.KeyMap
+	if *keymapPath != "" {
+		f, err := os.Open(*keymapPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening keymap: %v\n", err)
+			return 1
+		}
+		keyMap, err = ui.LoadKeyMap(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading keymap %s: %v\n", *keymapPath, err)
+			return 1
+		}
 	}
 
 	// Create the power monitor
-	monitor := power.NewMonitor()
+	var monitor power.Monitor
+	switch {
+	case *inputPipe != "" && *replayPath != "":
+		fmt.Fprintln(os.Stderr, "Error: -input-pipe and -replay can't be used together")
+		return 1
+	case *inputPipe != "":
+		pipe := power.NewPipeMonitor(*inputPipe)
+		if err := pipe.SetFormat(*inputFormat); err != nil {
+			pipe.Close()
+			fmt.Fprintf(os.Stderr, "Error: -input-format: %v\n", err)
+			return 1
+		}
+		monitor = pipe
+	case *replayPath != "":
+		readings, err := readReplayFile(*replayPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading replay file: %v\n", err)
+			return 1
+		}
+		monitor = power.NewReplayMonitor(readings, *replayLoop)
+	default:
+		monitor = power.NewMonitor()
+	}
+	// Close whatever monitor ends up wrapping this one on the way out, so
+	// wrappers get to pass it down
+	defer func() {
+		if err := power.Close(monitor); err != nil {
+			fmt.Fprintf(os.Stderr, "Error closing monitor: %v\n", err)
+		}
+	}()
+	if counter, ok := monitor.(power.SampleCounter); ok {
+		counter.SetSampleCount(*macSampleCount)
+	}
+	setter, _ := monitor.(power.IntervalSetter)
+	if setter != nil {
+		setter.SetInterval(*refreshInterval)
+	}
+	if selector, ok := monitor.(power.PowerMetricSelector); ok {
+		if err := selector.SetPowerMetric(*macPowerMetric); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	if preferrer, ok := monitor.(power.ComponentPreferrer); ok && *focus != ui.FocusAll {
+		if !preferrer.PreferComponents() {
+			fmt.Fprintf(os.Stderr, "Warning: -focus %s needs per-component power; run with sudo\n", *focus)
+		}
+	}
+
+	// The stream samples at the refresh interval, so it's kept to follow
+	// changes to it in the UI
+	var streamer power.Streamer
+	if s, ok := monitor.(power.Streamer); ok && *macStream {
+		if err := s.StartStreaming(*refreshInterval); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: -mac-stream: %v\n", err)
+		} else {
+			streamer = s
+		}
+	}
 
 	// Check if power monitoring is supported
 	if !monitor.IsSupported() {
+		if checker, ok := monitor.(ui.SudoChecker); ok && checker.NeedsSudo() {
+			fmt.Fprintf(os.Stderr, "Error: Power monitoring on this system needs elevated privileges; run powermon with sudo.\n")
+			fmt.Fprintf(os.Stderr, "Monitor: %s\n", monitor.Name())
+			return 1
+		}
 		fmt.Fprintf(os.Stderr, "Error: Power monitoring is not supported on this system.\n")
 		fmt.Fprintf(os.Stderr, "Monitor: %s\n", monitor.Name())
-		os.Exit(1)
+		return 1
 	}
 
+	// Optionally record a trace of raw reads
+	if *recordPath != "" {
+		traceFile, err := os.OpenFile(*recordPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening trace file: %v\n", err)
+			return 1
+		}
+		defer traceFile.Close()
+		monitor = power.NewRecordingMonitor(monitor, traceFile)
+	}
+
+	// Optionally estimate and subtract our own overhead
+	var overhead float64
+	if *calibrate {
+		phase := max(calibrationPhase, 5*(*refreshInterval))
+		fmt.Fprintf(os.Stderr, "Calibrating for about %s, keep the system idle...\n", 2*phase)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		calibration, err := power.Calibrate(ctx, monitor, *refreshInterval, phase)
+		stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error calibrating: %v\n", err)
+			return 1
+		}
+		overhead = calibration.Overhead()
+		fmt.Fprintf(os.Stderr, "tool overhead ~%.1fW (measured with %s)\n", overhead, calibration.Source)
+		monitor = power.NewCalibratedMonitor(monitor, calibration)
+	}
+
+	// Optionally smooth noisy samples before anything else sees them
+	if *medianWindow > 1 {
+		monitor = power.NewMedianFilterMonitor(monitor, *medianWindow)
+	}
+
+	// Optionally log every reading to a CSV file
+	if *logPath != "" {
+		logFile, err := os.OpenFile(*logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening log file: %v\n", err)
+			return 1
+		}
+		defer logFile.Close()
+
+		info, err := logFile.Stat()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening log file: %v\n", err)
+			return 1
+		}
+
+		csvMonitor, err := power.NewCSVMonitor(monitor, logFile, info.Size() == 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing log file: %v\n", err)
+			return 1
+		}
+		monitor = csvMonitor
+	}
+
+	// Optionally export metrics for node_exporter's textfile collector
+	if *metricsFile != "" {
+		monitor = metrics.NewFileMonitor(monitor, *metricsFile)
+	}
+
+	// Optionally serve metrics over HTTP
+	if *prometheusAddr != "" {
+		exporter := metrics.NewExporter(monitor)
+		monitor = exporter
+
+		server, err := serve(*prometheusAddr, metrics.NewServeMux(exporter))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving metrics: %v\n", err)
+			return 1
+		}
+		defer server.Close()
+	}
+
+	// The readings on screen, which the dashboard shows too, so it follows
+	// the UI's clamping, filters and clearing
+	history := power.NewHistory(ui.HistorySize(*historyDuration, *refreshInterval), *historyDuration)
+
+	// Optionally serve a dashboard page over HTTP
+	if *webAddr != "" {
+		dashboard := web.NewDashboard(history, *refreshInterval)
+
+		server, err := serve(*webAddr, web.NewServeMux(dashboard))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving dashboard: %v\n", err)
+			return 1
+		}
+		defer server.Close()
+	}
+
+	// Optionally summarize the session per minute
+	var summary *power.SummaryMonitor
+	if *verboseSummary {
+		summary = power.NewSummaryMonitor(monitor)
+		monitor = summary
+	}
+
+	// Take a single reading for scripts
+	if *once {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		reading, err := power.FirstReading(ctx, monitor, *startupRetries, *refreshInterval)
+		if power.ReadFailed(err) {
+			fmt.Fprintf(os.Stderr, "Error reading power: %v\n", err)
+			return 2
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Println(formatOnce(reading))
+		return 0
+	}
+
+	// With -duration, keep every reading of the run for the exit summary,
+	// apart from the history the UI shows
+	var runHistory *power.History
+	if *duration > 0 {
+		runHistory = power.NewHistory(ui.HistorySize(*duration, *refreshInterval), *duration+*refreshInterval)
+	}
+
+	// A single refreshing line instead of the full UI
+	if *format == "statusline" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if *duration > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *duration)
+			defer cancel()
+		}
+
+		var onReading func(power.Reading)
+		if runHistory != nil {
+			onReading = runHistory.Add
+		}
+		err := runStatusLine(ctx, os.Stdout, monitor, *refreshInterval, *startupRetries, history, *statsWindow, onReading)
+		if summary != nil {
+			printSummary(os.Stdout, summary)
+		}
+		if runHistory != nil {
+			fmt.Print(ui.Summary(runHistory, *peakWindow))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Headless modes skip the UI entirely
+	if *jsonOutput || *headless {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if *duration > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *duration)
+			defer cancel()
+		}
+
+		onReading := func(r power.Reading) {
+			if runHistory != nil {
+				runHistory.Add(r)
+			}
+			if *jsonOutput {
+				writeJSONLine(os.Stdout, r)
+			}
+		}
+		err := runHeadless(ctx, monitor, *refreshInterval, *startupRetries, history, onReading)
+
+		// Keep stdout parseable when it carries JSON lines
+		out := os.Stdout
+		if *jsonOutput {
+			out = os.Stderr
+		}
+		if summary != nil {
+			printSummary(out, summary)
+		}
+		if runHistory != nil {
+			fmt.Fprint(out, ui.Summary(runHistory, *peakWindow))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Restore the previous session's history, dropping anything older than
+	// -history
+	var state *power.StateFile
+	if *statePath != "" {
+		state = power.NewStateFile(*statePath)
+		if err := state.Load(history); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading state: %v\n", err)
+			return 1
+		}
+	}
+
+	// Reads and any background samplers share this context, which is
+	// canceled as soon as the UI quits
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// Create UI configuration
 	cfg := ui.Config{
-		Monitor:         monitor,
-		GraphWidth:      ui.DefaultGraphWidth,
-		GraphHeight:     ui.DefaultGraphHeight,
-		RefreshInterval: *refreshInterval,
-		HistoryDuration: *historyDuration,
-		MaxHistorySize:  int(historyDuration.Seconds()/refreshInterval.Seconds()) + 100,
+		Monitor:           monitor,
+		Context:           ctx,
+		Duration:          *duration,
+		GraphWidth:        ui.DefaultGraphWidth,
+		GraphHeight:       ui.DefaultGraphHeight,
+		RefreshInterval:   *refreshInterval,
+		HistoryDuration:   *historyDuration,
+		GraphWindow:       *graphWindow,
+		StatsWindow:       *statsWindow,
+		PeakWindow:        *peakWindow,
+		EMAAlpha:          *emaAlpha,
+		GraphScale:        scale,
+		GraphChars:        *graphChars,
+		Focus:             *focus,
+		MaxAbsDelta:       *maxAbsDelta,
+		MaxRelDelta:       *maxRelDelta,
+		MaxWatts:          *maxWatts,
+		TitleMetric:       *titleMetric,
+		KeyMap:            keyMap,
+		NoColor:           *noColor,
+		Quiet:             *quiet,
+		GraphValue:        *graphValue,
+		ReferenceLines:    *referenceLines,
+		SmoothWindow:      *smoothWindow,
+		HighlightAverage:  *highlightAvg,
+		AboveAverageColor: *aboveAvgColor,
+		BelowAverageColor: *belowAvgColor,
+		Overhead:          overhead,
+		AlertThreshold:    *alertThreshold,
+		History:           history,
+
+		BatteryHighThreshold: *batteryHigh,
+		BatteryLowThreshold:  *batteryLow,
+	}
+	if *clearState {
+		cfg.ClearStateFile = state
+	}
+	if runHistory != nil {
+		cfg.OnReading = runHistory.Add
+	}
+	if streamer != nil || setter != nil {
+		cfg.OnIntervalChange = func(interval time.Duration) error {
+			if setter != nil {
+				setter.SetInterval(interval)
+			}
+			if streamer != nil {
+				return streamer.StartStreaming(interval)
+			}
+			return nil
+		}
 	}
 
 	// Create and run the UI
 	model := ui.NewModel(cfg)
 	p := tea.NewProgram(model, tea.WithAltScreen())
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	cancel()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running power monitor: %v\n", err)
-		os.Exit(1)
+		return 1
+	}
+	if state != nil {
+		if err := state.Save(history); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving state: %v\n", err)
+			return 1
+		}
+	}
+	// -duration prints its own, more detailed summary
+	if runHistory != nil {
+		fmt.Print(ui.Summary(runHistory, *peakWindow))
+	} else if model, ok := final.(ui.Model); ok {
+		fmt.Println(model.Summary())
+	}
+	if summary != nil {
+		printSummary(os.Stdout, summary)
+	}
+	return 0
+}
+
+// serve starts an HTTP server for handler on addr in the background. The
+// address is bound before serve returns, so a port that's taken fails here
+// rather than after the UI has taken over the terminal.
+func serve(addr string, handler http.Handler) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		// Serve returns http.ErrServerClosed once the server is closed
+		_ = server.Serve(ln)
+	}()
+	return server, nil
+}
+
+// readReplayFile loads the readings recorded in the file at path.
+func readReplayFile(path string) ([]power.Reading, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return power.ReadReplay(f)
+}
+
+// printSummary writes the per-minute session breakdown to w.
+func printSummary(w io.Writer, summary *power.SummaryMonitor) {
+	minutes := summary.Minutes()
+	if len(minutes) == 0 {
+		fmt.Fprintln(w, "No readings recorded.")
+		return
+	}
+	fmt.Fprintln(w, "Per-minute summary:")
+	if err := power.WriteMinuteTable(w, minutes); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing summary: %v\n", err)
+	}
+}
+
+// formatOnce formats a reading compactly for -once, e.g.
+// "23.4W battery 78% discharging".
+func formatOnce(r power.Reading) string {
+	watts := fmt.Sprintf("%.1fW", r.Watts)
+	if !r.WattsAvailable {
+		watts = "watts unavailable"
+	}
+	if r.BatteryPercent < 0 {
+		if r.IsOnBattery {
+			return watts + " on battery"
+		}
+		return watts + " on AC"
+	}
+
+	state := "on AC"
+	if r.IsOnBattery {
+		state = "discharging"
+	} else if r.IsCharging {
+		state = "charging"
+	}
+	return fmt.Sprintf("%s battery %.0f%% %s", watts, r.BatteryPercent, state)
+}
+
+// runStatusLine rewrites a single status line on w after every reading until
+// ctx is canceled or a replay finishes. The cursor is hidden meanwhile and
+// restored on exit, with the last line left in place. Successful readings are
+// added to history and then passed to onReading unless it is nil. The first
+// reading is retried up to retries times; if it never succeeds,
+// runStatusLine returns an error wrapping power.ErrNoData without writing
+// anything.
+func runStatusLine(ctx context.Context, w io.Writer, monitor power.Monitor, interval time.Duration, retries int, history *power.History, statsWindow time.Duration, onReading func(power.Reading)) error {
+	reading, err := power.FirstReading(ctx, monitor, retries, interval)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if power.ReadFailed(err) {
+		return err
+	}
+
+	fmt.Fprint(w, "\x1b[?25l")
+	defer fmt.Fprint(w, "\x1b[?25h\n")
+
+	history.Add(reading)
+	if onReading != nil {
+		onReading(reading)
+	}
+	writeStatusLine(w, ui.StatusLine(reading, history.TrendPerMinuteOver(statsWindow)))
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(interval):
+	}
+
+	// Canceling on return stops the watch once a replay is done
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for result := range power.Watch(ctx, monitor, interval) {
+		if errors.Is(result.Err, power.ErrReplayDone) {
+			return nil
+		}
+		if errors.Is(result.Err, power.ErrNoNewReading) {
+			// Leave the last line up until a pushed source sends another
+			continue
+		}
+		line := "⚠ " + fmt.Sprint(result.Err)
+		if !power.ReadFailed(result.Err) {
+			history.Add(result.Reading)
+			if onReading != nil {
+				onReading(result.Reading)
+			}
+			line = ui.StatusLine(result.Reading, history.TrendPerMinuteOver(statsWindow))
+		}
+		writeStatusLine(w, line)
+	}
+	return nil
+}
+
+// writeStatusLine returns to the start of the line on w, writes line and
+// clears what's left of the previous one.
+func writeStatusLine(w io.Writer, line string) {
+	fmt.Fprintf(w, "\r%s\x1b[K", line)
+}
+
+// runHeadless reads from the monitor every interval until ctx is canceled or
+// a replay finishes, leaving any other output to the monitor's wrappers.
+// Successful readings are added to history and then passed to onReading
+// unless it is nil, so each carries the Seq it was given. The first reading
+// is retried up to retries times; if it never succeeds, runHeadless returns
+// an error wrapping power.ErrNoData.
+func runHeadless(ctx context.Context, monitor power.Monitor, interval time.Duration, retries int, history *power.History, onReading func(power.Reading)) error {
+	reading, err := power.FirstReading(ctx, monitor, retries, interval)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if power.ReadFailed(err) {
+		return err
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+
+	history.Add(reading)
+	if onReading != nil {
+		reading.Seq = history.Seq()
+		onReading(reading)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(interval):
+	}
+
+	engine := power.NewEngine(monitor, history, power.EngineConfig{
+		Interval:    interval,
+		ReadTimeout: ui.ReadTimeoutFor(interval),
+		OnReading:   onReading,
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "Error reading power: %v\n", err)
+		},
+	})
+	engine.Start(ctx)
+	<-engine.Done()
+	engine.Stop()
+	return nil
+}
+
+// writeJSONLine writes r to w as a -json line, reporting a failed write on
+// stderr.
+func writeJSONLine(w io.Writer, r power.Reading) {
+	if _, err := w.Write(power.JSONLine(r)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing reading: %v\n", err)
 	}
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+func TestServe(t *testing.T) {
+	t.Run("serves the handler once it returns", func(t *testing.T) {
+		// Find a free port to serve on
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := ln.Addr().String()
+		ln.Close()
+
+		server, err := serve(addr, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			io.WriteString(w, "ok")
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer server.Close()
+
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		if body, _ := io.ReadAll(resp.Body); string(body) != "ok" {
+			t.Errorf("expected the handler's response, got %q", body)
+		}
+	})
+
+	t.Run("fails when the address is taken", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+
+		if server, err := serve(ln.Addr().String(), http.NotFoundHandler()); err == nil {
+			server.Close()
+			t.Error("expected an error for an address in use")
+		}
+	})
+}
+
+func TestRunStatusLine(t *testing.T) {
+	t.Run("returns when a replay finishes", func(t *testing.T) {
+		monitor := power.NewReplayMonitor([]power.Reading{
+			{Watts: 10, WattsAvailable: true, BatteryPercent: -1},
+			{Watts: 12, WattsAvailable: true, BatteryPercent: -1},
+		}, false)
+		history := power.NewHistory(10, time.Minute)
+		runHistory := power.NewHistory(10, time.Minute)
+
+		var out bytes.Buffer
+		done := make(chan struct{})
+		go func() {
+			if err := runStatusLine(context.Background(), &out, monitor, time.Millisecond, 0, history, time.Minute, runHistory.Add); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("expected runStatusLine to return after the replay")
+		}
+		if history.Len() != 2 || runHistory.Len() != 2 {
+			t.Errorf("expected both readings in both histories, got %d and %d", history.Len(), runHistory.Len())
+		}
+		if !strings.Contains(out.String(), "12.0W") || strings.Contains(out.String(), "replay finished") {
+			t.Errorf("expected the last reading left in place, got %q", out.String())
+		}
+	})
+	t.Run("returns once canceled during a read", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		monitor := power.NewMockMonitor().WithReadDelay(time.Minute)
+
+		done := make(chan struct{})
+		go func() {
+			runStatusLine(ctx, io.Discard, monitor, time.Millisecond, 0, power.NewHistory(10, time.Minute), time.Minute, nil)
+			close(done)
+		}()
+		for monitor.ReadCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("expected runStatusLine to return once canceled")
+		}
+	})
+	t.Run("fails after retrying the first reading", func(t *testing.T) {
+		monitor := power.NewMockMonitor().WithError(errors.New("sensor offline"))
+
+		var out bytes.Buffer
+		err := runStatusLine(context.Background(), &out, monitor, time.Millisecond, 2, power.NewHistory(10, time.Minute), time.Minute, nil)
+		if !errors.Is(err, power.ErrNoData) {
+			t.Errorf("expected ErrNoData, got %v", err)
+		}
+		if monitor.ReadCount() != 3 {
+			t.Errorf("expected 3 attempts, got %d", monitor.ReadCount())
+		}
+		if out.Len() != 0 {
+			t.Errorf("expected nothing written, got %q", out.String())
+		}
+	})
+}
+
+func TestRunHeadless(t *testing.T) {
+	t.Run("writes JSON lines with increasing seq", func(t *testing.T) {
+		monitor := power.NewReplayMonitor([]power.Reading{
+			{Watts: 10, WattsAvailable: true, BatteryPercent: -1},
+			{Watts: 11, WattsAvailable: true, BatteryPercent: -1},
+			{Watts: 12, WattsAvailable: true, BatteryPercent: -1},
+		}, false)
+
+		var out bytes.Buffer
+		onReading := func(r power.Reading) { writeJSONLine(&out, r) }
+		if err := runHeadless(context.Background(), monitor, time.Millisecond, 0, power.NewHistory(10, time.Minute), onReading); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		dec := json.NewDecoder(&out)
+		var seqs []uint64
+		for dec.More() {
+			var line struct {
+				Seq uint64 `json:"seq"`
+			}
+			if err := dec.Decode(&line); err != nil {
+				t.Fatalf("decoding line: %v", err)
+			}
+			seqs = append(seqs, line.Seq)
+		}
+		if len(seqs) != 3 || seqs[0] != 1 || seqs[1] != 2 || seqs[2] != 3 {
+			t.Errorf("expected seq 1, 2, 3, got %v", seqs)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+// contentType is the Prometheus text exposition format content type.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Exporter wraps another Monitor, remembers the latest successful reading,
+// and serves it as Prometheus metrics over HTTP.
+type Exporter struct {
+	power.Monitor
+	mu     sync.RWMutex
+	latest power.Reading
+	ok     bool
+}
+
+// NewExporter wraps monitor so its readings can be scraped.
+func NewExporter(monitor power.Monitor) *Exporter {
+	return &Exporter{Monitor: monitor}
+}
+
+// NeedsSudo reports whether the wrapped monitor needs sudo.
+func (e *Exporter) NeedsSudo() bool {
+	return power.NeedsSudo(e.Monitor)
+}
+
+// Close closes the wrapped monitor.
+func (e *Exporter) Close() error {
+	return power.Close(e.Monitor)
+}
+
+// Read reads from the wrapped monitor and records the reading on success.
+func (e *Exporter) Read(ctx context.Context) (power.Reading, error) {
+	reading, err := e.Monitor.Read(ctx)
+	if power.ReadFailed(err) {
+		return reading, err
+	}
+	e.mu.Lock()
+	e.latest = reading
+	e.ok = true
+	e.mu.Unlock()
+	return reading, err
+}
+
+// Latest returns the most recent reading, or false if nothing has been read yet.
+func (e *Exporter) Latest() (power.Reading, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.latest, e.ok
+}
+
+// ServeHTTP writes the latest reading in the Prometheus text format. It
+// responds with 503 until the first reading is available.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	reading, ok := e.Latest()
+	if !ok {
+		http.Error(w, "no readings yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, reading); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// NewServeMux returns a mux serving the exporter at /metrics.
+func NewServeMux(e *Exporter) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	return mux
+}
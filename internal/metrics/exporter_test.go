@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+func TestExporter(t *testing.T) {
+	t.Run("implements Monitor interface", func(t *testing.T) {
+		var _ power.Monitor = NewExporter(power.NewMockMonitor())
+	})
+
+	t.Run("closes the wrapped monitor", func(t *testing.T) {
+		mock := power.NewMockMonitor()
+		if err := NewExporter(mock).Close(); err != nil || !mock.Closed() {
+			t.Errorf("expected wrapped monitor to be closed (err %v)", err)
+		}
+	})
+
+	t.Run("stops reading once canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		m := NewExporter(power.NewMockMonitor().WithReadDelay(time.Minute))
+
+		start := time.Now()
+		if _, err := m.Read(ctx); !errors.Is(err, context.Canceled) || time.Since(start) > time.Second {
+			t.Errorf("expected a prompt context.Canceled, got %v after %v", err, time.Since(start))
+		}
+	})
+
+	t.Run("returns 503 before first reading", func(t *testing.T) {
+		e := NewExporter(power.NewMockMonitor())
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", rec.Code)
+		}
+	})
+
+	t.Run("serves latest reading", func(t *testing.T) {
+		mock := power.NewMockMonitor().WithReadings(
+			power.Reading{Watts: 5, BatteryPercent: 50},
+			power.Reading{Watts: 15, BatteryPercent: 49, IsOnBattery: true},
+		)
+		e := NewExporter(mock)
+		_, _ = e.Read(context.Background())
+		_, _ = e.Read(context.Background())
+
+		srv := httptest.NewServer(NewServeMux(e))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/metrics")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+			t.Errorf("unexpected content type %q", resp.Header.Get("Content-Type"))
+		}
+		for _, line := range []string{
+			"powermon_watts 15\n",
+			"powermon_battery_percent 49\n",
+			"powermon_is_charging 0\n",
+			"powermon_is_on_battery 1\n",
+		} {
+			if !strings.Contains(string(body), line) {
+				t.Errorf("expected body to contain %q, got:\n%s", line, body)
+			}
+		}
+	})
+
+	t.Run("keeps last good reading on error", func(t *testing.T) {
+		mock := power.NewMockMonitor()
+		e := NewExporter(mock)
+		_, _ = e.Read(context.Background())
+
+		mock.WithError(errors.New("test error"))
+		if _, err := e.Read(context.Background()); err == nil {
+			t.Error("expected error")
+		}
+
+		latest, ok := e.Latest()
+		if !ok || latest.Watts != 10 {
+			t.Errorf("expected last good reading to be kept, got %v (ok=%v)", latest.Watts, ok)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+// Package metrics formats power readings as Prometheus metrics.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+// metric describes a single gauge in the exposition output.
+type metric struct {
+	name  string
+	help  string
+	value func(r power.Reading) float64
+}
+
+// metricsList is the set of gauges exported for every reading.
+var metricsList = []metric{
+	{"powermon_watts", "Current power consumption in watts.", func(r power.Reading) float64 { return r.Watts }},
+	{"powermon_battery_percent", "Battery charge percentage, or -1 if not available.", func(r power.Reading) float64 { return r.BatteryPercent }},
+	{"powermon_is_charging", "Whether the battery is charging (1) or not (0).", func(r power.Reading) float64 { return boolToFloat(r.IsCharging) }},
+	{"powermon_is_on_battery", "Whether the system is running on battery power (1) or not (0).", func(r power.Reading) float64 { return boolToFloat(r.IsOnBattery) }},
+}
+
+// Write writes r to w in the Prometheus text exposition format.
+func Write(w io.Writer, r power.Reading) error {
+	for _, m := range metricsList {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", m.name, m.help, m.name, m.name, m.value(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFile atomically replaces the file at path with metrics for r. The
+// metrics are written to a temporary file in the same directory and renamed
+// into place, so readers such as node_exporter never see a partial file.
+func WriteFile(path string, r power.Reading) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if err := Write(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	// CreateTemp uses 0600; the collector usually runs as another user
+	if err := os.Chmod(tmpPath, 0o644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// FileMonitor wraps another Monitor and rewrites a metrics file after every
+// successful reading.
+type FileMonitor struct {
+	power.Monitor
+	path string
+}
+
+// NewFileMonitor wraps monitor so that each reading is written to path.
+func NewFileMonitor(monitor power.Monitor, path string) *FileMonitor {
+	return &FileMonitor{Monitor: monitor, path: path}
+}
+
+// NeedsSudo reports whether the wrapped monitor needs sudo.
+func (m *FileMonitor) NeedsSudo() bool {
+	return power.NeedsSudo(m.Monitor)
+}
+
+// Close closes the wrapped monitor.
+func (m *FileMonitor) Close() error {
+	return power.Close(m.Monitor)
+}
+
+// Read reads from the wrapped monitor and writes the metrics file on success.
+func (m *FileMonitor) Read(ctx context.Context) (power.Reading, error) {
+	reading, err := m.Monitor.Read(ctx)
+	if power.ReadFailed(err) {
+		return reading, err
+	}
+	if werr := WriteFile(m.path, reading); werr != nil {
+		return reading, fmt.Errorf("%w: %w", power.ErrLogWrite, werr)
+	}
+	return reading, err
+}
+
+// boolToFloat converts a bool into a 0/1 gauge value.
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
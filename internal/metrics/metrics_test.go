@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+func TestWrite(t *testing.T) {
+	t.Run("writes all gauges", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := power.Reading{Watts: 12.5, BatteryPercent: 80, IsCharging: true}
+
+		if err := Write(&buf, r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		out := buf.String()
+		for _, line := range []string{
+			"# TYPE powermon_watts gauge",
+			"powermon_watts 12.5\n",
+			"powermon_battery_percent 80\n",
+			"powermon_is_charging 1\n",
+			"powermon_is_on_battery 0\n",
+		} {
+			if !strings.Contains(out, line) {
+				t.Errorf("expected output to contain %q, got:\n%s", line, out)
+			}
+		}
+	})
+}
+
+func TestWriteFile(t *testing.T) {
+	t.Run("writes metrics file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "powermon.prom")
+
+		if err := WriteFile(path, power.Reading{Watts: 7.25, BatteryPercent: -1}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(data), "powermon_watts 7.25\n") {
+			t.Errorf("expected watts metric, got:\n%s", data)
+		}
+		if !strings.Contains(string(data), "powermon_battery_percent -1\n") {
+			t.Errorf("expected battery metric, got:\n%s", data)
+		}
+	})
+
+	t.Run("replaces existing file without leaving temp files", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "powermon.prom")
+
+		if err := os.WriteFile(path, []byte("stale\n"), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := WriteFile(path, power.Reading{Watts: 3}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), "stale") {
+			t.Error("expected existing file to be replaced")
+		}
+
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			names := make([]string, 0, len(entries))
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			t.Errorf("expected only the metrics file, found %v", names)
+		}
+
+		info, _ := os.Stat(path)
+		if info.Mode().Perm() != 0o644 {
+			t.Errorf("expected mode 0644, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("fails for missing directory", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing", "powermon.prom")
+
+		if err := WriteFile(path, power.Reading{}); err == nil {
+			t.Error("expected error for missing directory")
+		}
+	})
+}
+
+func TestFileMonitor(t *testing.T) {
+	t.Run("implements Monitor interface", func(t *testing.T) {
+		var _ power.Monitor = NewFileMonitor(power.NewMockMonitor(), "")
+	})
+
+	t.Run("closes the wrapped monitor", func(t *testing.T) {
+		mock := power.NewMockMonitor()
+		if err := NewFileMonitor(mock, "").Close(); err != nil || !mock.Closed() {
+			t.Errorf("expected wrapped monitor to be closed (err %v)", err)
+		}
+	})
+
+	t.Run("stops reading once canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		m := NewFileMonitor(power.NewMockMonitor().WithReadDelay(time.Minute), filepath.Join(t.TempDir(), "powermon.prom"))
+
+		start := time.Now()
+		if _, err := m.Read(ctx); !errors.Is(err, context.Canceled) || time.Since(start) > time.Second {
+			t.Errorf("expected a prompt context.Canceled, got %v after %v", err, time.Since(start))
+		}
+	})
+
+	t.Run("writes file on read", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "powermon.prom")
+		m := NewFileMonitor(power.NewMockMonitor(), path)
+
+		if _, err := m.Read(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("expected metrics file: %v", err)
+		}
+		if !strings.Contains(string(data), "powermon_watts 10\n") {
+			t.Errorf("expected watts metric, got:\n%s", data)
+		}
+	})
+
+	t.Run("does not write on read error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "powermon.prom")
+		m := NewFileMonitor(power.NewMockMonitor().WithError(errors.New("test error")), path)
+
+		if _, err := m.Read(context.Background()); err == nil {
+			t.Error("expected error")
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Error("expected no metrics file to be written")
+		}
+	})
+}
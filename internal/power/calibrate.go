@@ -0,0 +1,196 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calibration is an estimate of the power powermon itself adds by sampling,
+// measured by Calibrate. It is experimental: background activity during either
+// phase skews the result.
+type Calibration struct {
+	Idle     float64 // Average watts with sampling off
+	Sampling float64 // Average watts with sampling on
+	Source   string  // What measured both phases, "rapl" or a monitor name
+}
+
+// Overhead returns the estimated watts added by sampling, never negative.
+func (c Calibration) Overhead() float64 {
+	return max(0, c.Sampling-c.Idle)
+}
+
+// Subtract removes the estimated overhead from a raw reading in watts,
+// clamping at zero.
+func (c Calibration) Subtract(watts float64) float64 {
+	return max(0, watts-c.Overhead())
+}
+
+// energyCounter returns an increasing energy total in joules, and the total
+// after which it wraps to zero or 0 if that isn't known.
+type energyCounter func() (energy, maxRange float64, err error)
+
+// raplEnergy reads the RAPL package energy counter.
+func raplEnergy() (float64, float64, error) {
+	uj, err := readMicrojoules(filepath.Join(raplPackagePath, "energy_uj"))
+	if err != nil {
+		return 0, 0, err
+	}
+	// Without a range a wrap can't be measured, which averagePower reports
+	maxRange, _ := readMicrojoules(filepath.Join(raplPackagePath, "max_energy_range_uj"))
+	return uj / 1e6, maxRange / 1e6, nil
+}
+
+// readMicrojoules reads a sysfs energy file.
+func readMicrojoules(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+}
+
+// Calibrate estimates powermon's own power draw by comparing a phase of
+// idling with a phase of reading m every interval, each lasting phase. Where
+// RAPL is available its energy counter measures both phases, since reading it
+// costs next to nothing. Otherwise m measures both: once after the idle phase
+// and on every read during the sampling phase.
+func Calibrate(ctx context.Context, m Monitor, interval, phase time.Duration) (Calibration, error) {
+	return calibrate(ctx, m, interval, phase, raplEnergy)
+}
+
+// calibrate implements Calibrate with a pluggable energy counter. A nil
+// counter, or one that fails, falls back to measuring with m.
+func calibrate(ctx context.Context, m Monitor, interval, phase time.Duration, counter energyCounter) (Calibration, error) {
+	if interval <= 0 || phase < interval {
+		return Calibration{}, fmt.Errorf("calibration phase %v must be at least one interval %v", phase, interval)
+	}
+
+	if counter != nil {
+		if _, _, err := counter(); err == nil {
+			return calibrateEnergy(ctx, m, interval, phase, counter)
+		}
+	}
+
+	if err := sleepContext(ctx, phase); err != nil {
+		return Calibration{}, err
+	}
+	reading, err := m.Read(ctx)
+	if err != nil {
+		return Calibration{}, fmt.Errorf("idle reading: %w", err)
+	}
+	sampling, err := sample(ctx, m, interval, phase)
+	if err != nil {
+		return Calibration{}, err
+	}
+	return Calibration{Idle: reading.Watts, Sampling: sampling, Source: m.Name()}, nil
+}
+
+// calibrateEnergy measures both phases as average power from counter.
+func calibrateEnergy(ctx context.Context, m Monitor, interval, phase time.Duration, counter energyCounter) (Calibration, error) {
+	idle, err := averagePower(counter, func() error { return sleepContext(ctx, phase) })
+	if err != nil {
+		return Calibration{}, fmt.Errorf("idle phase: %w", err)
+	}
+	sampling, err := averagePower(counter, func() error {
+		_, err := sample(ctx, m, interval, phase)
+		return err
+	})
+	if err != nil {
+		return Calibration{}, fmt.Errorf("sampling phase: %w", err)
+	}
+	return Calibration{Idle: idle, Sampling: sampling, Source: "rapl"}, nil
+}
+
+// averagePower runs fn and returns the average watts counter saw meanwhile.
+func averagePower(counter energyCounter, fn func() error) (float64, error) {
+	before, _, err := counter()
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	if err := fn(); err != nil {
+		return 0, err
+	}
+	after, maxRange, err := counter()
+	if err != nil {
+		return 0, err
+	}
+	elapsed := time.Since(start).Seconds()
+	if elapsed <= 0 {
+		return 0, errors.New("no time elapsed")
+	}
+	energy, ok := energyDelta(before, after, maxRange)
+	if !ok {
+		// The counter wrapped around without a known range
+		return 0, errors.New("energy counter wrapped")
+	}
+	return energy / elapsed, nil
+}
+
+// sample reads m every interval for phase and returns the average watts.
+func sample(ctx context.Context, m Monitor, interval, phase time.Duration) (float64, error) {
+	var sum float64
+	var n int
+	for elapsed := time.Duration(0); elapsed < phase; elapsed += interval {
+		if n > 0 {
+			if err := sleepContext(ctx, interval); err != nil {
+				return 0, err
+			}
+		}
+		reading, err := m.Read(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("sampling reading: %w", err)
+		}
+		sum += reading.Watts
+		n++
+	}
+	return sum / float64(n), nil
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// CalibratedMonitor wraps another Monitor and subtracts powermon's own
+// estimated overhead from every reading.
+type CalibratedMonitor struct {
+	Monitor
+	calibration Calibration
+}
+
+// NewCalibratedMonitor wraps monitor so that readings exclude c's overhead.
+func NewCalibratedMonitor(monitor Monitor, c Calibration) *CalibratedMonitor {
+	return &CalibratedMonitor{Monitor: monitor, calibration: c}
+}
+
+// NeedsSudo reports whether the wrapped monitor needs sudo.
+func (m *CalibratedMonitor) NeedsSudo() bool {
+	return NeedsSudo(m.Monitor)
+}
+
+// Close closes the wrapped monitor.
+func (m *CalibratedMonitor) Close() error {
+	return Close(m.Monitor)
+}
+
+// Read reads from the wrapped monitor and subtracts the overhead.
+func (m *CalibratedMonitor) Read(ctx context.Context) (Reading, error) {
+	reading, err := m.Monitor.Read(ctx)
+	if ReadFailed(err) {
+		return reading, err
+	}
+	reading.Watts = m.calibration.Subtract(reading.Watts)
+	return reading, err
+}
@@ -0,0 +1,197 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalibration_Subtract(t *testing.T) {
+	tests := []struct {
+		name         string
+		calibration  Calibration
+		raw          float64
+		wantOverhead float64
+		want         float64
+	}{
+		{
+			name:         "subtracts overhead",
+			calibration:  Calibration{Idle: 5.0, Sampling: 5.4},
+			raw:          12.0,
+			wantOverhead: 0.4,
+			want:         11.6,
+		},
+		{
+			name:         "never adds power when sampling measured lower",
+			calibration:  Calibration{Idle: 5.4, Sampling: 5.0},
+			raw:          12.0,
+			wantOverhead: 0,
+			want:         12.0,
+		},
+		{
+			name:         "clamps at zero",
+			calibration:  Calibration{Idle: 1.0, Sampling: 2.0},
+			raw:          0.5,
+			wantOverhead: 1.0,
+			want:         0,
+		},
+		{
+			name: "zero calibration is a no-op",
+			raw:  7.5,
+			want: 7.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calibration.Overhead(); math.Abs(got-tt.wantOverhead) > 1e-9 {
+				t.Errorf("Overhead() = %f, want %f", got, tt.wantOverhead)
+			}
+			if got := tt.calibration.Subtract(tt.raw); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Subtract(%f) = %f, want %f", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	t.Run("measures with the monitor without an energy counter", func(t *testing.T) {
+		mock := NewMockMonitor().WithReadings(
+			Reading{Watts: 10.0},
+			Reading{Watts: 10.5},
+			Reading{Watts: 10.3},
+		)
+
+		c, err := calibrate(context.Background(), mock, time.Millisecond, 2*time.Millisecond, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.Idle != 10.0 || math.Abs(c.Sampling-10.4) > 1e-9 {
+			t.Errorf("got idle %f sampling %f, want 10.0 and 10.4", c.Idle, c.Sampling)
+		}
+		if c.Source != "mock" {
+			t.Errorf("Source = %q, want mock", c.Source)
+		}
+		if math.Abs(c.Overhead()-0.4) > 1e-9 {
+			t.Errorf("Overhead() = %f, want 0.4", c.Overhead())
+		}
+	})
+
+	t.Run("falls back when the energy counter is unavailable", func(t *testing.T) {
+		counter := func() (float64, float64, error) { return 0, 0, errors.New("no rapl") }
+		mock := NewMockMonitor()
+
+		c, err := calibrate(context.Background(), mock, time.Millisecond, time.Millisecond, counter)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.Source != "mock" {
+			t.Errorf("Source = %q, want mock", c.Source)
+		}
+	})
+
+	t.Run("uses the energy counter when available", func(t *testing.T) {
+		start := time.Now()
+		counter := func() (float64, float64, error) { return time.Since(start).Seconds() * 3, 0, nil }
+		mock := NewMockMonitor()
+
+		c, err := calibrate(context.Background(), mock, time.Millisecond, 2*time.Millisecond, counter)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.Source != "rapl" {
+			t.Errorf("Source = %q, want rapl", c.Source)
+		}
+		if mock.ReadCount() != 2 {
+			t.Errorf("expected 2 reads during the sampling phase, got %d", mock.ReadCount())
+		}
+	})
+
+	t.Run("rejects a phase shorter than the interval", func(t *testing.T) {
+		_, err := calibrate(context.Background(), NewMockMonitor(), time.Second, time.Millisecond, nil)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("returns read errors", func(t *testing.T) {
+		mock := NewMockMonitor().WithError(errors.New("boom"))
+		_, err := calibrate(context.Background(), mock, time.Millisecond, time.Millisecond, nil)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("stops when the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := calibrate(ctx, NewMockMonitor(), time.Second, time.Minute, nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestAveragePower_Wraparound(t *testing.T) {
+	// counter returns 100 and then 5, wrapping after maxRange
+	counter := func(maxRange float64) energyCounter {
+		values := []float64{100, 5}
+		return func() (float64, float64, error) {
+			v := values[0]
+			values = values[1:]
+			return v, maxRange, nil
+		}
+	}
+
+	t.Run("counts the energy across the wrap", func(t *testing.T) {
+		start := time.Now()
+		watts, err := averagePower(counter(120), func() error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// 25 J over a little more than the sleep
+		if want := 25 / time.Since(start).Seconds(); watts < want || watts > 2500 {
+			t.Errorf("averagePower() = %f, want about %f", watts, want)
+		}
+	})
+
+	t.Run("fails without a range", func(t *testing.T) {
+		if _, err := averagePower(counter(0), func() error { return nil }); err == nil {
+			t.Error("expected error when the counter goes backwards")
+		}
+	})
+}
+
+func TestCalibratedMonitor(t *testing.T) {
+	t.Run("implements Monitor interface", func(t *testing.T) {
+		var _ Monitor = &CalibratedMonitor{}
+	})
+
+	t.Run("subtracts overhead from readings", func(t *testing.T) {
+		mock := NewMockMonitor().WithReadings(Reading{Watts: 8.0, BatteryPercent: 50})
+		m := NewCalibratedMonitor(mock, Calibration{Idle: 4.0, Sampling: 4.5})
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.Watts-7.5) > 1e-9 {
+			t.Errorf("Watts = %f, want 7.5", reading.Watts)
+		}
+		if reading.BatteryPercent != 50 {
+			t.Errorf("BatteryPercent = %f, want 50", reading.BatteryPercent)
+		}
+	})
+
+	t.Run("passes through errors", func(t *testing.T) {
+		m := NewCalibratedMonitor(NewMockMonitor().WithError(errors.New("boom")), Calibration{})
+		if _, err := m.Read(context.Background()); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
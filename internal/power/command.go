@@ -0,0 +1,29 @@
+//go:build darwin || windows || freebsd || openbsd || netbsd
+
+package power
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+)
+
+// commandRunner runs an external command and returns its standard output.
+// Output captured before a failure is returned alongside the error.
+// Monitors that shell out to system utilities use it so tests can substitute
+// canned output.
+type commandRunner interface {
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// execRunner runs commands using os/exec.
+type execRunner struct{}
+
+// Run executes the command and returns its standard output.
+func (execRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	return out.Bytes(), err
+}
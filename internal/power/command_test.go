@@ -0,0 +1,73 @@
+//go:build darwin || windows || freebsd || openbsd || netbsd
+
+package power
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeRunner returns canned output keyed by full command line (e.g.
+// "ioreg -rc AGPMController") or, failing that, by command name, and counts
+// calls under both keys.
+type fakeRunner struct {
+	mu      sync.Mutex
+	outputs map[string]string
+	errs    map[string]error
+	calls   map[string]int
+	delay   time.Duration
+}
+
+// newFakeRunner creates a fakeRunner returning outputs keyed by command line
+// or name.
+func newFakeRunner(outputs map[string]string) *fakeRunner {
+	return &fakeRunner{
+		outputs: outputs,
+		errs:    map[string]error{},
+		calls:   map[string]int{},
+	}
+}
+
+// Run returns the canned output for the command.
+func (f *fakeRunner) Run(_ context.Context, name string, args ...string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	line := strings.Join(append([]string{name}, args...), " ")
+	f.calls[name]++
+	f.calls[line]++
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+
+	key := name
+	if _, ok := f.outputs[line]; ok {
+		key = line
+	} else if _, ok := f.errs[line]; ok {
+		key = line
+	}
+	if err := f.errs[key]; err != nil {
+		return nil, err
+	}
+	return []byte(f.outputs[key]), nil
+}
+
+// Calls returns how many times the command name or full command line was run.
+func (f *fakeRunner) Calls(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[name]
+}
+
+// sampleIoreg is trimmed ioreg output from a MacBook on battery.
+var sampleIoreg = strings.Join([]string{
+	`+-o AppleSmartBattery  <class AppleSmartBattery>`,
+	`    "InstantAmperage" = 18446744073709550616`,
+	`    "Voltage" = 12000`,
+	`    "DesignCapacity" = 5103`,
+}, "\n")
+
+// samplePmset is pmset output from a MacBook on battery.
+const samplePmset = `Now drawing from 'Battery Power'
+ -InternalBattery-0 (id=1234567)	75%; discharging; 3:45 remaining present: true`
@@ -0,0 +1,84 @@
+package power
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// csvHeader is the header row written at the top of new CSV logs.
+var csvHeader = []string{"timestamp", "watts", "battery_percent", "is_charging", "is_on_battery", "source"}
+
+// CSVMonitor wraps another Monitor and appends every successful reading to a
+// CSV writer. Rows are flushed immediately so a crash loses at most one row.
+type CSVMonitor struct {
+	Monitor
+	mu sync.Mutex
+	w  *csv.Writer
+}
+
+// NewCSVMonitor wraps monitor so that readings are logged to w. If
+// writeHeader is true, the header row is written first.
+func NewCSVMonitor(monitor Monitor, w io.Writer, writeHeader bool) (*CSVMonitor, error) {
+	m := &CSVMonitor{
+		Monitor: monitor,
+		w:       csv.NewWriter(w),
+	}
+	if writeHeader {
+		if err := m.writeRow(csvHeader); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// NeedsSudo reports whether the wrapped monitor needs sudo.
+func (m *CSVMonitor) NeedsSudo() bool {
+	return NeedsSudo(m.Monitor)
+}
+
+// Close closes the wrapped monitor.
+func (m *CSVMonitor) Close() error {
+	return Close(m.Monitor)
+}
+
+// Read reads from the wrapped monitor and logs the reading on success. If
+// the row can't be written, the reading is still returned, with an error
+// wrapping ErrLogWrite.
+func (m *CSVMonitor) Read(ctx context.Context) (Reading, error) {
+	reading, err := m.Monitor.Read(ctx)
+	if ReadFailed(err) {
+		return reading, err
+	}
+	if werr := m.writeRow(csvRecord(reading)); werr != nil {
+		return reading, fmt.Errorf("%w: %w", ErrLogWrite, werr)
+	}
+	return reading, err
+}
+
+// writeRow writes and flushes a single CSV row.
+func (m *CSVMonitor) writeRow(record []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.w.Write(record); err != nil {
+		return err
+	}
+	m.w.Flush()
+	return m.w.Error()
+}
+
+// csvRecord converts a reading into a CSV row matching csvHeader.
+func csvRecord(r Reading) []string {
+	return []string{
+		r.Timestamp.Format(time.RFC3339),
+		strconv.FormatFloat(r.Watts, 'f', 3, 64),
+		strconv.FormatFloat(r.BatteryPercent, 'f', 1, 64),
+		strconv.FormatBool(r.IsCharging),
+		strconv.FormatBool(r.IsOnBattery),
+		r.Source,
+	}
+}
@@ -0,0 +1,114 @@
+package power
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingWriter is an io.Writer that always fails.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestCSVMonitor(t *testing.T) {
+	t.Run("implements Monitor interface", func(t *testing.T) {
+		var _ Monitor = &CSVMonitor{}
+	})
+
+	t.Run("writes header and rows", func(t *testing.T) {
+		ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		mock := NewMockMonitor().WithReadings(Reading{
+			Watts:          12.5,
+			Timestamp:      ts,
+			BatteryPercent: 80,
+			IsCharging:     true,
+			Source:         "mock",
+		})
+		var buf bytes.Buffer
+
+		m, err := NewCSVMonitor(mock, &buf, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := m.Read(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+		}
+		if lines[0] != "timestamp,watts,battery_percent,is_charging,is_on_battery,source" {
+			t.Errorf("unexpected header: %s", lines[0])
+		}
+		if lines[1] != "2024-01-02T03:04:05Z,12.500,80.0,true,false,mock" {
+			t.Errorf("unexpected row: %s", lines[1])
+		}
+	})
+
+	t.Run("skips header when appending", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		m, err := NewCSVMonitor(NewMockMonitor(), &buf, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, _ = m.Read(context.Background())
+
+		if strings.Contains(buf.String(), "timestamp") {
+			t.Error("expected no header row")
+		}
+		if strings.Count(buf.String(), "\n") != 1 {
+			t.Errorf("expected exactly one row, got %q", buf.String())
+		}
+	})
+
+	t.Run("does not log failed reads", func(t *testing.T) {
+		expectedErr := errors.New("test error")
+		var buf bytes.Buffer
+
+		m, _ := NewCSVMonitor(NewMockMonitor().WithError(expectedErr), &buf, false)
+		_, err := m.Read(context.Background())
+
+		if !errors.Is(err, expectedErr) {
+			t.Errorf("expected error %v, got %v", expectedErr, err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("expected nothing written, got %q", buf.String())
+		}
+	})
+
+	t.Run("keeps the reading when the row can't be written", func(t *testing.T) {
+		mock := NewMockMonitor().WithReadings(Reading{Watts: 12.5})
+		m, err := NewCSVMonitor(mock, failingWriter{}, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		reading, err := m.Read(context.Background())
+		if !errors.Is(err, ErrLogWrite) {
+			t.Errorf("expected an ErrLogWrite error, got %v", err)
+		}
+		if ReadFailed(err) || reading.Watts != 12.5 {
+			t.Errorf("expected the reading to be kept, got %+v", reading)
+		}
+	})
+
+	t.Run("forwards name and support", func(t *testing.T) {
+		mock := NewMockMonitor().WithSupported(false)
+		m, _ := NewCSVMonitor(mock, &bytes.Buffer{}, false)
+
+		if m.Name() != "mock" {
+			t.Errorf("expected Name=mock, got %s", m.Name())
+		}
+		if m.IsSupported() {
+			t.Error("expected IsSupported=false")
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package power
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats PipeMonitor.SetFormat accepts for the lines pushed to the pipe.
+const (
+	// PipeFormatJSON is powermon's own JSON lines, as written by -json.
+	PipeFormatJSON = "json"
+	// PipeFormatTasmota is Tasmota status or telemetry JSON, one message
+	// per line; see ParseTasmotaStatus.
+	PipeFormatTasmota = "tasmota"
+	// PipeFormatShelly is Shelly status JSON, one response per line; see
+	// ParseShellyStatus.
+	PipeFormatShelly = "shelly"
+	// PipeFormatIPMI is the text output of `ipmitool dcmi power reading`;
+	// see ParseIPMIPower.
+	PipeFormatIPMI = "ipmi"
+)
+
+// PipeFormats returns the formats PipeMonitor.SetFormat accepts.
+func PipeFormats() []string {
+	return []string{PipeFormatJSON, PipeFormatTasmota, PipeFormatShelly, PipeFormatIPMI}
+}
+
+// lineParsers maps each pipe format to how one line of it is parsed. Lines
+// that parse with an error wrapping ErrNoData carry no power figure and are
+// skipped rather than counted as malformed.
+var lineParsers = map[string]func([]byte) (Reading, error){
+	PipeFormatJSON:    parseReadingLine,
+	PipeFormatTasmota: ParseTasmotaStatus,
+	PipeFormatShelly:  ParseShellyStatus,
+	PipeFormatIPMI:    ParseIPMIPower,
+}
+
+// newDeviceReading returns a reading of real power from a metering device,
+// stamped now.
+func newDeviceReading(source string, watts float64) Reading {
+	return Reading{
+		Watts:          watts,
+		WattsAvailable: true,
+		PowerKind:      PowerKindSystem,
+		BatteryPercent: -1,
+		Timestamp:      time.Now(),
+		Source:         source,
+	}
+}
+
+// tasmotaEnergy is the ENERGY object of a Tasmota power-monitoring plug.
+type tasmotaEnergy struct {
+	Power         *float64 `json:"Power"`
+	ApparentPower float64  `json:"ApparentPower"`
+	Factor        float64  `json:"Factor"`
+}
+
+// ParseTasmotaStatus parses a Tasmota plug's energy report: the response to
+// the "Status 8" command, which nests it under StatusSNS, or a tele/SENSOR
+// telemetry message. Power, ApparentPower and Factor become the reading's
+// watts, ApparentVA and PowerFactor. Messages without an ENERGY reading
+// return an error wrapping ErrNoData.
+func ParseTasmotaStatus(data []byte) (Reading, error) {
+	var status struct {
+		StatusSNS *struct {
+			Energy *tasmotaEnergy `json:"ENERGY"`
+		} `json:"StatusSNS"`
+		Energy *tasmotaEnergy `json:"ENERGY"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return Reading{}, fmt.Errorf("parsing Tasmota status: %w", err)
+	}
+
+	energy := status.Energy
+	if status.StatusSNS != nil && status.StatusSNS.Energy != nil {
+		energy = status.StatusSNS.Energy
+	}
+	if energy == nil || energy.Power == nil {
+		return Reading{}, fmt.Errorf("no ENERGY in Tasmota status: %w", ErrNoData)
+	}
+
+	reading := newDeviceReading("tasmota", *energy.Power)
+	reading.ApparentVA = energy.ApparentPower
+	reading.PowerFactor = energy.Factor
+	return reading, nil
+}
+
+// ParseShellyStatus parses a Shelly device's status. It understands Gen1
+// /status responses, summing the channels under meters (plugs, which only
+// report real power) or emeters (energy meters, which also report power
+// factor), and Gen2 Switch.GetStatus and EM.GetStatus responses. Apparent
+// power is taken from the device where it reports it and otherwise derived
+// from power factor, or from voltage and current. A status without a power
+// figure returns an error wrapping ErrNoData.
+func ParseShellyStatus(data []byte) (Reading, error) {
+	var status struct {
+		Meters []struct {
+			Power float64 `json:"power"`
+		} `json:"meters"`
+		EMeters []struct {
+			Power float64 `json:"power"`
+			PF    float64 `json:"pf"`
+		} `json:"emeters"`
+
+		// Gen2 switches
+		APower  *float64 `json:"apower"`
+		Voltage float64  `json:"voltage"`
+		Current float64  `json:"current"`
+		PF      float64  `json:"pf"`
+
+		// Gen2 energy meters
+		TotalActPower  *float64 `json:"total_act_power"`
+		TotalAprtPower float64  `json:"total_aprt_power"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return Reading{}, fmt.Errorf("parsing Shelly status: %w", err)
+	}
+
+	switch {
+	case status.TotalActPower != nil:
+		reading := newDeviceReading("shelly", *status.TotalActPower)
+		reading.ApparentVA = status.TotalAprtPower
+		reading.PowerFactor = powerFactor(reading.Watts, reading.ApparentVA)
+		return reading, nil
+
+	case status.APower != nil:
+		reading := newDeviceReading("shelly", *status.APower)
+		reading.PowerFactor = status.PF
+		reading.ApparentVA = status.Voltage * status.Current
+		if reading.PowerFactor == 0 {
+			reading.PowerFactor = powerFactor(reading.Watts, reading.ApparentVA)
+		}
+		return reading, nil
+
+	case len(status.EMeters) > 0:
+		var watts, va float64
+		for _, meter := range status.EMeters {
+			watts += meter.Power
+			if meter.PF != 0 {
+				va += meter.Power / meter.PF
+			}
+		}
+		reading := newDeviceReading("shelly", watts)
+		reading.ApparentVA = va
+		reading.PowerFactor = powerFactor(watts, va)
+		return reading, nil
+
+	case len(status.Meters) > 0:
+		var watts float64
+		for _, meter := range status.Meters {
+			watts += meter.Power
+		}
+		return newDeviceReading("shelly", watts), nil
+	}
+	return Reading{}, fmt.Errorf("no power in Shelly status: %w", ErrNoData)
+}
+
+// powerFactor returns real over apparent power, or 0 if either is unknown.
+func powerFactor(watts, va float64) float64 {
+	if watts <= 0 || va <= 0 {
+		return 0
+	}
+	return min(watts/va, 1)
+}
+
+// ipmiPowerPrefix starts the line of `ipmitool dcmi power reading` output
+// with the current draw.
+const ipmiPowerPrefix = "Instantaneous power reading:"
+
+// ParseIPMIPower parses the output of `ipmitool dcmi power reading`, or any
+// line of it, taking the instantaneous reading as the system's draw. DCMI
+// only reports real power, so ApparentVA and PowerFactor are left unset.
+// Output without the instantaneous reading returns an error wrapping
+// ErrNoData.
+func ParseIPMIPower(out []byte) (Reading, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		value, ok := strings.CutPrefix(line, ipmiPowerPrefix)
+		if !ok {
+			continue
+		}
+		watts, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), " Watts"), 64)
+		if err != nil {
+			return Reading{}, fmt.Errorf("parsing IPMI power reading %q: %w", line, err)
+		}
+		return newDeviceReading("ipmi", watts), nil
+	}
+	return Reading{}, fmt.Errorf("no IPMI power reading: %w", ErrNoData)
+}
@@ -0,0 +1,107 @@
+package power
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// sampleTasmotaStatus8 is a Tasmota plug's response to "Status 8".
+const sampleTasmotaStatus8 = `{"StatusSNS":{"Time":"2024-03-02T10:15:03","ENERGY":{"TotalStartTime":"2023-11-20T18:02:11","Total":41.372,"Yesterday":0.512,"Today":0.233,"Power":110,"ApparentPower":120,"ReactivePower":48,"Factor":0.92,"Voltage":231,"Current":0.519}}}`
+
+// sampleTasmotaSensor is a Tasmota plug's tele/SENSOR telemetry message.
+const sampleTasmotaSensor = `{"Time":"2024-03-02T10:15:33","ENERGY":{"TotalStartTime":"2023-11-20T18:02:11","Total":41.373,"Yesterday":0.512,"Today":0.234,"Period":1,"Power":55,"ApparentPower":61,"ReactivePower":26,"Factor":0.9,"Voltage":230,"Current":0.265}}`
+
+// sampleShellyPlugGen1 is a Gen1 Shelly Plug S /status response, trimmed.
+const sampleShellyPlugGen1 = `{"wifi_sta":{"connected":true,"ssid":"home","ip":"192.168.1.40","rssi":-61},"relays":[{"ison":true,"has_timer":false,"overpower":false}],"meters":[{"power":42.17,"overpower":0,"is_valid":true,"timestamp":1709374503,"counters":[42.1,41.9,42.3],"total":18312}],"temperature":31.2}`
+
+// sampleShellyEMGen1 is a Gen1 Shelly EM /status response with two
+// channels, trimmed.
+const sampleShellyEMGen1 = `{"relays":[{"ison":false}],"emeters":[{"power":200,"reactive":96.9,"pf":0.8,"voltage":229.8,"is_valid":true,"total":5230.1,"total_returned":0},{"power":100,"reactive":0,"pf":1,"voltage":229.8,"is_valid":true,"total":812.4,"total_returned":0}]}`
+
+// sampleShellySwitchGen2 is a Gen2 Shelly Plus Plug S Switch.GetStatus
+// response.
+const sampleShellySwitchGen2 = `{"id":0,"source":"init","output":true,"apower":110.4,"voltage":230.2,"current":0.52,"pf":0.92,"aenergy":{"total":1520.316,"by_minute":[1832.4,1840.1,1838.9],"minute_ts":1709374500},"temperature":{"tC":38.1,"tF":100.6}}`
+
+// sampleShellyEMGen2 is a Gen2 Shelly Pro 3EM EM.GetStatus response,
+// trimmed to the totals.
+const sampleShellyEMGen2 = `{"id":0,"a_current":1.2,"a_voltage":230.1,"a_act_power":250.5,"a_aprt_power":276.1,"a_pf":0.91,"total_current":1.2,"total_act_power":250.5,"total_aprt_power":276.1}`
+
+// sampleIPMIPower is the output of ipmitool dcmi power reading.
+const sampleIPMIPower = `
+    Instantaneous power reading:                   220 Watts
+    Minimum during sampling period:                 80 Watts
+    Maximum during sampling period:                412 Watts
+    Average power reading over sample period:      198 Watts
+    IPMI timestamp:                           Sat Mar  2 10:15:03 2024
+    Sampling period:                          00000005 Seconds.
+    Power reading state is:                   activated
+`
+
+func TestParseDeviceStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		parse  func([]byte) (Reading, error)
+		input  string
+		watts  float64
+		va     float64
+		pf     float64
+		source string
+	}{
+		{"tasmota status 8", ParseTasmotaStatus, sampleTasmotaStatus8, 110, 120, 0.92, "tasmota"},
+		{"tasmota telemetry", ParseTasmotaStatus, sampleTasmotaSensor, 55, 61, 0.9, "tasmota"},
+		{"shelly gen1 plug", ParseShellyStatus, sampleShellyPlugGen1, 42.17, 0, 0, "shelly"},
+		{"shelly gen1 em", ParseShellyStatus, sampleShellyEMGen1, 300, 350, 300.0 / 350, "shelly"},
+		{"shelly gen2 switch", ParseShellyStatus, sampleShellySwitchGen2, 110.4, 230.2 * 0.52, 0.92, "shelly"},
+		{"shelly gen2 em", ParseShellyStatus, sampleShellyEMGen2, 250.5, 276.1, 250.5 / 276.1, "shelly"},
+		{"ipmi dcmi", ParseIPMIPower, sampleIPMIPower, 220, 0, 0, "ipmi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := tt.parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(r.Watts-tt.watts) > 0.001 || math.Abs(r.ApparentVA-tt.va) > 0.001 || math.Abs(r.PowerFactor-tt.pf) > 0.001 {
+				t.Errorf("got %.3fW, %.3f VA, PF %.3f, want %.3fW, %.3f VA, PF %.3f",
+					r.Watts, r.ApparentVA, r.PowerFactor, tt.watts, tt.va, tt.pf)
+			}
+			if !r.WattsAvailable || r.PowerKind != PowerKindSystem || r.BatteryPercent != -1 || r.Source != tt.source || r.Timestamp.IsZero() {
+				t.Errorf("unexpected reading: %+v", r)
+			}
+		})
+	}
+}
+
+func TestParseDeviceStatus_NoPower(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func([]byte) (Reading, error)
+		input string
+	}{
+		{"tasmota state", ParseTasmotaStatus, `{"Time":"2024-03-02T10:15:33","Uptime":"0T01:02:03","POWER":"ON"}`},
+		{"shelly without meters", ParseShellyStatus, `{"wifi_sta":{"connected":true},"relays":[{"ison":true}]}`},
+		{"ipmi other lines", ParseIPMIPower, "    Power reading state is:                   activated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.parse([]byte(tt.input)); !errors.Is(err, ErrNoData) {
+				t.Errorf("expected ErrNoData, got %v", err)
+			}
+		})
+	}
+
+	for name, parse := range map[string]func([]byte) (Reading, error){
+		"tasmota": ParseTasmotaStatus,
+		"shelly":  ParseShellyStatus,
+	} {
+		if _, err := parse([]byte("not json")); err == nil || errors.Is(err, ErrNoData) {
+			t.Errorf("%s: expected a parse error for malformed input, got %v", name, err)
+		}
+	}
+	if _, err := ParseIPMIPower([]byte("Instantaneous power reading: lots Watts")); err == nil || errors.Is(err, ErrNoData) {
+		t.Errorf("ipmi: expected a parse error for a bad value, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// EngineConfig configures an Engine.
+type EngineConfig struct {
+	// Interval is how often the monitor is read. It must be positive.
+	Interval time.Duration
+	// ReadTimeout bounds each read. Zero leaves reads bounded only by the
+	// context passed to Start.
+	ReadTimeout time.Duration
+	// StatsWindow limits the stats Snapshot returns to the readings within
+	// this duration of the newest one. Zero covers the whole history.
+	StatsWindow time.Duration
+	// OnReading, if set, is called with each reading after it's added to the
+	// history.
+	OnReading func(Reading)
+	// OnError, if set, is called with the error from each failed read, and
+	// from each reading that couldn't be logged (see ErrLogWrite). Reads
+	// that return ErrNoNewReading are skipped without calling it.
+	OnError func(error)
+}
+
+// Engine reads a Monitor on a ticker and keeps the readings in a History,
+// independently of any UI. Programs embedding powermon can poll Snapshot
+// for the latest reading and stats, or react to each reading as it comes in
+// through EngineConfig.OnReading. Callbacks run on the engine's goroutine,
+// so they should return quickly. powermon's headless modes run on an Engine;
+// the TUI schedules its own reads, since pausing and changing the interval
+// from the keyboard aren't things an Engine does.
+type Engine struct {
+	monitor Monitor
+	history *History
+	cfg     EngineConfig
+
+	mu      sync.Mutex
+	latest  Reading
+	lastErr error
+	started bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewEngine creates an engine that reads monitor into history. Call Start to
+// begin reading.
+func NewEngine(monitor Monitor, history *History, cfg EngineConfig) *Engine {
+	return &Engine{
+		monitor: monitor,
+		history: history,
+		cfg:     cfg,
+		done:    make(chan struct{}),
+	}
+}
+
+// Start reads from the monitor immediately and then every interval, until
+// ctx is canceled, Stop is called or a replay finishes. Failed reads are
+// recorded and passed to OnError rather than stopping the engine. Calling
+// Start again has no effect. Like time.NewTicker, it panics if the interval
+// is not positive.
+func (e *Engine) Start(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.started {
+		return
+	}
+	e.started = true
+
+	ctx, e.cancel = context.WithCancel(ctx)
+	ticker := time.NewTicker(e.cfg.Interval)
+	go e.run(ctx, ticker)
+}
+
+// Stop stops reading and waits for a read in flight to finish. It does
+// nothing if the engine was never started.
+func (e *Engine) Stop() {
+	e.mu.Lock()
+	started, cancel := e.started, e.cancel
+	e.mu.Unlock()
+	if !started {
+		return
+	}
+	cancel()
+	<-e.done
+}
+
+// Done returns a channel that's closed once a started engine stops reading.
+func (e *Engine) Done() <-chan struct{} {
+	return e.done
+}
+
+// History returns the history readings are added to.
+func (e *Engine) History() *History {
+	return e.history
+}
+
+// Snapshot returns the latest successful reading, which is the zero Reading
+// until one arrives, and the stats over the history.
+func (e *Engine) Snapshot() (Reading, Stats) {
+	e.mu.Lock()
+	latest := e.latest
+	e.mu.Unlock()
+	return latest, e.history.StatsOver(e.cfg.StatsWindow)
+}
+
+// Err returns the error from the most recent read, or nil if it succeeded.
+// It is ErrReplayDone once a replay has finished.
+func (e *Engine) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastErr
+}
+
+// run reads on every tick until ctx is canceled or a replay finishes.
+func (e *Engine) run(ctx context.Context, ticker *time.Ticker) {
+	defer close(e.done)
+	defer ticker.Stop()
+
+	for {
+		if !e.read(ctx) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// read takes one reading and records it, reporting whether the engine
+// should keep going.
+func (e *Engine) read(ctx context.Context) bool {
+	readCtx := ctx
+	if e.cfg.ReadTimeout > 0 {
+		var cancel context.CancelFunc
+		readCtx, cancel = context.WithTimeout(ctx, e.cfg.ReadTimeout)
+		defer cancel()
+	}
+
+	reading, err := e.monitor.Read(readCtx)
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, ErrNoNewReading) {
+		// Nothing was pushed since the last tick; wait for the next one
+		return true
+	}
+
+	ok := !ReadFailed(err)
+	if ok {
+		e.history.Add(reading)
+		reading.Seq = e.history.Seq()
+	}
+
+	e.mu.Lock()
+	e.lastErr = err
+	if ok {
+		e.latest = reading
+	}
+	e.mu.Unlock()
+
+	if errors.Is(err, ErrReplayDone) {
+		return false
+	}
+	if err != nil && e.cfg.OnError != nil {
+		e.cfg.OnError(err)
+	}
+	if ok && e.cfg.OnReading != nil {
+		e.cfg.OnReading(reading)
+	}
+	return true
+}
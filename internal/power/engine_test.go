@@ -0,0 +1,277 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForEngine waits for the engine to stop reading, failing the test if it
+// takes too long.
+func waitForEngine(t *testing.T, e *Engine) {
+	t.Helper()
+	select {
+	case <-e.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("engine did not stop")
+	}
+}
+
+func TestEngine(t *testing.T) {
+	t.Run("reads into the history", func(t *testing.T) {
+		monitor := NewMockMonitor().WithAutoIncrement(10)
+		history := NewHistory(100, time.Hour)
+
+		var mu sync.Mutex
+		var got []float64
+		e := NewEngine(monitor, history, EngineConfig{
+			Interval: time.Millisecond,
+			OnReading: func(r Reading) {
+				mu.Lock()
+				got = append(got, r.Watts)
+				mu.Unlock()
+			},
+		})
+		e.Start(context.Background())
+
+		deadline := time.Now().Add(2 * time.Second)
+		for history.Len() < 3 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		e.Stop()
+
+		if history.Len() < 3 {
+			t.Fatalf("expected at least 3 readings, got %d", history.Len())
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(got) != history.Len() {
+			t.Errorf("OnReading called %d times for %d readings", len(got), history.Len())
+		}
+		for i, w := range got {
+			if w != 10+float64(i) {
+				t.Errorf("reading %d: expected %v, got %v", i, 10+float64(i), w)
+			}
+		}
+	})
+
+	t.Run("snapshot returns the latest reading and stats", func(t *testing.T) {
+		monitor := NewMockMonitor().WithReadings(Reading{Watts: 10}, Reading{Watts: 20})
+		history := NewHistory(100, time.Hour)
+		e := NewEngine(monitor, history, EngineConfig{Interval: time.Hour})
+
+		if r, stats := e.Snapshot(); r.Watts != 0 || stats != (Stats{}) {
+			t.Errorf("expected an empty snapshot before starting, got %+v, %+v", r, stats)
+		}
+
+		e.Start(context.Background())
+		deadline := time.Now().Add(2 * time.Second)
+		for history.Len() < 1 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		e.Stop()
+
+		r, stats := e.Snapshot()
+		if r.Watts != 10 || r.Seq != 1 {
+			t.Errorf("expected the first reading with Seq 1, got %+v", r)
+		}
+		if stats.Samples != 1 || stats.Average != 10 {
+			t.Errorf("expected stats over one 10W reading, got %+v", stats)
+		}
+		if e.Err() != nil {
+			t.Errorf("expected no error, got %v", e.Err())
+		}
+	})
+
+	t.Run("keeps readings that couldn't be logged", func(t *testing.T) {
+		monitor, err := NewCSVMonitor(NewMockMonitor(), failingWriter{}, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		history := NewHistory(100, time.Hour)
+
+		var mu sync.Mutex
+		var errs []error
+		e := NewEngine(monitor, history, EngineConfig{
+			Interval: time.Hour,
+			OnError: func(err error) {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			},
+		})
+		e.Start(context.Background())
+		deadline := time.Now().Add(2 * time.Second)
+		for history.Len() < 1 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		e.Stop()
+
+		if history.Len() != 1 {
+			t.Errorf("expected the reading in the history, got %d readings", history.Len())
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(errs) != 1 || !errors.Is(errs[0], ErrLogWrite) {
+			t.Errorf("expected the write error to be reported, got %v", errs)
+		}
+	})
+
+	t.Run("reports failed reads and keeps going", func(t *testing.T) {
+		readErr := errors.New("boom")
+		monitor := NewMockMonitor().WithFailures(2, readErr)
+		history := NewHistory(100, time.Hour)
+
+		var mu sync.Mutex
+		var errs []error
+		e := NewEngine(monitor, history, EngineConfig{
+			Interval: time.Millisecond,
+			OnError: func(err error) {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			},
+		})
+		e.Start(context.Background())
+
+		deadline := time.Now().Add(2 * time.Second)
+		for history.Len() < 1 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		e.Stop()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(errs) != 2 {
+			t.Fatalf("expected 2 errors, got %d", len(errs))
+		}
+		for _, err := range errs {
+			if !errors.Is(err, readErr) {
+				t.Errorf("expected %v, got %v", readErr, err)
+			}
+		}
+		if history.Len() == 0 {
+			t.Error("expected readings after the failures")
+		}
+	})
+
+	t.Run("skips ticks with no new reading", func(t *testing.T) {
+		monitor := NewMockMonitor().WithFailures(3, ErrNoNewReading)
+		history := NewHistory(100, time.Hour)
+
+		var mu sync.Mutex
+		var errs []error
+		e := NewEngine(monitor, history, EngineConfig{
+			Interval: time.Millisecond,
+			OnError: func(err error) {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			},
+		})
+		e.Start(context.Background())
+
+		deadline := time.Now().Add(2 * time.Second)
+		for history.Len() < 1 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		e.Stop()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(errs) != 0 {
+			t.Errorf("expected no errors reported, got %v", errs)
+		}
+		if history.Len() == 0 {
+			t.Error("expected readings once the source sent one")
+		}
+	})
+
+	t.Run("stops when a replay finishes", func(t *testing.T) {
+		monitor := NewReplayMonitor([]Reading{{Watts: 5}, {Watts: 7}}, false)
+		history := NewHistory(100, time.Hour)
+		called := false
+		e := NewEngine(monitor, history, EngineConfig{
+			Interval: time.Millisecond,
+			OnError:  func(error) { called = true },
+		})
+		e.Start(context.Background())
+		waitForEngine(t, e)
+
+		if history.Len() != 2 {
+			t.Errorf("expected 2 readings, got %d", history.Len())
+		}
+		if !errors.Is(e.Err(), ErrReplayDone) {
+			t.Errorf("expected ErrReplayDone, got %v", e.Err())
+		}
+		if called {
+			t.Error("expected OnError not to be called for the end of a replay")
+		}
+		e.Stop()
+	})
+
+	t.Run("stops when the context is canceled", func(t *testing.T) {
+		monitor := NewMockMonitor()
+		e := NewEngine(monitor, NewHistory(100, time.Hour), EngineConfig{Interval: time.Hour})
+		ctx, cancel := context.WithCancel(context.Background())
+		e.Start(ctx)
+		cancel()
+		waitForEngine(t, e)
+	})
+
+	t.Run("stop halts reading", func(t *testing.T) {
+		monitor := NewMockMonitor()
+		e := NewEngine(monitor, NewHistory(100, time.Hour), EngineConfig{Interval: time.Millisecond})
+		e.Start(context.Background())
+		e.Stop()
+		waitForEngine(t, e)
+
+		count := monitor.ReadCount()
+		time.Sleep(10 * time.Millisecond)
+		if monitor.ReadCount() != count {
+			t.Errorf("expected no reads after Stop, got %d more", monitor.ReadCount()-count)
+		}
+	})
+
+	t.Run("read timeout cancels slow reads", func(t *testing.T) {
+		monitor := NewMockMonitor().WithReadDelay(time.Hour)
+		errCh := make(chan error, 1)
+		e := NewEngine(monitor, NewHistory(100, time.Hour), EngineConfig{
+			Interval:    time.Hour,
+			ReadTimeout: time.Millisecond,
+			OnError: func(err error) {
+				select {
+				case errCh <- err:
+				default:
+				}
+			},
+		})
+		e.Start(context.Background())
+		defer e.Stop()
+
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected context.DeadlineExceeded, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("read was not timed out")
+		}
+	})
+
+	t.Run("start twice and stop without start are no-ops", func(t *testing.T) {
+		monitor := NewMockMonitor()
+		e := NewEngine(monitor, NewHistory(100, time.Hour), EngineConfig{Interval: time.Hour})
+		e.Stop()
+
+		e.Start(context.Background())
+		e.Start(context.Background())
+		e.Stop()
+		waitForEngine(t, e)
+		if monitor.ReadCount() != 1 {
+			t.Errorf("expected a single read, got %d", monitor.ReadCount())
+		}
+	})
+}
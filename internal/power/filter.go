@@ -0,0 +1,156 @@
+package power
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+)
+
+// DefaultFilterWindow is the number of recent readings used to compute the
+// median that incoming readings are compared against.
+const DefaultFilterWindow = 5
+
+// DefaultMedianWindow is the number of raw reads MedianFilterMonitor takes the
+// median of.
+const DefaultMedianWindow = 3
+
+// OutlierFilter rejects readings that jump too far from the recent median.
+// A reading is only rejected when it exceeds every enabled threshold, so tiny
+// fluctuations on a low baseline and proportionally small swings on a high
+// baseline both pass. A threshold of zero or less disables that check.
+type OutlierFilter struct {
+	maxAbsDelta float64
+	maxRelDelta float64
+	window      int
+	recent      []float64
+}
+
+// NewOutlierFilter creates a filter comparing each reading against the median
+// of the last window readings. maxAbsDelta is in watts and maxRelDelta is a
+// fraction of the median (e.g. 0.5 for 50%).
+func NewOutlierFilter(window int, maxAbsDelta, maxRelDelta float64) *OutlierFilter {
+	if window < 1 {
+		window = DefaultFilterWindow
+	}
+	return &OutlierFilter{
+		maxAbsDelta: maxAbsDelta,
+		maxRelDelta: maxRelDelta,
+		window:      window,
+		recent:      make([]float64, 0, window),
+	}
+}
+
+// Accept reports whether the reading should be kept. Every reading, accepted
+// or not, is remembered so that a genuine sustained change in power shifts the
+// median and starts being accepted after a few samples.
+func (f *OutlierFilter) Accept(r Reading) bool {
+	accept := true
+	if len(f.recent) >= f.window/2+1 {
+		accept = !f.isOutlier(r.Watts, median(f.recent))
+	}
+
+	f.recent = append(f.recent, r.Watts)
+	if len(f.recent) > f.window {
+		f.recent = f.recent[1:]
+	}
+
+	return accept
+}
+
+// Reset forgets all previously seen readings.
+func (f *OutlierFilter) Reset() {
+	f.recent = f.recent[:0]
+}
+
+// isOutlier checks a value against the enabled thresholds.
+func (f *OutlierFilter) isOutlier(watts, med float64) bool {
+	if f.maxAbsDelta <= 0 && f.maxRelDelta <= 0 {
+		return false
+	}
+
+	delta := math.Abs(watts - med)
+	if f.maxAbsDelta > 0 && delta <= f.maxAbsDelta {
+		return false
+	}
+	if f.maxRelDelta > 0 {
+		rel := math.Inf(1)
+		if med != 0 {
+			rel = delta / math.Abs(med)
+		}
+		if rel <= f.maxRelDelta {
+			return false
+		}
+	}
+	return delta > 0
+}
+
+// MedianFilterMonitor wraps another Monitor and replaces each reading's watts
+// with the median of the last few raw reads. Unlike a mean, the median
+// ignores a one-off spike entirely, and it follows a genuine step in power as
+// soon as the step makes up half the window.
+type MedianFilterMonitor struct {
+	Monitor
+	mu     sync.Mutex
+	window int
+	recent []float64
+}
+
+// NewMedianFilterMonitor wraps monitor so that readings report the median
+// watts of the last window reads. A window below 1 uses DefaultMedianWindow.
+func NewMedianFilterMonitor(monitor Monitor, window int) *MedianFilterMonitor {
+	if window < 1 {
+		window = DefaultMedianWindow
+	}
+	return &MedianFilterMonitor{
+		Monitor: monitor,
+		window:  window,
+		recent:  make([]float64, 0, window),
+	}
+}
+
+// NeedsSudo reports whether the wrapped monitor needs sudo.
+func (m *MedianFilterMonitor) NeedsSudo() bool {
+	return NeedsSudo(m.Monitor)
+}
+
+// Close closes the wrapped monitor.
+func (m *MedianFilterMonitor) Close() error {
+	return Close(m.Monitor)
+}
+
+// Read reads from the wrapped monitor and smooths its watts. Failed reads
+// and readings without a power figure pass through and don't enter the
+// window.
+func (m *MedianFilterMonitor) Read(ctx context.Context) (Reading, error) {
+	reading, err := m.Monitor.Read(ctx)
+	if ReadFailed(err) || !reading.WattsAvailable {
+		return reading, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.recent) == m.window {
+		copy(m.recent, m.recent[1:])
+		m.recent = m.recent[:m.window-1]
+	}
+	m.recent = append(m.recent, reading.Watts)
+	reading.Watts = median(m.recent)
+	return reading, err
+}
+
+// median returns the median of values without modifying the slice.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
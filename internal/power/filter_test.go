@@ -0,0 +1,251 @@
+package power
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// feed pushes watts through the filter and returns which readings were accepted.
+func feed(f *OutlierFilter, watts ...float64) []bool {
+	now := time.Now()
+	accepted := make([]bool, len(watts))
+	for i, w := range watts {
+		accepted[i] = f.Accept(Reading{Watts: w, Timestamp: now.Add(time.Duration(i) * time.Second)})
+	}
+	return accepted
+}
+
+func TestOutlierFilter(t *testing.T) {
+	t.Run("accepts everything when disabled", func(t *testing.T) {
+		f := NewOutlierFilter(5, 0, 0)
+
+		for i, ok := range feed(f, 10, 10, 10, 1000, 0) {
+			if !ok {
+				t.Errorf("expected reading %d to be accepted", i)
+			}
+		}
+	})
+
+	t.Run("accepts readings until window is primed", func(t *testing.T) {
+		f := NewOutlierFilter(5, 1, 0)
+
+		for i, ok := range feed(f, 10, 500) {
+			if !ok {
+				t.Errorf("expected reading %d to be accepted while priming", i)
+			}
+		}
+	})
+
+	t.Run("absolute-only rejection", func(t *testing.T) {
+		f := NewOutlierFilter(5, 5, 0)
+
+		got := feed(f, 10, 10, 10, 14, 30)
+		want := []bool{true, true, true, true, false}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("reading %d: accepted=%v, want %v", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("relative-only rejection", func(t *testing.T) {
+		f := NewOutlierFilter(5, 0, 0.5)
+
+		got := feed(f, 100, 100, 100, 140, 200)
+		want := []bool{true, true, true, true, false}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("reading %d: accepted=%v, want %v", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("combined rejection requires both thresholds", func(t *testing.T) {
+		f := NewOutlierFilter(5, 5, 0.5)
+
+		// Small baseline: 10W -> 18W is +80% but only +8W, beyond both
+		// thresholds, so it's rejected. 10W -> 14W is +40% and +4W, within both.
+		got := feed(f, 10, 10, 10, 14, 18)
+		want := []bool{true, true, true, true, false}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("small baseline reading %d: accepted=%v, want %v", i, got[i], want[i])
+			}
+		}
+
+		// Tiny baseline: 1W -> 3W is +200% but only +2W, so it passes.
+		f = NewOutlierFilter(5, 5, 0.5)
+		got = feed(f, 1, 1, 1, 3)
+		if !got[3] {
+			t.Error("expected large relative but small absolute jump to be accepted")
+		}
+
+		// Large baseline: 100W -> 120W is +20W but only +20%, so it passes.
+		f = NewOutlierFilter(5, 5, 0.5)
+		got = feed(f, 100, 100, 100, 120)
+		if !got[3] {
+			t.Error("expected large absolute but small relative jump to be accepted")
+		}
+	})
+
+	t.Run("accepts sustained ramps", func(t *testing.T) {
+		f := NewOutlierFilter(5, 5, 0.5)
+
+		got := feed(f, 10, 10, 10, 50, 50, 50, 50)
+		if got[3] {
+			t.Error("expected first sample of step to be rejected")
+		}
+		if !got[len(got)-1] {
+			t.Error("expected step to be accepted once it dominates the window")
+		}
+	})
+
+	t.Run("reset clears recent readings", func(t *testing.T) {
+		f := NewOutlierFilter(5, 5, 0)
+		feed(f, 10, 10, 10)
+
+		f.Reset()
+
+		if !f.Accept(Reading{Watts: 100}) {
+			t.Error("expected reading to be accepted after reset")
+		}
+	})
+}
+
+// readWatts reads n times from m and returns the watts of each reading.
+func readWatts(t *testing.T, m Monitor, n int) []float64 {
+	t.Helper()
+	watts := make([]float64, n)
+	for i := range watts {
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("read %d: unexpected error: %v", i, err)
+		}
+		watts[i] = reading.Watts
+	}
+	return watts
+}
+
+func TestMedianFilterMonitor(t *testing.T) {
+	t.Run("implements Monitor interface", func(t *testing.T) {
+		var _ Monitor = &MedianFilterMonitor{}
+	})
+
+	t.Run("suppresses a spike", func(t *testing.T) {
+		mock := NewMockMonitor().WithReadings(
+			Reading{Watts: 10}, Reading{Watts: 11}, Reading{Watts: 95},
+			Reading{Watts: 10}, Reading{Watts: 12},
+		)
+		m := NewMedianFilterMonitor(mock, 3)
+
+		got := readWatts(t, m, 5)
+		want := []float64{10, 10.5, 11, 11, 12}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("reading %d: Watts = %g, want %g", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("follows a sustained step", func(t *testing.T) {
+		mock := NewMockMonitor().WithReadings(
+			Reading{Watts: 10}, Reading{Watts: 10}, Reading{Watts: 10},
+			Reading{Watts: 40}, Reading{Watts: 40},
+		)
+		m := NewMedianFilterMonitor(mock, 3)
+
+		if got := readWatts(t, m, 5); got[4] != 40 {
+			t.Errorf("expected the step to show once it fills half the window, got %v", got)
+		}
+	})
+
+	t.Run("window below 1 uses the default", func(t *testing.T) {
+		if m := NewMedianFilterMonitor(NewMockMonitor(), 0); m.window != DefaultMedianWindow {
+			t.Errorf("window = %d, want %d", m.window, DefaultMedianWindow)
+		}
+	})
+
+	t.Run("keeps other fields", func(t *testing.T) {
+		mock := NewMockMonitor().WithReadings(Reading{Watts: 8, BatteryPercent: 50, IsCharging: true})
+		reading, err := NewMedianFilterMonitor(mock, 3).Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.BatteryPercent != 50 || !reading.IsCharging {
+			t.Errorf("expected battery fields to pass through, got %+v", reading)
+		}
+	})
+
+	t.Run("passes through errors without recording them", func(t *testing.T) {
+		mock := NewMockMonitor().WithReadings(Reading{Watts: 10}).WithFailures(1, errors.New("boom"))
+		m := NewMedianFilterMonitor(mock, 3)
+
+		if _, err := m.Read(context.Background()); err == nil {
+			t.Fatal("expected error")
+		}
+		readWatts(t, m, 1)
+		if len(m.recent) != 1 {
+			t.Errorf("expected only the successful read in the window, got %v", m.recent)
+		}
+	})
+
+	t.Run("ignores readings without watts", func(t *testing.T) {
+		m := NewMedianFilterMonitor(NewMockMonitor().WithWattsUnavailable(), 3)
+
+		readWatts(t, m, 2)
+		if len(m.recent) != 0 {
+			t.Errorf("expected an empty window, got %v", m.recent)
+		}
+	})
+
+	t.Run("delegates name and support", func(t *testing.T) {
+		mock := NewMockMonitor().WithSupported(false)
+		m := NewMedianFilterMonitor(mock, 3)
+		if m.Name() != mock.Name() || m.IsSupported() {
+			t.Errorf("expected Name and IsSupported from the wrapped monitor, got %q and %v", m.Name(), m.IsSupported())
+		}
+	})
+
+	t.Run("logs smoothed readings when wrapped", func(t *testing.T) {
+		mock := NewMockMonitor().WithReadings(Reading{Watts: 10}, Reading{Watts: 90}, Reading{Watts: 12})
+		var buf bytes.Buffer
+		m, err := NewCSVMonitor(NewMedianFilterMonitor(mock, 3), &buf, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		readWatts(t, m, 3)
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			got = append(got, strings.Split(line, ",")[1])
+		}
+		if strings.Join(got, " ") != "10.000 50.000 12.000" {
+			t.Errorf("expected smoothed watts in the log, got %q", buf.String())
+		}
+	})
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		expected float64
+	}{
+		{"empty", nil, 0},
+		{"single", []float64{4}, 4},
+		{"odd count", []float64{3, 1, 2}, 2},
+		{"even count", []float64{4, 1, 3, 2}, 2.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := median(tt.values); got != tt.expected {
+				t.Errorf("median(%v) = %f, want %f", tt.values, got, tt.expected)
+			}
+		})
+	}
+}
@@ -14,9 +14,15 @@ type MockMonitor struct {
 	supported     bool
 	name          string
 	err           error
+	failures      int
+	failErr       error
 	readCount     int
 	autoIncrement bool
 	baseWatts     float64
+	readDelay     time.Duration
+	unavailable   bool
+	now           func() time.Time
+	closed        bool
 }
 
 // NewMockMonitor creates a new mock monitor.
@@ -25,6 +31,7 @@ func NewMockMonitor() *MockMonitor {
 		supported: true,
 		name:      "mock",
 		baseWatts: 10.0,
+		now:       time.Now,
 	}
 }
 
@@ -49,6 +56,15 @@ func (m *MockMonitor) WithError(err error) *MockMonitor {
 	return m
 }
 
+// WithFailures makes the next n calls to Read return err before succeeding.
+func (m *MockMonitor) WithFailures(n int, err error) *MockMonitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures = n
+	m.failErr = err
+	return m
+}
+
 // WithAutoIncrement enables automatic watts incrementing for testing trends.
 func (m *MockMonitor) WithAutoIncrement(base float64) *MockMonitor {
 	m.autoIncrement = true
@@ -56,6 +72,33 @@ func (m *MockMonitor) WithAutoIncrement(base float64) *MockMonitor {
 	return m
 }
 
+// WithReadDelay makes Read block for d before returning, or until its
+// context is done, whichever comes first.
+func (m *MockMonitor) WithReadDelay(d time.Duration) *MockMonitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readDelay = d
+	return m
+}
+
+// WithWattsUnavailable makes every reading report that watts couldn't be
+// measured. By default all readings are marked available.
+func (m *MockMonitor) WithWattsUnavailable() *MockMonitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unavailable = true
+	return m
+}
+
+// WithClock makes Read stamp readings with now instead of time.Now, so tests
+// can control the passage of time.
+func (m *MockMonitor) WithClock(now func() time.Time) *MockMonitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+	return m
+}
+
 // Name returns the name of this mock monitor.
 func (m *MockMonitor) Name() string {
 	return m.name
@@ -68,10 +111,27 @@ func (m *MockMonitor) IsSupported() bool {
 
 // Read returns the next reading from the configured sequence.
 func (m *MockMonitor) Read(ctx context.Context) (Reading, error) {
+	m.mu.Lock()
+	m.readCount++
+	delay := m.readDelay
+	m.mu.Unlock()
+
+	// Sleep without holding the lock so concurrent reads overlap
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return Reading{}, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.readCount++
+	if m.failures > 0 {
+		m.failures--
+		return Reading{}, m.failErr
+	}
 
 	if m.err != nil {
 		return Reading{}, m.err
@@ -81,15 +141,17 @@ func (m *MockMonitor) Read(ctx context.Context) (Reading, error) {
 		reading := m.readings[m.readIndex]
 		m.readIndex = (m.readIndex + 1) % len(m.readings)
 		if reading.Timestamp.IsZero() {
-			reading.Timestamp = time.Now()
+			reading.Timestamp = m.now()
 		}
+		reading.WattsAvailable = !m.unavailable
 		return reading, nil
 	}
 
 	// Generate a reading if no predefined readings
 	reading := Reading{
 		Watts:          m.baseWatts,
-		Timestamp:      time.Now(),
+		WattsAvailable: !m.unavailable,
+		Timestamp:      m.now(),
 		IsOnBattery:    false,
 		BatteryPercent: 75.0,
 		IsCharging:     true,
@@ -103,6 +165,21 @@ func (m *MockMonitor) Read(ctx context.Context) (Reading, error) {
 	return reading, nil
 }
 
+// Close records that the monitor was closed; see Closed.
+func (m *MockMonitor) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+// Closed reports whether Close was called.
+func (m *MockMonitor) Closed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closed
+}
+
 // ReadCount returns how many times Read was called.
 func (m *MockMonitor) ReadCount() int {
 	m.mu.Lock()
@@ -117,4 +194,5 @@ func (m *MockMonitor) Reset() {
 	m.readIndex = 0
 	m.readCount = 0
 	m.err = nil
+	m.failures = 0
 }
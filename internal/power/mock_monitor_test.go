@@ -67,6 +67,21 @@ func TestMockMonitor(t *testing.T) {
 		}
 	})
 
+	t.Run("fails a fixed number of times", func(t *testing.T) {
+		expectedErr := errors.New("test error")
+		m := NewMockMonitor().WithFailures(2, expectedErr)
+		ctx := context.Background()
+
+		for i := 0; i < 2; i++ {
+			if _, err := m.Read(ctx); !errors.Is(err, expectedErr) {
+				t.Errorf("read %d: expected error %v, got %v", i, expectedErr, err)
+			}
+		}
+		if _, err := m.Read(ctx); err != nil {
+			t.Errorf("expected success after failures, got %v", err)
+		}
+	})
+
 	t.Run("reports supported status correctly", func(t *testing.T) {
 		m := NewMockMonitor()
 		if !m.IsSupported() {
@@ -115,6 +130,109 @@ func TestMockMonitor(t *testing.T) {
 		}
 	})
 
+	t.Run("read delay blocks before returning", func(t *testing.T) {
+		m := NewMockMonitor().WithReadings(Reading{Watts: 7}).WithReadDelay(30 * time.Millisecond)
+
+		start := time.Now()
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+			t.Errorf("expected Read to take at least 30ms, took %v", elapsed)
+		}
+		if reading.Watts != 7 {
+			t.Errorf("expected configured reading, got %f", reading.Watts)
+		}
+	})
+
+	t.Run("read delay respects context cancellation", func(t *testing.T) {
+		m := NewMockMonitor().WithReadDelay(time.Minute)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := m.Read(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		if m.ReadCount() != 1 {
+			t.Errorf("expected ReadCount=1, got %d", m.ReadCount())
+		}
+	})
+
+	t.Run("read delay still returns configured error", func(t *testing.T) {
+		expectedErr := errors.New("test error")
+		m := NewMockMonitor().WithError(expectedErr).WithReadDelay(time.Millisecond)
+
+		if _, err := m.Read(context.Background()); !errors.Is(err, expectedErr) {
+			t.Errorf("expected error %v, got %v", expectedErr, err)
+		}
+	})
+
+	t.Run("delayed reads run concurrently", func(t *testing.T) {
+		m := NewMockMonitor().WithReadDelay(100 * time.Millisecond)
+
+		start := time.Now()
+		done := make(chan struct{})
+		for i := 0; i < 3; i++ {
+			go func() {
+				_, _ = m.Read(context.Background())
+				done <- struct{}{}
+			}()
+		}
+		for i := 0; i < 3; i++ {
+			<-done
+		}
+		if elapsed := time.Since(start); elapsed >= 300*time.Millisecond {
+			t.Errorf("expected overlapping reads, took %v", elapsed)
+		}
+		if m.ReadCount() != 3 {
+			t.Errorf("expected ReadCount=3, got %d", m.ReadCount())
+		}
+	})
+
+	t.Run("readings are available by default", func(t *testing.T) {
+		m := NewMockMonitor().WithReadings(Reading{Watts: 3})
+		if r, _ := m.Read(context.Background()); !r.WattsAvailable {
+			t.Error("expected predefined reading to be available")
+		}
+		if r, _ := NewMockMonitor().Read(context.Background()); !r.WattsAvailable {
+			t.Error("expected generated reading to be available")
+		}
+		if r, _ := NewMockMonitor().WithWattsUnavailable().Read(context.Background()); r.WattsAvailable {
+			t.Error("expected WithWattsUnavailable to mark readings unavailable")
+		}
+	})
+
+	t.Run("stamps readings with the injected clock", func(t *testing.T) {
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		clock := func() time.Time { return now }
+		ctx := context.Background()
+
+		generated := NewMockMonitor().WithClock(clock)
+		sequenced := NewMockMonitor().WithClock(clock).WithReadings(Reading{Watts: 5})
+		h := NewHistory(100, 10*time.Second)
+		for i := 0; i < 3; i++ {
+			for _, m := range []*MockMonitor{generated, sequenced} {
+				r, err := m.Read(ctx)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !r.Timestamp.Equal(now) {
+					t.Errorf("read %d: expected timestamp %v, got %v", i, now, r.Timestamp)
+				}
+				h.Add(r)
+			}
+			now = now.Add(5 * time.Second)
+		}
+
+		// At 12:00:15 only the 12:00:10 readings are inside the 10s window
+		h.PruneNow(now)
+		if h.Len() != 2 {
+			t.Errorf("expected 2 readings after pruning, got %d", h.Len())
+		}
+	})
+
 	t.Run("reset clears state", func(t *testing.T) {
 		expectedErr := errors.New("test error")
 		m := NewMockMonitor().WithError(expectedErr)
@@ -3,14 +3,19 @@
 package power
 
 import (
-	"bytes"
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"math"
 	"os"
 	"os/exec"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,47 +26,159 @@ var (
 	amperageRe        = regexp.MustCompile(`"Amperage"\s*=\s*(\d+)`)
 	designCapacityRe  = regexp.MustCompile(`"DesignCapacity"\s*=\s*(\d+)`)
 	currentCapacityRe = regexp.MustCompile(`"CurrentCapacity"\s*=\s*(\d+)`)
+	maxCapacityRe     = regexp.MustCompile(`"MaxCapacity"\s*=\s*(\d+)`)
+	rawMaxCapacityRe  = regexp.MustCompile(`"AppleRawMaxCapacity"\s*=\s*(\d+)`)
+	rawCurCapacityRe  = regexp.MustCompile(`"AppleRawCurrentCapacity"\s*=\s*(\d+)`)
+	temperatureRe     = regexp.MustCompile(`"Temperature"\s*=\s*(\d+)`)
+	cycleCountRe      = regexp.MustCompile(`"CycleCount"\s*=\s*(\d+)`)
 	batteryPercentRe  = regexp.MustCompile(`(\d+)%`)
+	pmsetRemainingRe  = regexp.MustCompile(`(\d+):(\d{2}) remaining`)
+	// Adapter details are a dictionary, or an array of them for the raw
+	// details; these match up to the opening brace of the first one
+	adapterDetailsRe    = regexp.MustCompile(`"AdapterDetails"\s*=\s*\(?\s*\{`)
+	rawAdapterDetailsRe = regexp.MustCompile(`"AppleRawAdapterDetails"\s*=\s*\(?\s*\{`)
+	adapterWattsRe      = regexp.MustCompile(`"Watts"\s*=\s*(\d+)`)
 	// powermetrics output parsing (for desktop Macs)
-	cpuPowerRe      = regexp.MustCompile(`CPU Power:\s*([\d.]+)\s*mW`)
-	gpuPowerRe      = regexp.MustCompile(`GPU Power:\s*([\d.]+)\s*mW`)
-	anePowerRe      = regexp.MustCompile(`ANE Power:\s*([\d.]+)\s*mW`)
+	cpuPowerRe      = regexp.MustCompile(`(?m)^\s*CPU Power:\s*([\d.]+)\s*mW`)
+	gpuPowerRe      = regexp.MustCompile(`(?m)^\s*GPU Power:\s*([\d.]+)\s*mW`)
+	anePowerRe      = regexp.MustCompile(`(?m)^\s*ANE Power:\s*([\d.]+)\s*mW`)
+	dgpuPowerRe     = regexp.MustCompile(`(?m)^\s*(?:Discrete GPU|dGPU) Power:\s*([\d.]+)\s*mW`)
 	combinedPowerRe = regexp.MustCompile(`Combined Power.*?:\s*([\d.]+)\s*mW`)
 	packagePowerRe  = regexp.MustCompile(`Package Power:\s*([\d.]+)\s*mW`)
+	// Intel reports the average across cores, Apple Silicon one per cluster
+	cpuAverageFreqRe = regexp.MustCompile(`(?m)^\s*CPU Average frequency as fraction of nominal:.*\(([\d.]+)\s*[Mm][Hh]z\)`)
+	clusterFreqRe    = regexp.MustCompile(`(?m)^\s*\S+-Cluster HW active frequency:\s*([\d.]+)\s*MHz`)
 	// Power telemetry (system load / input power) from ioreg
 	systemPowerInRe = regexp.MustCompile(`"SystemPowerIn"\s*=\s*(\d+)`)
 	systemLoadRe    = regexp.MustCompile(`"SystemLoad"\s*=\s*(\d+)`)
 	systemCurrentInRe = regexp.MustCompile(`"SystemCurrentIn"\s*=\s*(\d+)`)
 	systemVoltageInRe = regexp.MustCompile(`"SystemVoltageIn"\s*=\s*(\d+)`)
 	batteryPowerRe  = regexp.MustCompile(`"BatteryPower"\s*=\s*(\d+)`)
+	// Discrete GPU power (mW) from the AGPM controller on Intel Macs
+	agpmGPUPowerRe = regexp.MustCompile(`"GPUPower"\s*=\s*(\d+)`)
+	// Intel Power Gadget PowerLog CSV columns and summary lines
+	powerLogPackageRe = regexp.MustCompile(`^Processor Power_\d+\s*\(Watt\)$`)
+	powerLogIARe      = regexp.MustCompile(`^IA Power_\d+\s*\(Watt\)$`)
+	powerLogGTRe      = regexp.MustCompile(`^GT Power_\d+\s*\(Watt\)$`)
+	powerLogAverageRe = regexp.MustCompile(`(?m)^Average Processor Power_\d+\s*\(Watt\)\s*=\s*([\d.]+)`)
 )
 
+// powerGadgetName is the monitor's name, and its readings' source, while
+// Intel Power Gadget provides them.
+const powerGadgetName = "macOS-power-gadget"
+
+// powerLogPaths are where Intel Power Gadget's PowerLog CLI may be found.
+var powerLogPaths = []string{"PowerLog", "/Applications/Intel Power Gadget/PowerLog"}
+
+// powermetricsComponents maps component names to the powermetrics lines
+// reporting their power.
+var powermetricsComponents = map[string]*regexp.Regexp{
+	ComponentCPU:         cpuPowerRe,
+	ComponentGPU:         gpuPowerRe,
+	ComponentANE:         anePowerRe,
+	ComponentDiscreteGPU: dgpuPowerRe,
+}
+
+// DefaultIoregCacheTTL is how long raw ioreg output is reused between reads.
+const DefaultIoregCacheTTL = 500 * time.Millisecond
+
+// powermetricsSampleHeader marks the start of each sample block in powermetrics output.
+const powermetricsSampleHeader = "*** Sampled system activity"
+
 // DarwinMonitor reads power information on macOS using system utilities.
 type DarwinMonitor struct {
 	hasBattery      bool
 	hasRoot         bool
 	checkedBattery  bool
 	usePowermetrics bool
+	hasDiscreteGPU  bool
+	powerLogPath    string // Intel Power Gadget CLI, empty if not installed
+	sampleCount     int
+	powerMetric     string
+	runner          commandRunner
+
+	// lookPath finds system utilities on PATH, like exec.LookPath
+	lookPath func(file string) (string, error)
+
+	// ioregTTL is how long cached ioreg output stays fresh
+	ioregTTL   time.Duration
+	ioregMu    sync.Mutex
+	ioregCache string
+	ioregAt    time.Time
+
+	// powerLogInterval is the refresh interval PowerLog's runs are sized
+	// from, and powerLogErr why PowerLog failed, after which the other
+	// sources are used instead
+	powerLogMu       sync.Mutex
+	powerLogInterval time.Duration
+	powerLogErr      error
+
+	// stream is a long-running powermetrics process, if streaming, and
+	// streamInterval the refresh interval it was started for
+	streamMu       sync.Mutex
+	stream         *powermetricsStream
+	streamInterval time.Duration
+	// startStream starts a powermetrics stream, like startPowermetricsStream
+	startStream func(interval time.Duration, samples int, samplers string) (*powermetricsStream, error)
 }
 
-// NewDarwinMonitor creates a new macOS power monitor.
+// NewDarwinMonitor creates a new macOS power monitor. On Intel Macs with
+// Intel Power Gadget installed, its package power takes priority.
 func NewDarwinMonitor() *DarwinMonitor {
-	m := &DarwinMonitor{}
+	m := newDarwinMonitorWithRunner(execRunner{})
+	if runtime.GOARCH == "amd64" {
+		m.powerLogPath = findPowerLog()
+	}
+	return m
+}
+
+// findPowerLog returns the path to Intel Power Gadget's PowerLog CLI, or an
+// empty string if it isn't installed.
+func findPowerLog() string {
+	for _, p := range powerLogPaths {
+		if path, err := exec.LookPath(p); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// newDarwinMonitorWithRunner creates a macOS power monitor that runs system
+// utilities through runner.
+func newDarwinMonitorWithRunner(runner commandRunner) *DarwinMonitor {
+	m := &DarwinMonitor{
+		sampleCount: 1,
+		powerMetric: PowerMetricAdapter,
+		runner:      runner,
+		lookPath:    exec.LookPath,
+		ioregTTL:    DefaultIoregCacheTTL,
+		startStream: startPowermetricsStream,
+	}
 	m.detectCapabilities()
 	return m
 }
 
+// SetIoregCacheTTL sets how long raw ioreg output is reused between reads.
+// A TTL of zero disables caching.
+func (m *DarwinMonitor) SetIoregCacheTTL(ttl time.Duration) {
+	m.ioregMu.Lock()
+	defer m.ioregMu.Unlock()
+	m.ioregTTL = ttl
+}
+
 // detectCapabilities checks what power monitoring methods are available.
 func (m *DarwinMonitor) detectCapabilities() {
-	// Check if we have a battery
-	cmd := exec.Command("ioreg", "-rn", "AppleSmartBattery")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err == nil && strings.Contains(out.String(), "AppleSmartBattery") {
+	// Check if we have a battery (this also primes the ioreg cache)
+	if out, err := m.runIoreg(context.Background()); err == nil && strings.Contains(out, "AppleSmartBattery") {
 		m.hasBattery = true
 	}
 	m.checkedBattery = true
 
+	// Intel Macs with a discrete GPU expose its power under AGPM
+	if out, err := m.runner.Run(context.Background(), "ioreg", "-rc", "AGPMController"); err == nil {
+		_, m.hasDiscreteGPU = parseAGPMGPUWatts(string(out))
+	}
+
 	// Check if we have root privileges (needed for powermetrics on desktops)
 	m.hasRoot = os.Geteuid() == 0
 
@@ -71,6 +188,9 @@ func (m *DarwinMonitor) detectCapabilities() {
 
 // Name returns the name of this monitor.
 func (m *DarwinMonitor) Name() string {
+	if m.HasPowerGadget() {
+		return powerGadgetName
+	}
 	if m.usePowermetrics {
 		return "macOS-powermetrics"
 	}
@@ -80,11 +200,25 @@ func (m *DarwinMonitor) Name() string {
 	return "macOS-battery"
 }
 
-// IsSupported checks if power monitoring is available on this system.
+// IsSupported checks if power monitoring is available on this system: pmset
+// must be installed, and power must be readable from a battery, from
+// powermetrics as root, or from Intel Power Gadget. A desktop Mac without
+// root is unsupported, and NeedsSudo reports that sudo would fix it.
 func (m *DarwinMonitor) IsSupported() bool {
-	// Always supported on macOS - we have fallbacks
-	_, err := exec.LookPath("pmset")
-	return err == nil
+	if _, err := m.lookPath("pmset"); err != nil {
+		return false
+	}
+	return m.hasBattery || m.hasRoot || m.HasPowerGadget()
+}
+
+// Close stops the streaming powermetrics process, if one was started.
+func (m *DarwinMonitor) Close() error {
+	m.streamMu.Lock()
+	defer m.streamMu.Unlock()
+	if m.stream == nil {
+		return nil
+	}
+	return m.stream.Close()
 }
 
 // HasBattery returns true if the system has a battery.
@@ -92,34 +226,165 @@ func (m *DarwinMonitor) HasBattery() bool {
 	return m.hasBattery
 }
 
+// HasPowerGadget returns true if Intel Power Gadget is used for power
+// readings. It turns false once PowerLog has failed.
+func (m *DarwinMonitor) HasPowerGadget() bool {
+	m.powerLogMu.Lock()
+	defer m.powerLogMu.Unlock()
+	return m.powerLogPath != "" && m.powerLogErr == nil
+}
+
+// SetInterval sets the refresh interval, which each PowerLog run is sized
+// from.
+func (m *DarwinMonitor) SetInterval(interval time.Duration) {
+	m.powerLogMu.Lock()
+	defer m.powerLogMu.Unlock()
+	m.powerLogInterval = interval
+}
+
+// HasDiscreteGPU returns true if the system has a discrete GPU reporting power.
+func (m *DarwinMonitor) HasDiscreteGPU() bool {
+	return m.hasDiscreteGPU
+}
+
+// SetSampleCount sets how many powermetrics samples are averaged per reading.
+// Values below 1 are treated as 1. A running stream is restarted to take
+// that many samples per interval.
+func (m *DarwinMonitor) SetSampleCount(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	m.streamMu.Lock()
+	defer m.streamMu.Unlock()
+	if n == m.sampleCount {
+		return
+	}
+	m.sampleCount = n
+	if m.stream != nil {
+		// If the restart fails, reads fall back to running powermetrics
+		// with the new count each time
+		_ = m.restartStream(m.streamInterval)
+	}
+}
+
+// SetPowerMetric sets which ioreg power figure battery Macs report: adapter
+// input (the default), system load, or battery power. Other sources such as
+// powermetrics aren't affected.
+func (m *DarwinMonitor) SetPowerMetric(metric string) error {
+	switch metric {
+	case PowerMetricAdapter, PowerMetricSystem, PowerMetricBattery:
+		m.powerMetric = metric
+		return nil
+	default:
+		return fmt.Errorf("unknown power metric %q (choose from adapter, system, battery)", metric)
+	}
+}
+
+// PreferComponents switches battery Macs to powermetrics, which breaks power
+// down by component, when running as root. Desktops already use it whenever
+// they can.
+func (m *DarwinMonitor) PreferComponents() bool {
+	if !m.hasRoot {
+		return false
+	}
+	m.usePowermetrics = true
+	return true
+}
+
+// StartStreaming keeps a single powermetrics process running instead of
+// starting one per reading, taking the configured number of samples every
+// interval. Reads then average its latest samples. It fails unless the
+// monitor reads from powermetrics, which needs root. Calling it again with a
+// new interval, e.g. after the refresh interval changes, restarts the
+// process; Close stops it.
+func (m *DarwinMonitor) StartStreaming(interval time.Duration) error {
+	if !m.usePowermetrics {
+		return errors.New("streaming needs powermetrics; run with sudo")
+	}
+
+	m.streamMu.Lock()
+	defer m.streamMu.Unlock()
+	if m.stream != nil && m.streamInterval == interval {
+		return nil
+	}
+	return m.restartStream(interval)
+}
+
+// restartStream replaces any running powermetrics stream with one for
+// interval. The caller must hold streamMu.
+func (m *DarwinMonitor) restartStream(interval time.Duration) error {
+	if m.stream != nil {
+		if err := m.stream.Close(); err != nil {
+			return err
+		}
+		m.stream = nil
+	}
+	stream, err := m.startStream(interval, m.sampleCount, m.powermetricsSamplers())
+	if err != nil {
+		return err
+	}
+	m.stream = stream
+	m.streamInterval = interval
+	return nil
+}
+
 // NeedsSudo returns true if power monitoring would benefit from sudo.
 func (m *DarwinMonitor) NeedsSudo() bool {
-	return !m.hasBattery && !m.hasRoot
+	return !m.hasBattery && !m.hasRoot && !m.HasPowerGadget()
 }
 
-// Read returns the current power consumption reading.
+// Read returns the current power consumption reading. The reading is stamped
+// when sampling finishes, since pmset, ioreg and powermetrics can take a
+// noticeable fraction of the refresh interval.
 func (m *DarwinMonitor) Read(ctx context.Context) (Reading, error) {
+	reading, err := m.read(ctx)
+	reading.Timestamp = time.Now()
+	return reading, err
+}
+
+// read collects a reading from whichever sources are available.
+func (m *DarwinMonitor) read(ctx context.Context) (Reading, error) {
+	// Intel Power Gadget reports package power directly; once it fails,
+	// fall back to the other sources for good
+	if m.HasPowerGadget() {
+		if r, err := m.readFromPowerGadget(ctx); err == nil {
+			return r, nil
+		}
+	}
+
 	reading := Reading{
-		Timestamp:      time.Now(),
 		BatteryPercent: -1, // Default to not available
 		Source:         m.Name(),
 	}
 
-	// Desktop Mac with root access: use powermetrics
+	// Desktop Mac with root access, or a laptop preferring components: use
+	// powermetrics, keeping a laptop's battery status from pmset
 	if m.usePowermetrics {
+		if m.hasBattery {
+			if pmsetData, err := m.runPmset(ctx); err == nil {
+				m.parsePmset(pmsetData, &reading)
+			}
+		}
 		return m.readFromPowermetrics(ctx, reading)
 	}
 
 	// Get battery info from pmset
 	pmsetData, err := m.runPmset(ctx)
+	if errors.Is(err, exec.ErrNotFound) {
+		return reading, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
 	if err != nil {
 		return reading, err
 	}
 	m.parsePmset(pmsetData, &reading)
 
-	// If no battery, we can't get power data without sudo
+	// If no battery, we can't get power data without sudo; the reading
+	// still carries the AC state from pmset
 	if !m.hasBattery {
-		// Return reading with 0 watts - UI will show helpful message
+		if m.NeedsSudo() {
+			return reading, fmt.Errorf("desktop Macs report power through powermetrics, which needs sudo: %w", ErrNeedsPrivilege)
+		}
 		return reading, nil
 	}
 
@@ -129,105 +394,494 @@ func (m *DarwinMonitor) Read(ctx context.Context) (Reading, error) {
 		return reading, nil
 	}
 
+	// Get battery capacity in mAh
+	m.parseCapacityFromIoreg(ioregData, &reading)
+
+	// Get battery temperature
+	reading.Temperature = parseTemperatureFromIoreg(ioregData)
+
+	// Get battery wear
+	reading.CycleCount = parseCycleCountFromIoreg(ioregData)
+
+	// Get the charger's rating while plugged in
+	if !reading.IsOnBattery {
+		reading.AdapterMaxWatts = parseAdapterWattsFromIoreg(ioregData)
+	}
+
 	// Get power consumption from ioreg (Apple Silicon and Intel with power metrics)
-	watts := m.parseWattsFromIoreg(ioregData)
+	watts, kind := m.parseWattsFromIoreg(ioregData)
 	if watts > 0 {
 		reading.Watts = watts
+		reading.PowerKind = kind
 	} else {
 		// Fallback: estimate based on battery discharge if available
 		watts = m.estimateWattsFromIoreg(ioregData)
 		if watts > 0 {
 			reading.Watts = watts
+			reading.PowerKind = batteryPowerKind(reading)
+		}
+	}
+	reading.WattsAvailable = reading.Watts > 0
+
+	// The battery telemetry already covers the dGPU, so it's only broken out
+	if m.hasDiscreteGPU {
+		if dgpu, ok := m.readDiscreteGPUWatts(ctx); ok {
+			reading.Components = map[string]float64{ComponentDiscreteGPU: dgpu}
 		}
 	}
 
 	return reading, nil
 }
 
-// readFromPowermetrics reads power data using powermetrics (requires root).
+// powermetricsSamplers returns the powermetrics samplers to request.
+func (m *DarwinMonitor) powermetricsSamplers() string {
+	if m.hasDiscreteGPU {
+		return "cpu_power,gpu_power"
+	}
+	return "cpu_power"
+}
+
+// readFromPowermetrics reads power data using powermetrics (requires root),
+// from the streaming process while it runs and otherwise by running it for
+// the configured number of samples.
 func (m *DarwinMonitor) readFromPowermetrics(ctx context.Context, reading Reading) (Reading, error) {
-	// Run powermetrics for a single sample
-	cmd := exec.CommandContext(ctx, "powermetrics",
-		"-n", "1", // Single sample
+	m.streamMu.Lock()
+	stream := m.stream
+	m.streamMu.Unlock()
+	if stream != nil {
+		if sample, running := stream.Latest(); running {
+			// Until the first sample arrives there's no data yet
+			return m.readingFromPowermetrics(ctx, reading, sample), nil
+		}
+	}
+
+	out, err := m.runner.Run(ctx, "powermetrics",
+		"-n", strconv.Itoa(m.sampleCount),
 		"-i", "100", // 100ms sample interval
-		"--samplers", "cpu_power",
+		"--samplers", m.powermetricsSamplers(),
 		"-f", "text",
 	)
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
+	if err != nil {
 		// Fall back to no data
 		return reading, nil
 	}
+	return m.readingFromPowermetrics(ctx, reading, string(out)), nil
+}
+
+// readingFromPowermetrics fills in reading from powermetrics output.
+func (m *DarwinMonitor) readingFromPowermetrics(ctx context.Context, reading Reading, out string) Reading {
+	reading.Watts, reading.Components = m.parsePowermetricsSamples(out)
+	reading.CPUFreqMHz = parsePowermetricsFrequency(out)
+	reading.WattsAvailable = reading.Watts > 0
+	if reading.WattsAvailable {
+		reading.PowerKind = PowerKindSystem
+	}
+
+	// Some powermetrics versions omit the dGPU; fall back to AGPM
+	if m.hasDiscreteGPU && reading.Watts > 0 {
+		if _, ok := reading.Components[ComponentDiscreteGPU]; !ok {
+			if dgpu, ok := m.readDiscreteGPUWatts(ctx); ok {
+				if reading.Components == nil {
+					reading.Components = map[string]float64{}
+				}
+				reading.Components[ComponentDiscreteGPU] = dgpu
+				reading.Watts += dgpu
+			}
+		}
+	}
+
+	return reading
+}
+
+// powermetricsStream holds the latest samples from a long-running
+// powermetrics process. A goroutine consumes the process's output as it
+// streams in.
+type powermetricsStream struct {
+	cmd  *exec.Cmd // nil when fed from a reader in tests
+	done chan struct{}
+	keep int // how many recent samples Latest returns; below 1 means 1
+
+	mu      sync.Mutex
+	samples []string
+	ended   bool
+}
+
+// startPowermetricsStream starts powermetrics taking samples evenly spread
+// over every interval, with no sample limit, and streams its output into a
+// powermetricsStream that keeps that many.
+func startPowermetricsStream(interval time.Duration, samples int, samplers string) (*powermetricsStream, error) {
+	samples = max(samples, 1)
+	ms := max(interval.Milliseconds()/int64(samples), 1)
+	cmd := exec.Command("powermetrics",
+		"-i", strconv.FormatInt(ms, 10),
+		"--samplers", samplers,
+		"-f", "text",
+	)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("starting powermetrics: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting powermetrics: %w", err)
+	}
+
+	s := &powermetricsStream{cmd: cmd, done: make(chan struct{}), keep: samples}
+	go func() {
+		defer close(s.done)
+		s.consume(stdout)
+		cmd.Wait()
+	}()
+	return s, nil
+}
+
+// consume reads powermetrics text output from r until it ends, keeping the
+// most recent complete sample blocks. A block is complete once the next one
+// starts, or the output ends. Anything before the first block is ignored.
+func (s *powermetricsStream) consume(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	var block strings.Builder
+	inSample := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, powermetricsSampleHeader) {
+			if inSample {
+				s.addSample(block.String())
+			}
+			block.Reset()
+			inSample = true
+		}
+		if inSample {
+			block.WriteString(line)
+			block.WriteByte('\n')
+		}
+	}
+	if inSample {
+		s.addSample(block.String())
+	}
+
+	s.mu.Lock()
+	s.ended = true
+	s.mu.Unlock()
+}
+
+// addSample records sample as the most recent one, dropping the oldest once
+// more than keep are held.
+func (s *powermetricsStream) addSample(sample string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, sample)
+	if extra := len(s.samples) - max(s.keep, 1); extra > 0 {
+		s.samples = s.samples[extra:]
+	}
+}
+
+// Latest returns the most recent complete samples, oldest first, which is
+// empty until the first one arrives, and whether the process is still
+// producing output.
+func (s *powermetricsStream) Latest() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.samples, ""), !s.ended
+}
+
+// Close terminates the powermetrics process and waits for its output to be
+// consumed.
+func (s *powermetricsStream) Close() error {
+	if s.cmd != nil && s.cmd.Process != nil {
+		if err := s.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return fmt.Errorf("stopping powermetrics: %w", err)
+		}
+	}
+	<-s.done
+	return nil
+}
+
+// readFromPowerGadget reads package power with Intel Power Gadget's PowerLog
+// CLI, plus battery state from pmset. If PowerLog fails for any reason but
+// the read being canceled, the failure is kept and it isn't run again.
+func (m *DarwinMonitor) readFromPowerGadget(ctx context.Context) (Reading, error) {
+	reading := Reading{BatteryPercent: -1, Source: powerGadgetName}
+
+	m.powerLogMu.Lock()
+	seconds, resolution := powerLogSampling(m.powerLogInterval)
+	m.powerLogMu.Unlock()
 
-	output := out.String()
-	reading.Watts = m.parsePowermetrics(output)
+	// Log straight to stdout
+	out, err := m.runner.Run(ctx, m.powerLogPath,
+		"-resolution", strconv.Itoa(resolution),
+		"-duration", strconv.Itoa(seconds),
+		"-file", "/dev/stdout",
+	)
+	var watts float64
+	var components map[string]float64
+	if err == nil {
+		watts, components, err = parsePowerLogCSV(string(out))
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			m.powerLogMu.Lock()
+			m.powerLogErr = err
+			m.powerLogMu.Unlock()
+		}
+		return reading, err
+	}
+	reading.Watts = watts
+	reading.WattsAvailable = true
+	reading.PowerKind = PowerKindPackage
+	reading.Components = components
 
+	if pmsetData, err := m.runPmset(ctx); err == nil {
+		m.parsePmset(pmsetData, &reading)
+	}
 	return reading, nil
 }
 
+// powerLogSampling returns PowerLog's -duration in seconds and -resolution
+// in milliseconds for a refresh interval. Each run logs for half the
+// interval, in whole seconds and at least one, so a read finishes well before
+// the next is due, and takes ten samples. Without an interval it logs for one
+// second.
+func powerLogSampling(interval time.Duration) (seconds, resolution int) {
+	seconds = max(1, int(interval/2/time.Second))
+	return seconds, seconds * 1000 / 10
+}
+
+// parsePowerLogCSV parses Intel Power Gadget's PowerLog CSV output into
+// package power and a CPU (IA) and GPU (GT) breakdown, in watts. Package power
+// comes from the "Average Processor Power" summary lines when present and is
+// otherwise averaged over the sample rows; multiple packages are summed.
+func parsePowerLogCSV(output string) (float64, map[string]float64, error) {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+
+	// Find the header row
+	header := -1
+	var columns []string
+	for i, line := range lines {
+		if strings.Contains(line, "Processor Power_") && strings.Contains(line, ",") {
+			header = i
+			columns = strings.Split(line, ",")
+			break
+		}
+	}
+	if header < 0 {
+		return 0, nil, errors.New("no PowerLog header found")
+	}
+
+	var sums [3]float64 // package, IA, GT
+	var rows int
+	for _, line := range lines[header+1:] {
+		fields := strings.Split(line, ",")
+		if len(fields) != len(columns) {
+			// Blank line or the summary after the samples
+			continue
+		}
+		rows++
+		for i, col := range columns {
+			col = strings.TrimSpace(col)
+			v, err := strconv.ParseFloat(strings.TrimSpace(fields[i]), 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case powerLogPackageRe.MatchString(col):
+				sums[0] += v
+			case powerLogIARe.MatchString(col):
+				sums[1] += v
+			case powerLogGTRe.MatchString(col):
+				sums[2] += v
+			}
+		}
+	}
+
+	var watts float64
+	averages := powerLogAverageRe.FindAllStringSubmatch(output, -1)
+	for _, matches := range averages {
+		if w, err := strconv.ParseFloat(matches[1], 64); err == nil {
+			watts += w
+		}
+	}
+	if len(averages) == 0 {
+		if rows == 0 {
+			return 0, nil, errors.New("no PowerLog samples found")
+		}
+		watts = sums[0] / float64(rows)
+	}
+
+	var components map[string]float64
+	if rows > 0 && (sums[1] > 0 || sums[2] > 0) {
+		components = map[string]float64{
+			ComponentCPU: sums[1] / float64(rows),
+			ComponentGPU: sums[2] / float64(rows),
+		}
+	}
+	return watts, components, nil
+}
+
+// parsePowermetricsSamples averages power and its component breakdown across
+// every sample block in powermetrics output. Blocks without any power data
+// are skipped.
+func (m *DarwinMonitor) parsePowermetricsSamples(output string) (float64, map[string]float64) {
+	blocks := strings.Split(output, powermetricsSampleHeader)
+
+	var sum float64
+	var count int
+	var components map[string]float64
+	for _, block := range blocks {
+		watts := m.parsePowermetrics(block)
+		if watts <= 0 {
+			continue
+		}
+		sum += watts
+		count++
+		for name, w := range parsePowermetricsComponents(block) {
+			if components == nil {
+				components = map[string]float64{}
+			}
+			components[name] += w
+		}
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+	for name := range components {
+		components[name] /= float64(count)
+	}
+	return sum / float64(count), components
+}
+
+// parsePowermetricsFrequency averages the CPU frequency, in MHz, across
+// every sample block in powermetrics output. Blocks without a frequency are
+// skipped, and 0 is returned if none report one.
+func parsePowermetricsFrequency(output string) float64 {
+	var sum float64
+	var count int
+	for _, block := range strings.Split(output, powermetricsSampleHeader) {
+		if mhz := powermetricsBlockFrequency(block); mhz > 0 {
+			sum += mhz
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// powermetricsBlockFrequency returns the CPU frequency in one powermetrics
+// sample: the average across cores on Intel, or the fastest cluster's active
+// frequency on Apple Silicon.
+func powermetricsBlockFrequency(block string) float64 {
+	if matches := cpuAverageFreqRe.FindStringSubmatch(block); len(matches) >= 2 {
+		if mhz, err := strconv.ParseFloat(matches[1], 64); err == nil {
+			return mhz
+		}
+	}
+
+	var fastest float64
+	for _, matches := range clusterFreqRe.FindAllStringSubmatch(block, -1) {
+		if mhz, err := strconv.ParseFloat(matches[1], 64); err == nil && mhz > fastest {
+			fastest = mhz
+		}
+	}
+	return fastest
+}
+
 // parsePowermetrics extracts power consumption from powermetrics output.
 func (m *DarwinMonitor) parsePowermetrics(output string) float64 {
-	var totalWatts float64
+	components := parsePowermetricsComponents(output)
+
+	// Combined and package power only cover the SoC or CPU package, so a
+	// discrete GPU is added on top
+	dgpu := components[ComponentDiscreteGPU]
 
 	// Try to find Combined Power first (most accurate for total system)
 	if matches := combinedPowerRe.FindStringSubmatch(output); len(matches) >= 2 {
 		if mw, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			return mw / 1000.0 // Convert mW to W
+			return mw/1000.0 + dgpu // Convert mW to W
 		}
 	}
 
 	// Try Package Power (common on Apple Silicon)
 	if matches := packagePowerRe.FindStringSubmatch(output); len(matches) >= 2 {
 		if mw, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			return mw / 1000.0 // Convert mW to W
+			return mw/1000.0 + dgpu // Convert mW to W
 		}
 	}
 
-	// Otherwise, sum CPU + GPU + ANE power
-	if matches := cpuPowerRe.FindStringSubmatch(output); len(matches) >= 2 {
-		if mw, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			totalWatts += mw / 1000.0
-		}
+	// Otherwise, sum CPU + GPU + ANE (+ dGPU) power
+	var totalWatts float64
+	for _, w := range components {
+		totalWatts += w
 	}
+	return totalWatts
+}
 
-	if matches := gpuPowerRe.FindStringSubmatch(output); len(matches) >= 2 {
+// parsePowermetricsComponents extracts per-component power, in watts, from
+// powermetrics output. It returns nil if no component lines are found.
+func parsePowermetricsComponents(output string) map[string]float64 {
+	var components map[string]float64
+	for name, re := range powermetricsComponents {
+		matches := re.FindStringSubmatch(output)
+		if len(matches) < 2 {
+			continue
+		}
 		if mw, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			totalWatts += mw / 1000.0
+			if components == nil {
+				components = map[string]float64{}
+			}
+			components[name] = mw / 1000.0 // Convert mW to W
 		}
 	}
+	return components
+}
 
-	if matches := anePowerRe.FindStringSubmatch(output); len(matches) >= 2 {
-		if mw, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			totalWatts += mw / 1000.0
-		}
+// readDiscreteGPUWatts reads the discrete GPU's power draw from the AGPM
+// controller in ioreg.
+func (m *DarwinMonitor) readDiscreteGPUWatts(ctx context.Context) (float64, bool) {
+	out, err := m.runner.Run(ctx, "ioreg", "-rc", "AGPMController")
+	if err != nil {
+		return 0, false
 	}
+	return parseAGPMGPUWatts(string(out))
+}
 
-	return totalWatts
+// parseAGPMGPUWatts parses discrete GPU power from AGPM ioreg output. The
+// second result reports whether a GPU power field was present at all, since
+// an idle dGPU may legitimately report 0.
+func parseAGPMGPUWatts(output string) (float64, bool) {
+	matches := agpmGPUPowerRe.FindStringSubmatch(output)
+	if len(matches) < 2 {
+		return 0, false
+	}
+	mw, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return mw / 1000.0, true
 }
 
 // runPmset executes pmset -g batt and returns output.
 func (m *DarwinMonitor) runPmset(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "pmset", "-g", "batt")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
+	out, err := m.runner.Run(ctx, "pmset", "-g", "batt")
+	if err != nil {
 		return "", err
 	}
-	return out.String(), nil
+	return string(out), nil
 }
 
 // parsePmset parses pmset -g batt output to extract battery information.
 func (m *DarwinMonitor) parsePmset(output string, reading *Reading) {
 	lines := strings.Split(output, "\n")
 
-	// First line usually indicates power source
-	if len(lines) > 0 {
-		firstLine := strings.ToLower(lines[0])
-		reading.IsOnBattery = strings.Contains(firstLine, "battery power")
+	// The power source is usually the first line, but don't rely on it
+	// Example: "Now drawing from 'Battery Power'"
+	for _, line := range lines {
+		lineLower := strings.ToLower(line)
+		if strings.Contains(lineLower, "drawing from") {
+			reading.IsOnBattery = strings.Contains(lineLower, "battery power")
+			break
+		}
 	}
 
 	// Look for battery percentage and charging status
@@ -248,27 +902,67 @@ func (m *DarwinMonitor) parsePmset(output string, reading *Reading) {
 				// Only set charging if we didn't find discharging
 				reading.IsCharging = true
 			}
+			// While charging, the estimate is the time until full
+			if strings.Contains(lineLower, "discharging") {
+				reading.TimeRemaining = parsePmsetRemaining(line)
+			}
 		}
 	}
 }
 
-// runIoreg executes ioreg and returns output for AppleSmartBattery.
+// parsePmsetRemaining parses the time estimate from a pmset battery line,
+// e.g. "3:45 remaining". Returns 0 while pmset says "(no estimate)".
+func parsePmsetRemaining(line string) time.Duration {
+	matches := pmsetRemainingRe.FindStringSubmatch(line)
+	if len(matches) < 3 {
+		return 0
+	}
+	hours, err1 := strconv.Atoi(matches[1])
+	mins, err2 := strconv.Atoi(matches[2])
+	if err1 != nil || err2 != nil {
+		return 0
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(mins)*time.Minute
+}
+
+// runIoreg executes ioreg and returns output for AppleSmartBattery. Output
+// younger than the cache TTL is reused instead of spawning ioreg again.
 func (m *DarwinMonitor) runIoreg(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "ioreg", "-rn", "AppleSmartBattery")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
+	m.ioregMu.Lock()
+	defer m.ioregMu.Unlock()
+
+	if m.ioregTTL > 0 && !m.ioregAt.IsZero() && time.Since(m.ioregAt) < m.ioregTTL {
+		return m.ioregCache, nil
+	}
+
+	out, err := m.runner.Run(ctx, "ioreg", "-rn", "AppleSmartBattery")
+	if err != nil {
 		return "", err
 	}
-	return out.String(), nil
+	m.ioregCache = string(out)
+	m.ioregAt = time.Now()
+	return m.ioregCache, nil
 }
 
-// parseWattsFromIoreg parses power consumption from ioreg output.
-func (m *DarwinMonitor) parseWattsFromIoreg(output string) float64 {
-	if watts := m.parseTelemetryWattsFromIoreg(output); watts > 0 {
-		return watts
+// parseWattsFromIoreg parses power consumption from ioreg output, preferring
+// the figure selected by the power metric, and says what kind of power it is.
+func (m *DarwinMonitor) parseWattsFromIoreg(output string) (float64, PowerKind) {
+	if m.powerMetric == PowerMetricBattery {
+		if watts := ioregWatts(output, batteryPowerRe); watts != 0 {
+			return math.Abs(watts), batteryFlowKind(watts)
+		}
+		return batteryWattsFromIoreg(output)
 	}
 
+	if watts, kind := m.parseTelemetryWattsFromIoreg(output); watts > 0 {
+		return watts, kind
+	}
+	return batteryWattsFromIoreg(output)
+}
+
+// batteryWattsFromIoreg calculates battery power from InstantAmperage and
+// Voltage.
+func batteryWattsFromIoreg(output string) (float64, PowerKind) {
 	// Look for InstantAmperage and Voltage to calculate watts
 	// Watts = Voltage * Amperage
 	var voltage, amperage float64
@@ -291,51 +985,63 @@ func (m *DarwinMonitor) parseWattsFromIoreg(output string) float64 {
 	if voltage > 0 && amperage != 0 {
 		// Power in watts, use absolute value for display
 		watts := voltage * amperage
-		if watts < 0 {
-			watts = -watts
-		}
-		return watts
+		return math.Abs(watts), batteryFlowKind(watts)
 	}
 
-	return 0
+	return 0, ""
 }
 
-func (m *DarwinMonitor) parseTelemetryWattsFromIoreg(output string) float64 {
-	// Prefer adapter input power when available (AC power).
-	if matches := systemPowerInRe.FindStringSubmatch(output); len(matches) >= 2 {
-		if v, ok := parseIoregSigned(matches[1]); ok {
-			if v != 0 {
-				return math.Abs(float64(v)) / 1000.0
-			}
-		}
+func (m *DarwinMonitor) parseTelemetryWattsFromIoreg(output string) (float64, PowerKind) {
+	// Prefer adapter input power when available (AC power), unless the
+	// system's own load was asked for.
+	sources := []struct {
+		re   *regexp.Regexp
+		kind PowerKind
+	}{
+		{systemPowerInRe, PowerKindAdapter},
+		{systemLoadRe, PowerKindSystem},
 	}
-
-	// Fall back to system load (total consumption), available on many Macs.
-	if matches := systemLoadRe.FindStringSubmatch(output); len(matches) >= 2 {
-		if v, ok := parseIoregSigned(matches[1]); ok {
-			if v != 0 {
-				return math.Abs(float64(v)) / 1000.0
-			}
+	if m.powerMetric == PowerMetricSystem {
+		sources[0], sources[1] = sources[1], sources[0]
+	}
+	for _, src := range sources {
+		if watts := ioregWatts(output, src.re); watts != 0 {
+			return math.Abs(watts), src.kind
 		}
 	}
 
 	// If we have current and voltage in, calculate power.
 	if watts := calculateInputPower(output); watts > 0 {
-		return watts
+		return watts, PowerKindAdapter
 	}
 
 	// Last resort: battery power (may be negative when discharging).
-	if matches := batteryPowerRe.FindStringSubmatch(output); len(matches) >= 2 {
+	if watts := ioregWatts(output, batteryPowerRe); watts != 0 {
+		return math.Abs(watts), batteryFlowKind(watts)
+	}
+	return 0, ""
+}
+
+// ioregWatts returns the signed milliwatt key matched by re, in watts, or 0
+// if it's missing.
+func ioregWatts(output string, re *regexp.Regexp) float64 {
+	if matches := re.FindStringSubmatch(output); len(matches) >= 2 {
 		if v, ok := parseIoregSigned(matches[1]); ok {
-			if v != 0 {
-				return math.Abs(float64(v)) / 1000.0
-			}
+			return float64(v) / 1000.0
 		}
 	}
-
 	return 0
 }
 
+// batteryFlowKind classifies signed battery power, which is positive while
+// the battery charges and negative while it drains.
+func batteryFlowKind(watts float64) PowerKind {
+	if watts > 0 {
+		return PowerKindCharge
+	}
+	return PowerKindDischarge
+}
+
 func calculateInputPower(output string) float64 {
 	matchesCurrent := systemCurrentInRe.FindStringSubmatch(output)
 	matchesVoltage := systemVoltageInRe.FindStringSubmatch(output)
@@ -376,6 +1082,109 @@ func parseIoregSigned(value string) (int64, bool) {
 	return int64(v), true
 }
 
+// parseCapacityFromIoreg parses battery capacities (in mAh) from ioreg output.
+// Apple Silicon reports MaxCapacity and CurrentCapacity as percentages and the
+// real values as AppleRawMaxCapacity and AppleRawCurrentCapacity, so the raw
+// keys are preferred and percentage-sized values are ignored.
+func (m *DarwinMonitor) parseCapacityFromIoreg(output string, reading *Reading) {
+	reading.CapacityDesign = firstIoregCapacity(output, designCapacityRe)
+	reading.CapacityFull = firstIoregCapacity(output, rawMaxCapacityRe, maxCapacityRe)
+	reading.CapacityNow = firstIoregCapacity(output, rawCurCapacityRe, currentCapacityRe)
+
+	if reading.CapacityDesign > 0 || reading.CapacityFull > 0 || reading.CapacityNow > 0 {
+		reading.CapacityUnit = "mAh"
+	}
+	reading.BatteryHealthPercent = batteryHealthPercent(*reading)
+}
+
+// parseTemperatureFromIoreg parses the battery temperature from ioreg
+// output, which reports it in hundredths of a degree Celsius.
+func parseTemperatureFromIoreg(output string) float64 {
+	if matches := temperatureRe.FindStringSubmatch(output); len(matches) >= 2 {
+		if v, err := strconv.ParseFloat(matches[1], 64); err == nil {
+			return v / 100.0
+		}
+	}
+	return 0
+}
+
+// parseCycleCountFromIoreg parses the battery's charge cycle count from
+// ioreg output, or returns 0 if it isn't reported.
+func parseCycleCountFromIoreg(output string) int {
+	if matches := cycleCountRe.FindStringSubmatch(output); len(matches) >= 2 {
+		if v, err := strconv.Atoi(matches[1]); err == nil {
+			return v
+		}
+	}
+	return 0
+}
+
+// parseAdapterWattsFromIoreg parses the connected charger's rated wattage
+// from the "Watts" key of AdapterDetails, falling back to the first entry of
+// AppleRawAdapterDetails, or returns 0 if neither reports one.
+//
+// Example:
+//
+//	"AdapterDetails" = {"AdapterID"=0,"Watts"=67,"UsbHvcMenu"=({"Index"=0,...}),...}
+func parseAdapterWattsFromIoreg(output string) float64 {
+	for _, re := range []*regexp.Regexp{adapterDetailsRe, rawAdapterDetailsRe} {
+		dict := ioregTopLevel(output, re)
+		if matches := adapterWattsRe.FindStringSubmatch(dict); len(matches) >= 2 {
+			if v, err := strconv.ParseFloat(matches[1], 64); err == nil && v > 0 {
+				return v
+			}
+		}
+	}
+	return 0
+}
+
+// ioregTopLevel returns the body of the dictionary whose opening brace ends
+// the match of re, with the contents of nested dictionaries and arrays left
+// out, so that only the dictionary's own keys can match. It returns "" if re
+// doesn't match.
+func ioregTopLevel(output string, re *regexp.Regexp) string {
+	loc := re.FindStringIndex(output)
+	if loc == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	depth, quoted := 1, false
+	for _, c := range output[loc[1]:] {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '{' || c == '(':
+			depth++
+			continue
+		case c == '}' || c == ')':
+			depth--
+			if depth == 0 {
+				return b.String()
+			}
+			continue
+		}
+		if depth == 1 {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// firstIoregCapacity returns the first value above 100 matched by the given
+// regexes in order, or 0 if none match.
+func firstIoregCapacity(output string, res ...*regexp.Regexp) float64 {
+	for _, re := range res {
+		if matches := re.FindStringSubmatch(output); len(matches) >= 2 {
+			if v, err := strconv.ParseFloat(matches[1], 64); err == nil && v > 100 {
+				return v
+			}
+		}
+	}
+	return 0
+}
+
 // estimateWattsFromIoreg estimates power consumption from ioreg battery data.
 func (m *DarwinMonitor) estimateWattsFromIoreg(output string) float64 {
 	// Try to calculate from battery capacity and current draw
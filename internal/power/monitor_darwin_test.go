@@ -4,6 +4,12 @@ package power
 
 import (
 	"context"
+	"errors"
+	"io"
+	"math"
+	"os/exec"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -12,7 +18,7 @@ func TestDarwinMonitor_Name(t *testing.T) {
 	m := NewDarwinMonitor()
 	name := m.Name()
 	// Name should be one of the valid names based on system configuration
-	validNames := []string{"macOS-battery", "macOS-desktop", "macOS-powermetrics"}
+	validNames := []string{"macOS-battery", "macOS-desktop", "macOS-powermetrics", "macOS-power-gadget"}
 	valid := false
 	for _, validName := range validNames {
 		if name == validName {
@@ -27,9 +33,52 @@ func TestDarwinMonitor_Name(t *testing.T) {
 
 func TestDarwinMonitor_IsSupported(t *testing.T) {
 	m := NewDarwinMonitor()
-	// On macOS, pmset should always be available
-	if !m.IsSupported() {
-		t.Error("expected IsSupported=true on macOS")
+	// On macOS, pmset should always be available, so support only depends
+	// on whether this machine needs sudo
+	if m.IsSupported() == m.NeedsSudo() {
+		t.Errorf("expected IsSupported=%v with NeedsSudo=%v", !m.NeedsSudo(), m.NeedsSudo())
+	}
+}
+
+func TestDarwinMonitor_IsSupportedCapabilities(t *testing.T) {
+	tests := []struct {
+		name          string
+		hasPmset      bool
+		hasBattery    bool
+		hasRoot       bool
+		powerGadget   bool
+		wantSupported bool
+		wantNeedsSudo bool
+	}{
+		{name: "laptop", hasPmset: true, hasBattery: true, wantSupported: true},
+		{name: "laptop as root", hasPmset: true, hasBattery: true, hasRoot: true, wantSupported: true},
+		{name: "desktop as root", hasPmset: true, hasRoot: true, wantSupported: true},
+		{name: "desktop with Power Gadget", hasPmset: true, powerGadget: true, wantSupported: true},
+		{name: "desktop without root", hasPmset: true, wantSupported: false, wantNeedsSudo: true},
+		{name: "no pmset", hasBattery: true, hasRoot: true, wantSupported: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+			m.hasBattery, m.hasRoot = tt.hasBattery, tt.hasRoot
+			if tt.powerGadget {
+				m.powerLogPath = "/usr/local/bin/PowerLog"
+			}
+			m.lookPath = func(file string) (string, error) {
+				if file == "pmset" && tt.hasPmset {
+					return "/usr/bin/pmset", nil
+				}
+				return "", exec.ErrNotFound
+			}
+
+			if got := m.IsSupported(); got != tt.wantSupported {
+				t.Errorf("IsSupported() = %v, want %v", got, tt.wantSupported)
+			}
+			if got := m.NeedsSudo(); got != tt.wantNeedsSudo {
+				t.Errorf("NeedsSudo() = %v, want %v", got, tt.wantNeedsSudo)
+			}
+		})
 	}
 }
 
@@ -81,6 +130,31 @@ func TestDarwinMonitor_ParsePmset(t *testing.T) {
 			wantPercent:  5.0,
 			wantCharging: false,
 		},
+		{
+			name: "power source line not first",
+			input: `
+ -InternalBattery-0 (id=1234567)	62%; discharging; 2:10 remaining present: true
+Now drawing from 'Battery Power'`,
+			wantBattery:  true,
+			wantPercent:  62.0,
+			wantCharging: false,
+		},
+		{
+			name: "warning before power source line",
+			input: `pmset: could not read sleep preventers
+Now drawing from 'Battery Power'
+ -InternalBattery-0 (id=1234567)	40%; discharging; 1:30 remaining present: true`,
+			wantBattery:  true,
+			wantPercent:  40.0,
+			wantCharging: false,
+		},
+		{
+			name:         "AC power without a battery line",
+			input:        `Now drawing from 'AC Power'`,
+			wantBattery:  false,
+			wantPercent:  0,
+			wantCharging: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -102,6 +176,45 @@ func TestDarwinMonitor_ParsePmset(t *testing.T) {
 	}
 }
 
+func TestParsePmsetRemaining(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want time.Duration
+	}{
+		{"hours and minutes", " -InternalBattery-0 (id=1234567)\t75%; discharging; 3:45 remaining present: true", 3*time.Hour + 45*time.Minute},
+		{"under an hour", " -InternalBattery-0 (id=1234567)\t8%; discharging; 0:22 remaining present: true", 22 * time.Minute},
+		{"no estimate", " -InternalBattery-0 (id=1234567)\t75%; discharging; (no estimate) present: true", 0},
+		{"fully charged", " -InternalBattery-0 (id=1234567)\t100%; charged; 0:00 remaining present: true", 0},
+		{"empty", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePmsetRemaining(tt.line); got != tt.want {
+				t.Errorf("parsePmsetRemaining() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("only while discharging", func(t *testing.T) {
+		m := NewDarwinMonitor()
+
+		reading := Reading{BatteryPercent: -1}
+		m.parsePmset(samplePmset, &reading)
+		if reading.TimeRemaining != 3*time.Hour+45*time.Minute {
+			t.Errorf("TimeRemaining = %v, want 3h45m", reading.TimeRemaining)
+		}
+
+		reading = Reading{BatteryPercent: -1}
+		m.parsePmset(`Now drawing from 'AC Power'
+ -InternalBattery-0 (id=1234567)	85%; charging; 1:00 remaining present: true`, &reading)
+		if reading.TimeRemaining != 0 {
+			t.Errorf("expected no time remaining while charging, got %v", reading.TimeRemaining)
+		}
+	})
+}
+
 func TestDarwinMonitor_Read(t *testing.T) {
 	m := NewDarwinMonitor()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -201,6 +314,463 @@ ANE Power: 500 mW`,
 	}
 }
 
+func TestDarwinMonitor_ParsePowermetricsSamples(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{
+			name: "averages multiple samples",
+			input: `*** Sampled system activity (100.00ms elapsed) ***
+Combined Power (CPU + GPU + ANE): 4000 mW
+
+*** Sampled system activity (100.00ms elapsed) ***
+Combined Power (CPU + GPU + ANE): 6000 mW
+
+*** Sampled system activity (100.00ms elapsed) ***
+Combined Power (CPU + GPU + ANE): 8000 mW`,
+			expected: 6.0,
+		},
+		{
+			name: "single sample",
+			input: `*** Sampled system activity (100.00ms elapsed) ***
+Combined Power (CPU + GPU + ANE): 5432 mW`,
+			expected: 5.432,
+		},
+		{
+			name: "skips samples without power data",
+			input: `*** Sampled system activity (100.00ms elapsed) ***
+CPU Power: 3000 mW
+
+*** Sampled system activity (100.00ms elapsed) ***
+Nothing useful here`,
+			expected: 3.0,
+		},
+		{
+			name:     "no power data",
+			input:    `Some other output without power info`,
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewDarwinMonitor()
+			result, _ := m.parsePowermetricsSamples(tt.input)
+
+			diff := result - tt.expected
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff > 0.001 {
+				t.Errorf("parsePowermetricsSamples() = %f, want %f", result, tt.expected)
+			}
+		})
+	}
+}
+
+// sampleIntelDGPUPowermetrics is a powermetrics sample from an Intel MacBook
+// Pro with a discrete AMD GPU.
+const sampleIntelDGPUPowermetrics = `*** Sampled system activity (Tue Mar  4 10:12:01 2025 -0800) (100.42ms elapsed) ***
+
+**** Processor usage ****
+
+Intel energy model derived package power (CPUs+GT+SA): 6.12W
+CPU Power: 4100 mW
+GPU Power: 350 mW
+Package Power: 6120 mW
+
+**** GPU usage ****
+
+GPU 0 name AMD Radeon Pro 5500M
+GPU 0 active residency:  41.20%
+Discrete GPU Power: 9400 mW`
+
+// sampleAGPM is trimmed ioreg -rc AGPMController output from the same machine.
+var sampleAGPM = strings.Join([]string{
+	`+-o AGPMController  <class AGPMController>`,
+	`    "GPUPowerState" = 2`,
+	`    "GPUPower" = 9400`,
+}, "\n")
+
+// sampleAppleSiliconCPUPowermetrics is a trimmed cpu_power sample from an
+// M1 MacBook Air.
+const sampleAppleSiliconCPUPowermetrics = `*** Sampled system activity (Wed Mar  5 09:41:12 2025 -0800) (100.37ms elapsed) ***
+
+**** Processor usage ****
+
+E-Cluster HW active frequency: 1209 MHz
+E-Cluster HW active residency:  38.21% (600 MHz:  12% 972 MHz: 4.1% 1332 MHz:  19% 1704 MHz: 3.0% 2064 MHz:  0%)
+CPU 0 frequency: 1260 MHz
+CPU 0 active residency:  22.43% (600 MHz:  11% 972 MHz: 3.2% 1332 MHz: 6.5% 1704 MHz: 1.7% 2064 MHz:   0%)
+
+P-Cluster HW active frequency: 2516 MHz
+P-Cluster HW active residency:  14.02% (600 MHz: 2.1% 828 MHz:   0% 1056 MHz: 0.3% 3204 MHz:  11%)
+CPU 4 frequency: 2611 MHz
+
+CPU Power: 1250 mW
+GPU Power: 32 mW
+ANE Power: 0 mW
+Combined Power (CPU + GPU + ANE): 1282 mW`
+
+// sampleIntelCPUPowermetrics is a trimmed cpu_power sample from an Intel
+// MacBook Pro.
+const sampleIntelCPUPowermetrics = `*** Sampled system activity (Tue Mar  4 10:12:01 2025 -0800) (100.42ms elapsed) ***
+
+**** Processor usage ****
+
+Package 0 C-state residency: 71.50% (C2: 6.32% C3: 1.96% C6: 0.00% C7: 63.22% C8: 0.00% C9: 0.00% C10: 0.00% )
+CPU 0 duty cycles/s: active/idle [< 16 us: 57.76/0.00] [< 32 us: 0.00/0.00]
+CPU Average frequency as fraction of nominal: 70.46% (1620.63 Mhz)
+System Average frequency as fraction of nominal: 72.39% (1664.99 Mhz)
+
+Intel energy model derived package power (CPUs+GT+SA): 2.04W
+Package Power: 2040 mW`
+
+func TestParsePowermetricsFrequency(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{
+			name:     "apple silicon uses the fastest cluster",
+			input:    sampleAppleSiliconCPUPowermetrics,
+			expected: 2516,
+		},
+		{
+			name:     "intel uses the cpu average, not the system average",
+			input:    sampleIntelCPUPowermetrics,
+			expected: 1620.63,
+		},
+		{
+			name: "averages samples that report a frequency",
+			input: `*** Sampled system activity (100.00ms elapsed) ***
+P-Cluster HW active frequency: 2000 MHz
+
+*** Sampled system activity (100.00ms elapsed) ***
+P-Cluster HW active frequency: 3000 MHz
+
+*** Sampled system activity (100.00ms elapsed) ***
+Combined Power (CPU + GPU + ANE): 1000 mW`,
+			expected: 2500,
+		},
+		{
+			name:     "no frequency",
+			input:    sampleIntelDGPUPowermetrics,
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePowermetricsFrequency(tt.input); math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("parsePowermetricsFrequency() = %f, want %f", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDarwinMonitor_DiscreteGPU(t *testing.T) {
+	t.Run("parses powermetrics components", func(t *testing.T) {
+		m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+
+		watts, components := m.parsePowermetricsSamples(sampleIntelDGPUPowermetrics)
+
+		// Package power excludes the dGPU, so it's added on top
+		if watts < 15.519 || watts > 15.521 {
+			t.Errorf("Watts = %f, want 15.52", watts)
+		}
+		want := map[string]float64{
+			ComponentCPU:         4.1,
+			ComponentGPU:         0.35,
+			ComponentDiscreteGPU: 9.4,
+		}
+		if len(components) != len(want) {
+			t.Fatalf("components = %v, want %v", components, want)
+		}
+		for name, w := range want {
+			if diff := components[name] - w; diff < -0.001 || diff > 0.001 {
+				t.Errorf("component %s = %f, want %f", name, components[name], w)
+			}
+		}
+	})
+
+	t.Run("parses AGPM power", func(t *testing.T) {
+		watts, ok := parseAGPMGPUWatts(sampleAGPM)
+		if !ok || watts != 9.4 {
+			t.Errorf("parseAGPMGPUWatts() = %f, %v, want 9.4, true", watts, ok)
+		}
+		if _, ok := parseAGPMGPUWatts(sampleIoreg); ok {
+			t.Error("expected no dGPU in battery ioreg output")
+		}
+	})
+
+	t.Run("detects dGPU and falls back to AGPM", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"ioreg -rc AGPMController": sampleAGPM,
+			"powermetrics":             "*** Sampled system activity (100.00ms elapsed) ***\nPackage Power: 6000 mW",
+		})
+		m := newDarwinMonitorWithRunner(runner)
+		m.usePowermetrics = true
+
+		if !m.HasDiscreteGPU() {
+			t.Fatal("expected HasDiscreteGPU=true")
+		}
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts < 15.399 || reading.Watts > 15.401 {
+			t.Errorf("Watts = %f, want 15.4", reading.Watts)
+		}
+		if reading.Components[ComponentDiscreteGPU] != 9.4 {
+			t.Errorf("dGPU component = %f, want 9.4", reading.Components[ComponentDiscreteGPU])
+		}
+	})
+
+	t.Run("battery mac breaks out dGPU without adding it", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"ioreg -rc AGPMController": sampleAGPM,
+			"ioreg":                    sampleIoreg,
+			"pmset":                    samplePmset,
+		})
+		m := newDarwinMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts < 11.9 || reading.Watts > 12.1 {
+			t.Errorf("expected ~12W, got %f", reading.Watts)
+		}
+		if reading.Components[ComponentDiscreteGPU] != 9.4 {
+			t.Errorf("dGPU component = %f, want 9.4", reading.Components[ComponentDiscreteGPU])
+		}
+	})
+
+	t.Run("no dGPU", func(t *testing.T) {
+		m := newDarwinMonitorWithRunner(newFakeRunner(map[string]string{"ioreg": sampleIoreg}))
+		if m.HasDiscreteGPU() {
+			t.Error("expected HasDiscreteGPU=false")
+		}
+	})
+}
+
+// samplePowerLog is PowerLog CSV output from a single-package Intel Mac.
+const samplePowerLog = `System Time,RDTSC,Elapsed Time (sec), CPU Utilization(%),CPU Frequency_0(MHz),Processor Power_0(Watt),Cumulative Processor Energy_0(Joules),Cumulative Processor Energy_0(mWh),IA Power_0(Watt),Cumulative IA Energy_0(Joules),Cumulative IA Energy_0(mWh),Package Temperature_0(C),Package Hot_0,DRAM Power_0(Watt),Cumulative DRAM Energy_0(Joules),Cumulative DRAM Energy_0(mWh),GT Power_0(Watt),Cumulative GT Energy_0(Joules),Cumulative GT Energy_0(mWh),Package PL1_0(Watt),Package PL2_0(Watt),Package PL4_0(Watt),Platform PsysPL1_0(Watt),Platform PsysPL2_0(Watt),GT Frequency(MHz),GT Utilization(%)
+10:15:02:114,  3426245395718, 0.101, 12.000, 2600, 8.000, 0.808, 0.224, 6.000, 0.606, 0.168, 52, 0, 0.900, 0.091, 0.025, 0.500, 0.051, 0.014, 45.0, 90.0, 0.0, 0.0, 0.0, 350, 3.000
+10:15:02:215,  3426507189546, 0.101, 10.000, 2600, 10.000, 1.818, 0.505, 8.000, 1.414, 0.393, 53, 0, 0.900, 0.182, 0.051, 1.500, 0.202, 0.056, 45.0, 90.0, 0.0, 0.0, 0.0, 350, 4.000
+
+Total Elapsed Time (sec) = 1.002
+Measured RDTSC Frequency (GHz) = 2.592
+
+Cumulative Processor Energy_0 (Joules) = 9.321
+Cumulative Processor Energy_0 (mWh) = 2.589
+Average Processor Power_0 (Watt) = 9.302
+
+Cumulative IA Energy_0 (Joules) = 7.114
+Cumulative IA Energy_0 (mWh) = 1.976
+Average IA Power_0 (Watt) = 7.100
+`
+
+func TestParsePowerLogCSV(t *testing.T) {
+	t.Run("uses the average summary line", func(t *testing.T) {
+		watts, components, err := parsePowerLogCSV(samplePowerLog)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if watts != 9.302 {
+			t.Errorf("Watts = %f, want 9.302", watts)
+		}
+		if components[ComponentCPU] != 7.0 || components[ComponentGPU] != 1.0 {
+			t.Errorf("components = %v, want cpu 7 and gpu 1", components)
+		}
+	})
+
+	t.Run("averages rows without a summary", func(t *testing.T) {
+		output := samplePowerLog[:strings.Index(samplePowerLog, "\n\n")]
+		watts, _, err := parsePowerLogCSV(strings.ReplaceAll(output, "\n", "\r\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if watts != 9.0 {
+			t.Errorf("Watts = %f, want 9.0", watts)
+		}
+	})
+
+	t.Run("sums multiple packages", func(t *testing.T) {
+		output := "Elapsed Time (sec),Processor Power_0(Watt),Processor Power_1(Watt)\n0.1,20.0,15.5\n"
+		watts, components, err := parsePowerLogCSV(output)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if watts != 35.5 {
+			t.Errorf("Watts = %f, want 35.5", watts)
+		}
+		if components != nil {
+			t.Errorf("expected no components, got %v", components)
+		}
+	})
+
+	t.Run("errors without samples", func(t *testing.T) {
+		for _, output := range []string{"", "Error: Power Gadget driver not loaded", "Elapsed Time (sec),Processor Power_0(Watt)\n"} {
+			if _, _, err := parsePowerLogCSV(output); err == nil {
+				t.Errorf("expected error for %q", output)
+			}
+		}
+	})
+}
+
+func TestDarwinMonitor_PowerGadget(t *testing.T) {
+	t.Run("takes priority over other sources", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"PowerLog": samplePowerLog,
+			"pmset":    samplePmset,
+			"ioreg":    sampleIoreg,
+		})
+		m := newDarwinMonitorWithRunner(runner)
+		m.powerLogPath = "PowerLog"
+
+		if m.Name() != "macOS-power-gadget" || !m.HasPowerGadget() {
+			t.Errorf("expected power gadget monitor, got %q", m.Name())
+		}
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts != 9.302 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want 9.302", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.BatteryPercent != 75 {
+			t.Errorf("BatteryPercent = %f, want 75 from pmset", reading.BatteryPercent)
+		}
+		if reading.Source != "macOS-power-gadget" {
+			t.Errorf("Source = %q, want macOS-power-gadget", reading.Source)
+		}
+	})
+
+	t.Run("falls back when PowerLog fails", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"pmset": samplePmset,
+			"ioreg": sampleIoreg,
+		})
+		runner.errs["PowerLog"] = errors.New("driver not loaded")
+		m := newDarwinMonitorWithRunner(runner)
+		m.powerLogPath = "PowerLog"
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts < 11.9 || reading.Watts > 12.1 {
+			t.Errorf("expected ~12W from ioreg, got %f", reading.Watts)
+		}
+		if runner.Calls("PowerLog") != 1 {
+			t.Errorf("expected PowerLog to be tried once, got %d", runner.Calls("PowerLog"))
+		}
+	})
+
+	t.Run("stops running PowerLog once it fails", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"pmset": samplePmset,
+			"ioreg": sampleIoreg,
+		})
+		runner.errs["PowerLog"] = errors.New("driver not loaded")
+		m := newDarwinMonitorWithRunner(runner)
+		m.powerLogPath = "PowerLog"
+
+		for range 3 {
+			reading, err := m.Read(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reading.Source != m.Name() || reading.Source == "macOS-power-gadget" {
+				t.Errorf("expected the fallback source, got %q (monitor %q)", reading.Source, m.Name())
+			}
+		}
+		if runner.Calls("PowerLog") != 1 {
+			t.Errorf("expected PowerLog to be run once, got %d", runner.Calls("PowerLog"))
+		}
+		if m.HasPowerGadget() {
+			t.Error("expected HasPowerGadget=false after PowerLog failed")
+		}
+	})
+
+	t.Run("labels readings as package power", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"PowerLog": samplePowerLog, "pmset": samplePmset})
+		m := newDarwinMonitorWithRunner(runner)
+		m.powerLogPath = "PowerLog"
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.PowerKind != PowerKindPackage {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindPackage)
+		}
+	})
+
+	t.Run("sizes each run from the interval", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"PowerLog": samplePowerLog, "pmset": samplePmset})
+		m := newDarwinMonitorWithRunner(runner)
+		m.powerLogPath = "PowerLog"
+		m.SetInterval(10 * time.Second)
+
+		if _, err := m.Read(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if line := "PowerLog -resolution 500 -duration 5 -file /dev/stdout"; runner.Calls(line) != 1 {
+			t.Errorf("expected %q to be run", line)
+		}
+	})
+
+	t.Run("desktop does not need sudo", func(t *testing.T) {
+		m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+		m.hasBattery, m.hasRoot = false, false
+		m.powerLogPath = "PowerLog"
+		if m.NeedsSudo() {
+			t.Error("expected NeedsSudo=false with Power Gadget")
+		}
+	})
+}
+
+func TestPowerLogSampling(t *testing.T) {
+	tests := []struct {
+		interval   time.Duration
+		seconds    int
+		resolution int
+	}{
+		{0, 1, 100},
+		{250 * time.Millisecond, 1, 100},
+		{time.Second, 1, 100},
+		{3 * time.Second, 1, 100},
+		{4 * time.Second, 2, 200},
+		{10 * time.Second, 5, 500},
+	}
+	for _, tt := range tests {
+		seconds, resolution := powerLogSampling(tt.interval)
+		if seconds != tt.seconds || resolution != tt.resolution {
+			t.Errorf("powerLogSampling(%v) = %d, %d; want %d, %d", tt.interval, seconds, resolution, tt.seconds, tt.resolution)
+		}
+	}
+}
+
+func TestDarwinMonitor_SetSampleCount(t *testing.T) {
+	m := NewDarwinMonitor()
+
+	m.SetSampleCount(5)
+	if m.sampleCount != 5 {
+		t.Errorf("expected sampleCount=5, got %d", m.sampleCount)
+	}
+
+	m.SetSampleCount(0)
+	if m.sampleCount != 1 {
+		t.Errorf("expected sampleCount=1 for invalid input, got %d", m.sampleCount)
+	}
+}
+
 func TestDarwinMonitor_ParseWattsFromIoreg(t *testing.T) {
 	m := NewDarwinMonitor()
 
@@ -208,38 +778,51 @@ func TestDarwinMonitor_ParseWattsFromIoreg(t *testing.T) {
 		name     string
 		input    string
 		expected float64
+		kind     PowerKind
 	}{
 		{
 			name: "system power in",
 			input: `"PowerTelemetryData" = {"SystemPowerIn"=12345,"SystemLoad"=9999}`,
 			expected: 12.345,
+			kind:     PowerKindAdapter,
 		},
 		{
 			name: "system load",
 			input: `"PowerTelemetryData" = {"SystemPowerIn"=0,"SystemLoad"=9651}`,
 			expected: 9.651,
+			kind:     PowerKindSystem,
 		},
 		{
 			name: "system current and voltage",
 			input: `"PowerTelemetryData" = {"SystemCurrentIn"=532,"SystemVoltageIn"=19839}`,
 			expected: 10.554,
+			kind:     PowerKindAdapter,
 		},
 		{
 			name: "battery power negative",
 			input: `"PowerTelemetryData" = {"BatteryPower"=18446744073709541965}`,
 			expected: 9.651,
+			kind:     PowerKindDischarge,
 		},
 		{
 			name: "fallback amperage voltage",
 			input: `"InstantAmperage" = 2000
  "Voltage" = 11000`,
 			expected: 22.0,
+			kind:     PowerKindCharge,
+		},
+		{
+			name: "fallback amperage voltage discharging",
+			input: `"InstantAmperage" = 18446744073709549616
+ "Voltage" = 11000`,
+			expected: 22.0,
+			kind:     PowerKindDischarge,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := m.parseWattsFromIoreg(tt.input)
+			got, kind := m.parseWattsFromIoreg(tt.input)
 			diff := got - tt.expected
 			if diff < 0 {
 				diff = -diff
@@ -247,10 +830,705 @@ func TestDarwinMonitor_ParseWattsFromIoreg(t *testing.T) {
 			if diff > 0.001 {
 				t.Errorf("parseWattsFromIoreg() = %f, want %f", got, tt.expected)
 			}
+			if kind != tt.kind {
+				t.Errorf("parseWattsFromIoreg() kind = %q, want %q", kind, tt.kind)
+			}
 		})
 	}
 }
 
+func TestDarwinMonitor_PowerMetric(t *testing.T) {
+	// A MacBook topping off its battery on a 45W adapter
+	charging := strings.Join([]string{
+		`+-o AppleSmartBattery  <class AppleSmartBattery>`,
+		`    "InstantAmperage" = 2500`,
+		`    "Voltage" = 12600`,
+		`    "PowerTelemetryData" = {"SystemPowerIn"=45000,"SystemLoad"=12000,"BatteryPower"=30000}`,
+	}, "\n")
+	// An older model that only reports adapter input
+	adapterOnly := strings.Join([]string{
+		`+-o AppleSmartBattery  <class AppleSmartBattery>`,
+		`    "InstantAmperage" = 2500`,
+		`    "Voltage" = 12600`,
+		`    "PowerTelemetryData" = {"SystemPowerIn"=45000}`,
+	}, "\n")
+
+	tests := []struct {
+		name   string
+		metric string
+		input  string
+		want   float64
+		kind   PowerKind
+	}{
+		{"adapter", PowerMetricAdapter, charging, 45, PowerKindAdapter},
+		{"system", PowerMetricSystem, charging, 12, PowerKindSystem},
+		{"battery", PowerMetricBattery, charging, 30, PowerKindCharge},
+		{"system falls back to adapter input", PowerMetricSystem, adapterOnly, 45, PowerKindAdapter},
+		{"battery falls back to amperage and voltage", PowerMetricBattery, adapterOnly, 31.5, PowerKindCharge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+			if err := m.SetPowerMetric(tt.metric); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, kind := m.parseWattsFromIoreg(tt.input)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("parseWattsFromIoreg() = %f, want %f", got, tt.want)
+			}
+			if kind != tt.kind {
+				t.Errorf("parseWattsFromIoreg() kind = %q, want %q", kind, tt.kind)
+			}
+		})
+	}
+
+	t.Run("defaults to adapter", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"ioreg": charging, "pmset": samplePmset})
+		m := newDarwinMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.Watts-45) > 1e-9 {
+			t.Errorf("Watts = %f, want 45", reading.Watts)
+		}
+	})
+
+	t.Run("rejects unknown metrics", func(t *testing.T) {
+		m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+		if err := m.SetPowerMetric("wall"); err == nil {
+			t.Error("expected an error")
+		}
+		if m.powerMetric != PowerMetricAdapter {
+			t.Errorf("expected the metric to stay %q, got %q", PowerMetricAdapter, m.powerMetric)
+		}
+	})
+}
+
+func TestDarwinMonitor_PreferComponents(t *testing.T) {
+	t.Run("needs root", func(t *testing.T) {
+		m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+		m.hasBattery, m.hasRoot, m.usePowermetrics = true, false, false
+
+		if m.PreferComponents() {
+			t.Error("expected PreferComponents=false without root")
+		}
+		if m.usePowermetrics {
+			t.Error("expected to stay on ioreg without root")
+		}
+	})
+
+	t.Run("laptop switches to powermetrics and keeps battery status", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"pmset":        samplePmset,
+			"powermetrics": sampleAppleSiliconCPUPowermetrics,
+		})
+		m := newDarwinMonitorWithRunner(runner)
+		m.hasBattery, m.hasRoot, m.usePowermetrics = true, true, false
+
+		if !m.PreferComponents() {
+			t.Fatal("expected PreferComponents=true as root")
+		}
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Components[ComponentGPU] != 0.032 {
+			t.Errorf("expected GPU component from powermetrics, got %v", reading.Components)
+		}
+		if reading.BatteryPercent < 0 {
+			t.Error("expected battery percent from pmset")
+		}
+	})
+}
+
+// samplePowermetricsStream is streaming powermetrics output: a preamble
+// followed by three samples.
+const samplePowermetricsStream = `Machine model: Mac14,3
+OS version: 23A344
+
+*** Sampled system activity (Mon Oct 16 10:15:02 2023 -0700) (1001.23ms elapsed) ***
+
+**** Processor usage ****
+
+CPU Power: 2000 mW
+GPU Power: 100 mW
+Combined Power (CPU + GPU + ANE): 2100 mW
+
+*** Sampled system activity (Mon Oct 16 10:15:03 2023 -0700) (1000.87ms elapsed) ***
+
+**** Processor usage ****
+
+CPU Power: 4000 mW
+GPU Power: 200 mW
+Combined Power (CPU + GPU + ANE): 4200 mW
+
+*** Sampled system activity (Mon Oct 16 10:15:04 2023 -0700) (1000.42ms elapsed) ***
+
+**** Processor usage ****
+
+CPU Power: 6000 mW
+GPU Power: 300 mW
+Combined Power (CPU + GPU + ANE): 6300 mW
+`
+
+// newTestPowermetricsStream returns a stream consuming r in the background,
+// as it would a powermetrics process's output.
+func newTestPowermetricsStream(r io.Reader) *powermetricsStream {
+	s := &powermetricsStream{done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		s.consume(r)
+	}()
+	return s
+}
+
+// waitForStreamWatts polls s until its latest sample parses to watts or the
+// deadline passes.
+func waitForStreamWatts(t *testing.T, m *DarwinMonitor, s *powermetricsStream, watts float64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		sample, _ := s.Latest()
+		if math.Abs(m.parsePowermetrics(sample)-watts) < 0.001 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for a %.1fW sample", watts)
+}
+
+func TestPowermetricsStream(t *testing.T) {
+	t.Run("keeps the last sample", func(t *testing.T) {
+		s := newTestPowermetricsStream(strings.NewReader(samplePowermetricsStream))
+		<-s.done
+
+		sample, running := s.Latest()
+		if running {
+			t.Error("expected the stream to have ended")
+		}
+		if !strings.HasPrefix(sample, powermetricsSampleHeader) {
+			t.Errorf("expected sample to start with its header, got %q", sample)
+		}
+		if !strings.Contains(sample, "6300 mW") || strings.Contains(sample, "4200 mW") {
+			t.Errorf("expected only the last sample, got %q", sample)
+		}
+	})
+
+	t.Run("keeps as many samples as it takes per interval", func(t *testing.T) {
+		s := &powermetricsStream{done: make(chan struct{}), keep: 2}
+		s.consume(strings.NewReader(samplePowermetricsStream))
+
+		sample, _ := s.Latest()
+		if strings.Contains(sample, "2100 mW") || !strings.Contains(sample, "4200 mW") || !strings.Contains(sample, "6300 mW") {
+			t.Errorf("expected the last two samples, got %q", sample)
+		}
+		m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+		if watts, _ := m.parsePowermetricsSamples(sample); math.Abs(watts-5.25) > 0.001 {
+			t.Errorf("averaged watts = %f, want 5.25", watts)
+		}
+	})
+
+	t.Run("updates as samples complete", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		s := newTestPowermetricsStream(pr)
+		m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+
+		blocks := strings.SplitAfter(samplePowermetricsStream, "elapsed) ***\n")
+		// The preamble and first header alone don't make a sample yet
+		io.WriteString(pw, blocks[0])
+		if sample, running := s.Latest(); sample != "" || !running {
+			t.Fatalf("Latest() = %q, %v, want no sample while running", sample, running)
+		}
+
+		// Each sample completes when the next one's header arrives
+		for i, want := range []float64{2.1, 4.2} {
+			io.WriteString(pw, blocks[i+1])
+			waitForStreamWatts(t, m, s, want)
+		}
+
+		// The final sample completes when the output ends
+		io.WriteString(pw, blocks[3])
+		pw.Close()
+		<-s.done
+		sample, _ := s.Latest()
+		if got := m.parsePowermetrics(sample); math.Abs(got-6.3) > 0.001 {
+			t.Errorf("after the stream ended, watts = %f, want 6.3", got)
+		}
+	})
+}
+
+func TestDarwinMonitor_Streaming(t *testing.T) {
+	t.Run("needs powermetrics", func(t *testing.T) {
+		m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+		m.usePowermetrics = false
+
+		if err := m.StartStreaming(time.Second); err == nil {
+			t.Error("expected an error without powermetrics")
+		}
+		if err := m.Close(); err != nil {
+			t.Errorf("Close() without a stream = %v", err)
+		}
+	})
+
+	t.Run("reads the latest streamed sample", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+		m.usePowermetrics = true
+		m.stream = newTestPowermetricsStream(pr)
+
+		// Nothing has been sampled yet
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.WattsAvailable {
+			t.Errorf("expected no watts before the first sample, got %f", reading.Watts)
+		}
+
+		blocks := strings.SplitAfter(samplePowermetricsStream, "elapsed) ***\n")
+		io.WriteString(pw, blocks[0]+blocks[1]+blocks[2])
+		waitForStreamWatts(t, m, m.stream, 4.2)
+		reading, err = m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.Watts-4.2) > 0.001 {
+			t.Errorf("Watts = %f, want 4.2", reading.Watts)
+		}
+		if reading.Components[ComponentGPU] != 0.2 {
+			t.Errorf("GPU component = %f, want 0.2", reading.Components[ComponentGPU])
+		}
+
+		pw.Close()
+		if err := m.Close(); err != nil {
+			t.Errorf("Close() = %v", err)
+		}
+	})
+
+	t.Run("restarts for a new interval or sample count", func(t *testing.T) {
+		type start struct {
+			interval time.Duration
+			samples  int
+		}
+		var starts []start
+		m := newDarwinMonitorWithRunner(newFakeRunner(nil))
+		m.usePowermetrics = true
+		m.startStream = func(interval time.Duration, samples int, _ string) (*powermetricsStream, error) {
+			starts = append(starts, start{interval, samples})
+			return newTestPowermetricsStream(strings.NewReader("")), nil
+		}
+
+		for _, interval := range []time.Duration{time.Second, time.Second, 2 * time.Second} {
+			if err := m.StartStreaming(interval); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		m.SetSampleCount(4)
+		m.SetSampleCount(4)
+
+		want := []start{{time.Second, 1}, {2 * time.Second, 1}, {2 * time.Second, 4}}
+		if !reflect.DeepEqual(starts, want) {
+			t.Errorf("started streams %v, want %v", starts, want)
+		}
+		if err := m.Close(); err != nil {
+			t.Errorf("Close() = %v", err)
+		}
+	})
+
+	t.Run("falls back to running powermetrics once the stream ends", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"powermetrics": "*** Sampled system activity (100.00ms elapsed) ***\nPackage Power: 9000 mW",
+		})
+		m := newDarwinMonitorWithRunner(runner)
+		m.usePowermetrics = true
+		m.stream = newTestPowermetricsStream(strings.NewReader(samplePowermetricsStream))
+		<-m.stream.done
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.Watts-9) > 0.001 {
+			t.Errorf("Watts = %f, want 9 from a fresh powermetrics run", reading.Watts)
+		}
+	})
+}
+
+func TestDarwinMonitor_ParseCapacityFromIoreg(t *testing.T) {
+	m := NewDarwinMonitor()
+
+	tests := []struct {
+		name       string
+		input      string
+		wantNow    float64
+		wantFull   float64
+		wantDesign float64
+		wantUnit   string
+		wantHealth float64
+	}{
+		{
+			name: "apple silicon raw capacities",
+			input: `"AppleRawCurrentCapacity" = 3900
+ "AppleRawMaxCapacity" = 4820
+ "MaxCapacity" = 100
+ "CurrentCapacity" = 81
+ "DesignCapacity" = 5103`,
+			wantNow:    3900,
+			wantFull:   4820,
+			wantDesign: 5103,
+			wantUnit:   "mAh",
+			wantHealth: 4820.0 / 5103.0 * 100,
+		},
+		{
+			name: "intel capacities",
+			input: `"MaxCapacity" = 6200
+ "CurrentCapacity" = 5000
+ "DesignCapacity" = 6669`,
+			wantNow:    5000,
+			wantFull:   6200,
+			wantDesign: 6669,
+			wantUnit:   "mAh",
+			wantHealth: 6200.0 / 6669.0 * 100,
+		},
+		{
+			name:  "no capacity data",
+			input: `"Voltage" = 11000`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reading := Reading{}
+			m.parseCapacityFromIoreg(tt.input, &reading)
+
+			if reading.CapacityNow != tt.wantNow {
+				t.Errorf("CapacityNow = %f, want %f", reading.CapacityNow, tt.wantNow)
+			}
+			if reading.CapacityFull != tt.wantFull {
+				t.Errorf("CapacityFull = %f, want %f", reading.CapacityFull, tt.wantFull)
+			}
+			if reading.CapacityDesign != tt.wantDesign {
+				t.Errorf("CapacityDesign = %f, want %f", reading.CapacityDesign, tt.wantDesign)
+			}
+			if reading.CapacityUnit != tt.wantUnit {
+				t.Errorf("CapacityUnit = %q, want %q", reading.CapacityUnit, tt.wantUnit)
+			}
+			if math.Abs(reading.BatteryHealthPercent-tt.wantHealth) > 1e-9 {
+				t.Errorf("BatteryHealthPercent = %f, want %f", reading.BatteryHealthPercent, tt.wantHealth)
+			}
+		})
+	}
+}
+
+func TestParseTemperatureFromIoreg(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{"centi-celsius", `"Temperature" = 3055`, 30.55},
+		{"ignores virtual temperature", `"VirtualTemperature" = 2900`, 0},
+		{"missing", `"Voltage" = 12000`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTemperatureFromIoreg(tt.input); got != tt.expected {
+				t.Errorf("parseTemperatureFromIoreg() = %f, want %f", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCycleCountFromIoreg(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{"cycle count", "\"DesignCycleCount9C\" = 1000\n\"CycleCount\" = 287", 287},
+		{"ignores design cycle count", `"DesignCycleCount9C" = 1000`, 0},
+		{"missing", `"Voltage" = 12000`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCycleCountFromIoreg(tt.input); got != tt.expected {
+				t.Errorf("parseCycleCountFromIoreg() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+// sampleAdapterIoreg is trimmed ioreg -rn AppleSmartBattery output from a
+// MacBook charging from a 67W USB-C adapter.
+const sampleAdapterIoreg = `+-o AppleSmartBattery  <class AppleSmartBattery, id 0x100000a2b, registered, matched, active, busy 0 (0 ms), retain 7>
+    {
+      "PowerTelemetryData" = {"SystemPowerIn"=31245,"SystemLoad"=18032,"AdapterEfficiencyLoss"=1402}
+      "AppleRawAdapterDetails" = ({"AdapterID"=0,"Watts"=67,"FamilyCode"=18446744073172697098,"Voltage"=20000,"UsbHvcHvcIndex"=3,"Description"="pd charger","IsWireless"=No,"UsbHvcMenu"=({"Index"=0,"MaxCurrent"=3000,"MaxVoltage"=5000},{"Index"=3,"MaxCurrent"=3250,"MaxVoltage"=20000}),"Current"=3250,"AdapterVoltage"=20000,"PMUConfiguration"=0})
+      "AdapterDetails" = {"AdapterID"=0,"Watts"=67,"FamilyCode"=18446744073172697098,"Voltage"=20000,"UsbHvcHvcIndex"=3,"Description"="pd charger","IsWireless"=No,"UsbHvcMenu"=({"Index"=0,"MaxCurrent"=3000,"MaxVoltage"=5000},{"Index"=3,"MaxCurrent"=3250,"MaxVoltage"=20000}),"Current"=3250,"AdapterVoltage"=20000,"PMUConfiguration"=0}
+      "CycleCount" = 287
+      "Voltage" = 12600
+    }`
+
+func TestParseAdapterWattsFromIoreg(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{"adapter details", sampleAdapterIoreg, 67},
+		{"raw adapter details only", `"AppleRawAdapterDetails" = ({"AdapterID"=0,"Watts"=96,"Current"=4700})`, 96},
+		{"prefers adapter details", "\"AppleRawAdapterDetails\" = ({\"Watts\"=30})\n\"AdapterDetails\" = {\"Watts\"=140}", 140},
+		{"ignores nested watts", `"AdapterDetails" = {"Menu"=({"Watts"=15}),"Current"=3000}`, 0},
+		{"ignores braces in strings", `"AdapterDetails" = {"Description"="pd {charger}","Watts"=45}`, 45},
+		{"unplugged", `"AdapterDetails" = {"FamilyCode"=0}` + "\n" + `"AppleRawAdapterDetails" = ()`, 0},
+		{"ignores other watts keys", "\"AdapterDetails\" = {\"FamilyCode\"=0}\n\"BatteryData\" = {\"Watts\"=12}", 0},
+		{"missing", `"Voltage" = 12000`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAdapterWattsFromIoreg(tt.input); got != tt.expected {
+				t.Errorf("parseAdapterWattsFromIoreg() = %g, want %g", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDarwinMonitor_AdapterMaxWatts(t *testing.T) {
+	t.Run("reports the adapter rating on AC", func(t *testing.T) {
+		m := newDarwinMonitorWithRunner(newFakeRunner(map[string]string{
+			"ioreg": sampleAdapterIoreg,
+			"pmset": "Now drawing from 'AC Power'\n -InternalBattery-0 (id=1234567)\t80%; charging; 0:45 remaining present: true",
+		}))
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.AdapterMaxWatts != 67 {
+			t.Errorf("AdapterMaxWatts = %g, want 67", reading.AdapterMaxWatts)
+		}
+	})
+
+	t.Run("ignores stale adapter details on battery", func(t *testing.T) {
+		m := newDarwinMonitorWithRunner(newFakeRunner(map[string]string{
+			"ioreg": sampleAdapterIoreg,
+			"pmset": samplePmset,
+		}))
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.AdapterMaxWatts != 0 {
+			t.Errorf("AdapterMaxWatts = %g, want 0 on battery", reading.AdapterMaxWatts)
+		}
+	})
+}
+
+func TestDarwinMonitor_IoregCache(t *testing.T) {
+	t.Run("reuses ioreg output within TTL", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"ioreg": sampleIoreg, "pmset": samplePmset})
+		m := newDarwinMonitorWithRunner(runner)
+		m.SetIoregCacheTTL(time.Hour)
+
+		for i := 0; i < 3; i++ {
+			reading, err := m.Read(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reading.Watts < 11.9 || reading.Watts > 12.1 {
+				t.Errorf("expected ~12W, got %f", reading.Watts)
+			}
+		}
+
+		if calls := runner.Calls("ioreg -rn AppleSmartBattery"); calls != 1 {
+			t.Errorf("expected ioreg to run once, ran %d times", calls)
+		}
+		if calls := runner.Calls("pmset"); calls != 3 {
+			t.Errorf("expected pmset to run 3 times, ran %d times", calls)
+		}
+	})
+
+	t.Run("reruns ioreg when caching disabled", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"ioreg": sampleIoreg, "pmset": samplePmset})
+		m := newDarwinMonitorWithRunner(runner)
+		m.SetIoregCacheTTL(0)
+
+		for i := 0; i < 3; i++ {
+			_, _ = m.Read(context.Background())
+		}
+
+		if calls := runner.Calls("ioreg -rn AppleSmartBattery"); calls != 4 {
+			t.Errorf("expected ioreg to run 4 times, ran %d times", calls)
+		}
+	})
+
+	t.Run("reruns ioreg after TTL expires", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"ioreg": sampleIoreg, "pmset": samplePmset})
+		m := newDarwinMonitorWithRunner(runner)
+		m.SetIoregCacheTTL(10 * time.Millisecond)
+
+		time.Sleep(20 * time.Millisecond)
+		_, _ = m.Read(context.Background())
+
+		if calls := runner.Calls("ioreg -rn AppleSmartBattery"); calls != 2 {
+			t.Errorf("expected ioreg to run twice, ran %d times", calls)
+		}
+	})
+}
+
+func TestDarwinMonitor_ReadWithRunner(t *testing.T) {
+	t.Run("battery mac", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"ioreg": sampleIoreg, "pmset": samplePmset})
+		m := newDarwinMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !m.HasBattery() {
+			t.Error("expected HasBattery=true")
+		}
+		if reading.BatteryPercent != 75.0 {
+			t.Errorf("BatteryPercent = %f, want 75.0", reading.BatteryPercent)
+		}
+		if !reading.IsOnBattery {
+			t.Error("expected IsOnBattery=true")
+		}
+		if reading.CapacityDesign != 5103 {
+			t.Errorf("CapacityDesign = %f, want 5103", reading.CapacityDesign)
+		}
+		if !reading.WattsAvailable {
+			t.Error("expected watts to be available")
+		}
+		if reading.PowerKind != PowerKindDischarge {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindDischarge)
+		}
+	})
+
+	t.Run("desktop mac with powermetrics", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"powermetrics": `*** Sampled system activity (100.00ms elapsed) ***
+Combined Power (CPU + GPU + ANE): 5432 mW`,
+		})
+		m := newDarwinMonitorWithRunner(runner)
+		m.usePowermetrics = true
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts < 5.431 || reading.Watts > 5.433 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want 5.432", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.PowerKind != PowerKindSystem {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindSystem)
+		}
+		if reading.CPUFreqMHz != 0 {
+			t.Errorf("CPUFreqMHz = %f, want 0 without frequency lines", reading.CPUFreqMHz)
+		}
+		if runner.Calls("pmset") != 0 {
+			t.Error("expected pmset not to run in powermetrics mode")
+		}
+	})
+
+	t.Run("powermetrics reports cpu frequency", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"powermetrics": sampleAppleSiliconCPUPowermetrics})
+		m := newDarwinMonitorWithRunner(runner)
+		m.usePowermetrics = true
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.CPUFreqMHz != 2516 {
+			t.Errorf("CPUFreqMHz = %f, want 2516", reading.CPUFreqMHz)
+		}
+	})
+
+	t.Run("desktop mac without sudo needs privilege", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"pmset": "Now drawing from 'AC Power'",
+			"ioreg": "",
+		})
+		m := newDarwinMonitorWithRunner(runner)
+		m.hasRoot, m.usePowermetrics = false, false
+
+		reading, err := m.Read(context.Background())
+		if !errors.Is(err, ErrNeedsPrivilege) {
+			t.Fatalf("expected ErrNeedsPrivilege, got %v", err)
+		}
+		if m.HasBattery() || reading.WattsAvailable || reading.PowerKind != "" {
+			t.Errorf("expected a desktop without watts, got %+v", reading)
+		}
+		// The AC state from pmset is still returned
+		if reading.IsOnBattery || reading.Timestamp.IsZero() || reading.Source != m.Name() {
+			t.Errorf("expected partial data from pmset, got %+v", reading)
+		}
+	})
+
+	t.Run("desktop mac as root without powermetrics has no watts", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"pmset": "Now drawing from 'AC Power'",
+			"ioreg": "",
+		})
+		m := newDarwinMonitorWithRunner(runner)
+		m.hasRoot, m.usePowermetrics = true, false
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.WattsAvailable {
+			t.Errorf("expected no watts, got %+v", reading)
+		}
+	})
+
+	t.Run("missing pmset is unsupported", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"ioreg": sampleIoreg})
+		runner.errs["pmset"] = &exec.Error{Name: "pmset", Err: exec.ErrNotFound}
+		m := newDarwinMonitorWithRunner(runner)
+
+		if _, err := m.Read(context.Background()); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("expected ErrUnsupported, got %v", err)
+		}
+	})
+
+	t.Run("pmset failure", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"ioreg": sampleIoreg})
+		runner.errs["pmset"] = errors.New("pmset failed")
+		m := newDarwinMonitorWithRunner(runner)
+
+		if _, err := m.Read(context.Background()); err == nil {
+			t.Error("expected error when pmset fails")
+		}
+	})
+}
+
+func TestDarwinMonitor_ReadTimestamp(t *testing.T) {
+	runner := newFakeRunner(map[string]string{"ioreg": sampleIoreg, "pmset": samplePmset})
+	m := newDarwinMonitorWithRunner(runner)
+	m.SetIoregCacheTTL(0)
+	runner.delay = 50 * time.Millisecond
+
+	start := time.Now()
+	reading, err := m.Read(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// pmset and ioreg each take 50ms, so the reading completes >= 100ms later
+	if elapsed := reading.Timestamp.Sub(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected timestamp to reflect completion time, got %v after start", elapsed)
+	}
+}
+
 func TestDarwinMonitor_HasBattery(t *testing.T) {
 	m := NewDarwinMonitor()
 	// Just verify the method exists and returns a bool
@@ -0,0 +1,138 @@
+//go:build freebsd
+
+package power
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ACPI battery state bits reported by hw.acpi.battery.state.
+const (
+	acpiBatteryDischarging = 1 << 0
+	acpiBatteryCharging    = 1 << 1
+	acpiBatteryNotPresent  = 7
+)
+
+// FreeBSDMonitor reads power information on FreeBSD from ACPI sysctls.
+type FreeBSDMonitor struct {
+	runner commandRunner
+}
+
+// NewFreeBSDMonitor creates a new FreeBSD power monitor.
+func NewFreeBSDMonitor() *FreeBSDMonitor {
+	return newFreeBSDMonitorWithRunner(execRunner{})
+}
+
+// newFreeBSDMonitorWithRunner creates a FreeBSD power monitor that runs
+// sysctl through runner.
+func newFreeBSDMonitorWithRunner(runner commandRunner) *FreeBSDMonitor {
+	return &FreeBSDMonitor{runner: runner}
+}
+
+// Name returns the name of this monitor.
+func (m *FreeBSDMonitor) Name() string {
+	return "freebsd-acpi"
+}
+
+// IsSupported checks if power monitoring is available on this system.
+func (m *FreeBSDMonitor) IsSupported() bool {
+	_, err := m.runner.Run(context.Background(), "sysctl", "-n", "hw.acpi.battery.life")
+	return err == nil
+}
+
+// Close does nothing, since the monitor holds nothing open between reads.
+func (m *FreeBSDMonitor) Close() error {
+	return nil
+}
+
+// Read returns the current power consumption reading.
+func (m *FreeBSDMonitor) Read(ctx context.Context) (Reading, error) {
+	reading := Reading{
+		BatteryPercent: -1,
+		Source:         m.Name(),
+	}
+
+	output, err := m.runSysctl(ctx)
+	if err != nil {
+		reading.Timestamp = time.Now()
+		return reading, err
+	}
+	m.parseSysctl(output, &reading)
+
+	// Stamp the reading when sampling finished, not when it started
+	reading.Timestamp = time.Now()
+
+	return reading, nil
+}
+
+// runSysctl executes sysctl for the ACPI battery and AC line nodes.
+func (m *FreeBSDMonitor) runSysctl(ctx context.Context) (string, error) {
+	out, err := m.runner.Run(ctx, "sysctl", "hw.acpi.battery", "hw.acpi.acline")
+	// sysctl exits non-zero if any node is missing but still prints the rest
+	if err != nil && len(out) == 0 {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// parseSysctl parses sysctl output to extract battery and power information.
+//
+// Example:
+//
+//	hw.acpi.battery.life: 87
+//	hw.acpi.battery.state: 1
+//	hw.acpi.battery.rate: 9876
+//	hw.acpi.acline: 0
+func (m *FreeBSDMonitor) parseSysctl(output string, reading *Reading) {
+	hasACLine := false
+	var state int64
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "hw.acpi.battery.life":
+			// -1 means no battery is present
+			if pct, err := strconv.ParseFloat(value, 64); err == nil && pct >= 0 {
+				reading.BatteryPercent = pct
+			}
+		case "hw.acpi.battery.state":
+			if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+				state = v
+			}
+		case "hw.acpi.battery.rate":
+			// Present rate in mW, or -1 if unknown
+			if mw, err := strconv.ParseFloat(value, 64); err == nil && mw > 0 {
+				reading.Watts = mw / 1000.0
+				reading.WattsAvailable = true
+			}
+		case "hw.acpi.acline":
+			hasACLine = true
+			reading.IsOnBattery = value == "0"
+		}
+	}
+
+	if state == acpiBatteryNotPresent {
+		state = 0
+	}
+	reading.IsCharging = state&acpiBatteryCharging != 0
+	if !hasACLine {
+		reading.IsOnBattery = state&acpiBatteryDischarging != 0
+	}
+	if reading.WattsAvailable {
+		reading.PowerKind = batteryPowerKind(*reading)
+	}
+}
+
+// NewMonitor creates the appropriate monitor for this platform.
+func NewMonitor() Monitor {
+	return NewFreeBSDMonitor()
+}
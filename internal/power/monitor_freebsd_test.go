@@ -0,0 +1,191 @@
+//go:build freebsd
+
+package power
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFreeBSDMonitor_Name(t *testing.T) {
+	m := NewFreeBSDMonitor()
+	if m.Name() != "freebsd-acpi" {
+		t.Errorf("expected name 'freebsd-acpi', got '%s'", m.Name())
+	}
+}
+
+func TestFreeBSDMonitor_ParseSysctl(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantBattery  bool
+		wantPercent  float64
+		wantCharging bool
+		wantWatts    float64
+	}{
+		{
+			name: "discharging on battery",
+			input: `hw.acpi.battery.life: 87
+hw.acpi.battery.time: 215
+hw.acpi.battery.state: 1
+hw.acpi.battery.rate: 9876
+hw.acpi.battery.units: 1
+hw.acpi.acline: 0`,
+			wantBattery:  true,
+			wantPercent:  87.0,
+			wantCharging: false,
+			wantWatts:    9.876,
+		},
+		{
+			name: "charging on AC",
+			input: `hw.acpi.battery.life: 42
+hw.acpi.battery.time: -1
+hw.acpi.battery.state: 2
+hw.acpi.battery.rate: 25000
+hw.acpi.acline: 1`,
+			wantBattery:  false,
+			wantPercent:  42.0,
+			wantCharging: true,
+			wantWatts:    25.0,
+		},
+		{
+			name: "fully charged with unknown rate",
+			input: `hw.acpi.battery.life: 100
+hw.acpi.battery.state: 0
+hw.acpi.battery.rate: -1
+hw.acpi.acline: 1`,
+			wantBattery:  false,
+			wantPercent:  100.0,
+			wantCharging: false,
+			wantWatts:    0,
+		},
+		{
+			name: "no acline falls back to state",
+			input: `hw.acpi.battery.life: 30
+hw.acpi.battery.state: 1
+hw.acpi.battery.rate: 7000`,
+			wantBattery:  true,
+			wantPercent:  30.0,
+			wantCharging: false,
+			wantWatts:    7.0,
+		},
+		{
+			name: "no battery present",
+			input: `hw.acpi.battery.life: -1
+hw.acpi.battery.state: 7
+hw.acpi.acline: 1`,
+			wantBattery:  false,
+			wantPercent:  -1,
+			wantCharging: false,
+			wantWatts:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewFreeBSDMonitor()
+			reading := Reading{BatteryPercent: -1}
+			m.parseSysctl(tt.input, &reading)
+
+			if reading.IsOnBattery != tt.wantBattery {
+				t.Errorf("IsOnBattery = %v, want %v", reading.IsOnBattery, tt.wantBattery)
+			}
+			if reading.BatteryPercent != tt.wantPercent {
+				t.Errorf("BatteryPercent = %f, want %f", reading.BatteryPercent, tt.wantPercent)
+			}
+			if reading.IsCharging != tt.wantCharging {
+				t.Errorf("IsCharging = %v, want %v", reading.IsCharging, tt.wantCharging)
+			}
+			diff := reading.Watts - tt.wantWatts
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff > 0.001 {
+				t.Errorf("Watts = %f, want %f", reading.Watts, tt.wantWatts)
+			}
+		})
+	}
+}
+
+func TestFreeBSDMonitor_Read(t *testing.T) {
+	t.Run("reads sysctl output", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"sysctl": `hw.acpi.battery.life: 64
+hw.acpi.battery.state: 1
+hw.acpi.battery.rate: 8500
+hw.acpi.acline: 0`})
+		m := newFreeBSDMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts != 8.5 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want 8.5", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.BatteryPercent != 64 {
+			t.Errorf("BatteryPercent = %f, want 64", reading.BatteryPercent)
+		}
+		if reading.PowerKind != PowerKindDischarge {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindDischarge)
+		}
+		if reading.Timestamp.IsZero() {
+			t.Error("expected non-zero timestamp")
+		}
+	})
+
+	t.Run("rate while charging is the charge rate", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"sysctl": `hw.acpi.battery.life: 64
+hw.acpi.battery.state: 2
+hw.acpi.battery.rate: 20000
+hw.acpi.acline: 1`})
+		m := newFreeBSDMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.PowerKind != PowerKindCharge {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindCharge)
+		}
+	})
+
+	t.Run("unknown rate leaves watts unavailable", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"sysctl": `hw.acpi.battery.life: 100
+hw.acpi.battery.state: 0
+hw.acpi.battery.rate: -1
+hw.acpi.acline: 1`})
+		m := newFreeBSDMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.WattsAvailable {
+			t.Error("expected watts to be unavailable")
+		}
+	})
+
+	t.Run("returns error when sysctl fails without output", func(t *testing.T) {
+		runner := newFakeRunner(nil)
+		runner.errs["sysctl"] = errors.New("no such node")
+		m := newFreeBSDMonitorWithRunner(runner)
+
+		if _, err := m.Read(context.Background()); err == nil {
+			t.Error("expected error")
+		}
+		if m.IsSupported() {
+			t.Error("expected IsSupported=false")
+		}
+	})
+}
+
+func TestNewMonitor_FreeBSD(t *testing.T) {
+	m := NewMonitor()
+	if m == nil {
+		t.Fatal("NewMonitor returned nil")
+	}
+	if _, ok := m.(*FreeBSDMonitor); !ok {
+		t.Errorf("expected *FreeBSDMonitor, got %T", m)
+	}
+}
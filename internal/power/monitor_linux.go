@@ -4,40 +4,67 @@ package power
 
 import (
 	"context"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-const (
-	powerSupplyPath = "/sys/class/power_supply"
-)
+const powerSupplyPath = "/sys/class/power_supply"
 
 // LinuxMonitor reads power information on Linux from sysfs.
 type LinuxMonitor struct {
-	batteryPath string
-	acPath      string
+	root         string // powerSupplyPath, or a fake sysfs tree in tests
+	batteryPaths []string
+	acPath       string
+	// acPowerPaths are the adapters that report their input power, which
+	// is the actual wall draw while plugged in
+	acPowerPaths []string
+
+	// raplPath is the RAPL package domain, or empty if its energy counter
+	// isn't readable (it needs root on most current kernels)
+	raplPath string
+	raplMu   sync.Mutex
+	raplLast raplSample
+}
+
+// raplSample is a RAPL energy counter reading in microjoules.
+type raplSample struct {
+	energy float64
+	at     time.Time
 }
 
 // NewLinuxMonitor creates a new Linux power monitor.
 func NewLinuxMonitor() *LinuxMonitor {
-	m := &LinuxMonitor{}
+	m := newLinuxMonitorWithRoot(powerSupplyPath)
+	if _, err := os.ReadFile(filepath.Join(raplPackagePath, "energy_uj")); err == nil {
+		m.raplPath = raplPackagePath
+	}
+	return m
+}
+
+// newLinuxMonitorWithRoot creates a Linux power monitor that looks for power
+// supplies under root instead of /sys/class/power_supply.
+func newLinuxMonitorWithRoot(root string) *LinuxMonitor {
+	m := &LinuxMonitor{root: root}
 	m.detectPowerSupplies()
 	return m
 }
 
-// detectPowerSupplies finds available power supply paths.
+// detectPowerSupplies finds available power supply paths. Every battery is
+// kept, so machines with several (e.g. BAT0 and BAT1) report their total.
 func (m *LinuxMonitor) detectPowerSupplies() {
-	entries, err := os.ReadDir(powerSupplyPath)
+	entries, err := os.ReadDir(m.root)
 	if err != nil {
 		return
 	}
 
 	for _, entry := range entries {
 		name := entry.Name()
-		typePath := filepath.Join(powerSupplyPath, name, "type")
+		typePath := filepath.Join(m.root, name, "type")
 		typeBytes, err := os.ReadFile(typePath)
 		if err != nil {
 			continue
@@ -46,12 +73,14 @@ func (m *LinuxMonitor) detectPowerSupplies() {
 		supplyType := strings.TrimSpace(string(typeBytes))
 		switch supplyType {
 		case "Battery":
-			if m.batteryPath == "" {
-				m.batteryPath = filepath.Join(powerSupplyPath, name)
-			}
+			m.batteryPaths = append(m.batteryPaths, filepath.Join(m.root, name))
 		case "Mains", "USB", "USB_PD":
+			path := filepath.Join(m.root, name)
 			if m.acPath == "" {
-				m.acPath = filepath.Join(powerSupplyPath, name)
+				m.acPath = path
+			}
+			if m.hasPowerAttributes(path) {
+				m.acPowerPaths = append(m.acPowerPaths, path)
 			}
 		}
 	}
@@ -64,14 +93,18 @@ func (m *LinuxMonitor) Name() string {
 
 // IsSupported checks if power monitoring is available on this system.
 func (m *LinuxMonitor) IsSupported() bool {
-	_, err := os.Stat(powerSupplyPath)
-	return err == nil && (m.batteryPath != "" || m.acPath != "")
+	_, err := os.Stat(m.root)
+	return (err == nil && (len(m.batteryPaths) > 0 || m.acPath != "")) || m.raplPath != ""
+}
+
+// Close does nothing, since the monitor holds nothing open between reads.
+func (m *LinuxMonitor) Close() error {
+	return nil
 }
 
 // Read returns the current power consumption reading.
 func (m *LinuxMonitor) Read(ctx context.Context) (Reading, error) {
 	reading := Reading{
-		Timestamp:      time.Now(),
 		BatteryPercent: -1,
 		Source:         m.Name(),
 	}
@@ -83,27 +116,144 @@ func (m *LinuxMonitor) Read(ctx context.Context) (Reading, error) {
 	}
 
 	// Read battery information
-	if m.batteryPath != "" {
-		// Get battery percentage
-		capacity := m.readFile(filepath.Join(m.batteryPath, "capacity"))
-		if pct, err := strconv.ParseFloat(capacity, 64); err == nil {
-			reading.BatteryPercent = pct
-		} else {
-			// Calculate from energy_now/energy_full or charge_now/charge_full
-			reading.BatteryPercent = m.calculateBatteryPercent()
-		}
+	batteries := make([]Reading, len(m.batteryPaths))
+	for i, path := range m.batteryPaths {
+		batteries[i] = m.readBattery(path)
+	}
+	combineBatteries(batteries, &reading)
+	if reading.WattsAvailable {
+		reading.PowerKind = batteryPowerKind(reading)
+	}
 
-		// Check charging status
-		status := strings.ToLower(m.readFile(filepath.Join(m.batteryPath, "status")))
-		reading.IsCharging = status == "charging"
+	// While plugged in, battery power is only the charge rate; prefer what
+	// the adapters draw from the wall
+	if !reading.IsOnBattery {
+		if watts, ok := m.adapterWatts(); ok {
+			reading.Watts = watts
+			reading.WattsAvailable = true
+			reading.PowerKind = PowerKindAdapter
+		}
+	}
 
-		// Calculate watts
-		reading.Watts = m.calculateWatts()
+	// Sample RAPL on every read so consecutive reads can be compared, but
+	// only fall back to it without battery or adapter power, since it
+	// covers the CPU package rather than the whole system
+	if watts, ok := m.raplWatts(); ok && !reading.WattsAvailable {
+		reading.Watts = watts
+		reading.WattsAvailable = true
+		reading.PowerKind = PowerKindSystem
+		reading.Components = map[string]float64{ComponentCPU: watts}
 	}
 
+	// Stamp the reading when sampling finished, not when it started
+	reading.Timestamp = time.Now()
+
 	return reading, nil
 }
 
+// readBattery reads a single battery's sysfs directory into a Reading.
+func (m *LinuxMonitor) readBattery(path string) Reading {
+	var reading Reading
+
+	// Get battery percentage
+	capacity := m.readFile(filepath.Join(path, "capacity"))
+	if pct, err := strconv.ParseFloat(capacity, 64); err == nil {
+		reading.BatteryPercent = pct
+	} else {
+		// Calculate from energy_now/energy_full or charge_now/charge_full
+		reading.BatteryPercent = m.calculateBatteryPercent(path)
+	}
+
+	// Check charging status
+	status := strings.ToLower(m.readFile(filepath.Join(path, "status")))
+	reading.IsCharging = status == "charging"
+
+	// Get absolute capacity
+	m.readCapacity(path, &reading)
+
+	// Get temperature (reported in tenths of a degree Celsius)
+	reading.Temperature = m.readFloat(filepath.Join(path, "temp")) / 10.0
+
+	// Get charge cycles, which some batteries report as 0 when unknown
+	reading.CycleCount = int(m.readFloat(filepath.Join(path, "cycle_count")))
+
+	// Calculate watts
+	reading.Watts, reading.WattsAvailable = m.calculateWatts(path)
+
+	if status == "discharging" {
+		reading.TimeRemaining = m.calculateTimeRemaining(path)
+	}
+
+	return reading
+}
+
+// combineBatteries merges per-battery readings into reading. Watts and
+// capacities are summed, the most worn battery's cycle count is kept, and
+// the overall percentage is weighted by each
+// battery's full capacity so a small battery counts for less than a large one.
+// If capacities are missing or in different units, the percentages are
+// averaged instead.
+func combineBatteries(batteries []Reading, reading *Reading) {
+	if len(batteries) == 0 {
+		return
+	}
+
+	unit := batteries[0].CapacityUnit
+	weighted := unit != ""
+	var pctSum, weightedSum, weightSum float64
+	var pctCount int
+	for _, b := range batteries {
+		reading.Watts += b.Watts
+		reading.WattsAvailable = reading.WattsAvailable || b.WattsAvailable
+		reading.IsCharging = reading.IsCharging || b.IsCharging
+		reading.Temperature = max(reading.Temperature, b.Temperature)
+		reading.CycleCount = max(reading.CycleCount, b.CycleCount)
+
+		if b.CapacityUnit != unit {
+			weighted = false
+		}
+		reading.CapacityNow += b.CapacityNow
+		reading.CapacityFull += b.CapacityFull
+		reading.CapacityDesign += b.CapacityDesign
+
+		if b.BatteryPercent < 0 || b.CapacityFull <= 0 {
+			weighted = false
+		}
+		if b.BatteryPercent >= 0 {
+			pctSum += b.BatteryPercent
+			pctCount++
+			weightedSum += b.BatteryPercent * b.CapacityFull
+			weightSum += b.CapacityFull
+		}
+	}
+
+	switch {
+	case weighted && weightSum > 0:
+		reading.BatteryPercent = weightedSum / weightSum
+	case pctCount > 0:
+		reading.BatteryPercent = pctSum / float64(pctCount)
+	}
+
+	for _, b := range batteries {
+		if b.CapacityUnit != unit {
+			// Capacities in mixed units can't be added up
+			reading.CapacityNow, reading.CapacityFull, reading.CapacityDesign = 0, 0, 0
+			return
+		}
+	}
+	reading.CapacityUnit = unit
+	reading.BatteryHealthPercent = batteryHealthPercent(*reading)
+
+	// Batteries may drain one after another, so estimate from the combined
+	// energy and draw rather than any single battery's estimate
+	switch {
+	case len(batteries) == 1:
+		reading.TimeRemaining = batteries[0].TimeRemaining
+	case unit == "Wh" && reading.Watts > 0 && reading.IsOnBattery && !reading.IsCharging:
+		reading.TimeRemaining = time.Duration(reading.CapacityNow / reading.Watts * float64(time.Hour))
+	}
+}
+
 // readFile reads and trims a sysfs file.
 func (m *LinuxMonitor) readFile(path string) string {
 	data, err := os.ReadFile(path)
@@ -114,10 +264,10 @@ func (m *LinuxMonitor) readFile(path string) string {
 }
 
 // calculateBatteryPercent calculates battery percentage from energy or charge values.
-func (m *LinuxMonitor) calculateBatteryPercent() float64 {
+func (m *LinuxMonitor) calculateBatteryPercent(path string) float64 {
 	// Try energy-based calculation first
-	energyNow := m.readFile(filepath.Join(m.batteryPath, "energy_now"))
-	energyFull := m.readFile(filepath.Join(m.batteryPath, "energy_full"))
+	energyNow := m.readFile(filepath.Join(path, "energy_now"))
+	energyFull := m.readFile(filepath.Join(path, "energy_full"))
 	if energyNow != "" && energyFull != "" {
 		now, err1 := strconv.ParseFloat(energyNow, 64)
 		full, err2 := strconv.ParseFloat(energyFull, 64)
@@ -127,8 +277,8 @@ func (m *LinuxMonitor) calculateBatteryPercent() float64 {
 	}
 
 	// Try charge-based calculation
-	chargeNow := m.readFile(filepath.Join(m.batteryPath, "charge_now"))
-	chargeFull := m.readFile(filepath.Join(m.batteryPath, "charge_full"))
+	chargeNow := m.readFile(filepath.Join(path, "charge_now"))
+	chargeFull := m.readFile(filepath.Join(path, "charge_full"))
 	if chargeNow != "" && chargeFull != "" {
 		now, err1 := strconv.ParseFloat(chargeNow, 64)
 		full, err2 := strconv.ParseFloat(chargeFull, 64)
@@ -140,19 +290,56 @@ func (m *LinuxMonitor) calculateBatteryPercent() float64 {
 	return -1
 }
 
-// calculateWatts calculates current power consumption in watts.
-func (m *LinuxMonitor) calculateWatts() float64 {
+// readCapacity fills in absolute battery capacity. Batteries report either
+// energy_* files in µWh or charge_* files in µAh, which become Wh and mAh.
+func (m *LinuxMonitor) readCapacity(path string, reading *Reading) {
+	prefixes := []struct {
+		name    string
+		divisor float64
+		unit    string
+	}{
+		{"energy", 1000000.0, "Wh"}, // µWh to Wh
+		{"charge", 1000.0, "mAh"},   // µAh to mAh
+	}
+
+	for _, p := range prefixes {
+		now := m.readFloat(filepath.Join(path, p.name+"_now"))
+		full := m.readFloat(filepath.Join(path, p.name+"_full"))
+		design := m.readFloat(filepath.Join(path, p.name+"_full_design"))
+		if now <= 0 && full <= 0 && design <= 0 {
+			continue
+		}
+		reading.CapacityNow = now / p.divisor
+		reading.CapacityFull = full / p.divisor
+		reading.CapacityDesign = design / p.divisor
+		reading.CapacityUnit = p.unit
+		return
+	}
+}
+
+// readFloat reads a sysfs file as a float, returning 0 if missing or invalid.
+func (m *LinuxMonitor) readFloat(path string) float64 {
+	v, err := strconv.ParseFloat(m.readFile(path), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// calculateWatts calculates current power consumption in watts. The second
+// result reports whether the battery exposes power at all.
+func (m *LinuxMonitor) calculateWatts(path string) (float64, bool) {
 	// Try power_now first (in microwatts)
-	powerNow := m.readFile(filepath.Join(m.batteryPath, "power_now"))
+	powerNow := m.readFile(filepath.Join(path, "power_now"))
 	if powerNow != "" {
 		if p, err := strconv.ParseFloat(powerNow, 64); err == nil {
-			return p / 1000000.0 // Convert µW to W
+			return p / 1000000.0, true // Convert µW to W
 		}
 	}
 
 	// Calculate from voltage and current
-	voltageNow := m.readFile(filepath.Join(m.batteryPath, "voltage_now"))
-	currentNow := m.readFile(filepath.Join(m.batteryPath, "current_now"))
+	voltageNow := m.readFile(filepath.Join(path, "voltage_now"))
+	currentNow := m.readFile(filepath.Join(path, "current_now"))
 	if voltageNow != "" && currentNow != "" {
 		voltage, err1 := strconv.ParseFloat(voltageNow, 64)
 		current, err2 := strconv.ParseFloat(currentNow, 64)
@@ -162,13 +349,97 @@ func (m *LinuxMonitor) calculateWatts() float64 {
 			if watts < 0 {
 				watts = -watts
 			}
-			return watts
+			return watts, true
 		}
 	}
 
+	return 0, false
+}
+
+// calculateTimeRemaining estimates how long the battery at path lasts at its
+// current draw, from energy_now/power_now or charge_now/current_now. Returns
+// 0 if either is missing.
+func (m *LinuxMonitor) calculateTimeRemaining(path string) time.Duration {
+	pairs := [][2]string{
+		{"energy_now", "power_now"},   // µWh / µW
+		{"charge_now", "current_now"}, // µAh / µA
+	}
+
+	for _, p := range pairs {
+		stored := m.readFloat(filepath.Join(path, p[0]))
+		rate := math.Abs(m.readFloat(filepath.Join(path, p[1])))
+		if stored > 0 && rate > 0 {
+			return time.Duration(stored / rate * float64(time.Hour))
+		}
+	}
 	return 0
 }
 
+// hasPowerAttributes reports whether the supply at path exposes power_now,
+// or current_now and voltage_now to compute it from.
+func (m *LinuxMonitor) hasPowerAttributes(path string) bool {
+	if _, err := os.Stat(filepath.Join(path, "power_now")); err == nil {
+		return true
+	}
+	_, errCurrent := os.Stat(filepath.Join(path, "current_now"))
+	_, errVoltage := os.Stat(filepath.Join(path, "voltage_now"))
+	return errCurrent == nil && errVoltage == nil
+}
+
+// adapterWatts returns the total input power of the online adapters that
+// report it. The second result is false if none of them reported any power,
+// e.g. a USB-C port with nothing plugged in.
+func (m *LinuxMonitor) adapterWatts() (float64, bool) {
+	var total float64
+	for _, path := range m.acPowerPaths {
+		if m.readFile(filepath.Join(path, "online")) == "0" {
+			continue
+		}
+		if watts, ok := m.calculateWatts(path); ok {
+			total += watts
+		}
+	}
+	return total, total > 0
+}
+
+// raplWatts samples the RAPL package energy counter and returns the average
+// power since the previous sample. The second result is false on the first
+// sample, or if RAPL isn't available.
+func (m *LinuxMonitor) raplWatts() (float64, bool) {
+	if m.raplPath == "" {
+		return 0, false
+	}
+	energy, err := strconv.ParseFloat(m.readFile(filepath.Join(m.raplPath, "energy_uj")), 64)
+	if err != nil {
+		return 0, false
+	}
+	cur := raplSample{energy: energy, at: time.Now()}
+
+	m.raplMu.Lock()
+	defer m.raplMu.Unlock()
+	prev := m.raplLast
+	m.raplLast = cur
+	if prev.at.IsZero() {
+		return 0, false
+	}
+	return raplPower(prev, cur, m.readFloat(filepath.Join(m.raplPath, "max_energy_range_uj")))
+}
+
+// raplPower returns the average power in watts between two RAPL samples,
+// allowing for the counter wrapping after maxRange microjoules.
+func raplPower(prev, cur raplSample, maxRange float64) (float64, bool) {
+	elapsed := cur.at.Sub(prev.at).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+
+	delta, ok := energyDelta(prev.energy, cur.energy, maxRange)
+	if !ok {
+		return 0, false
+	}
+	return delta / 1000000.0 / elapsed, true // µJ to J, over seconds
+}
+
 // NewMonitor creates the appropriate monitor for this platform.
 func NewMonitor() Monitor {
 	return NewLinuxMonitor()
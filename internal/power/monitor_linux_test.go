@@ -0,0 +1,681 @@
+//go:build linux
+
+package power
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// writeSysfs creates a fake power supply directory with the given files.
+func writeSysfs(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content+"\n"), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLinuxMonitor_Name(t *testing.T) {
+	m := NewLinuxMonitor()
+	if m.Name() != "linux-sysfs" {
+		t.Errorf("expected name 'linux-sysfs', got '%s'", m.Name())
+	}
+}
+
+func TestLinuxMonitor_ReadCapacity(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		wantNow    float64
+		wantFull   float64
+		wantDesign float64
+		wantUnit   string
+	}{
+		{
+			name: "energy based battery",
+			files: map[string]string{
+				"energy_now":         "40000000",
+				"energy_full":        "52000000",
+				"energy_full_design": "57000000",
+			},
+			wantNow:    40,
+			wantFull:   52,
+			wantDesign: 57,
+			wantUnit:   "Wh",
+		},
+		{
+			name: "charge based battery",
+			files: map[string]string{
+				"charge_now":         "3900000",
+				"charge_full":        "4820000",
+				"charge_full_design": "5100000",
+			},
+			wantNow:    3900,
+			wantFull:   4820,
+			wantDesign: 5100,
+			wantUnit:   "mAh",
+		},
+		{
+			name:  "no capacity files",
+			files: map[string]string{"capacity": "50"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &LinuxMonitor{}
+			reading := Reading{}
+			m.readCapacity(writeSysfs(t, tt.files), &reading)
+
+			if reading.CapacityNow != tt.wantNow {
+				t.Errorf("CapacityNow = %f, want %f", reading.CapacityNow, tt.wantNow)
+			}
+			if reading.CapacityFull != tt.wantFull {
+				t.Errorf("CapacityFull = %f, want %f", reading.CapacityFull, tt.wantFull)
+			}
+			if reading.CapacityDesign != tt.wantDesign {
+				t.Errorf("CapacityDesign = %f, want %f", reading.CapacityDesign, tt.wantDesign)
+			}
+			if reading.CapacityUnit != tt.wantUnit {
+				t.Errorf("CapacityUnit = %q, want %q", reading.CapacityUnit, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestLinuxMonitor_CalculateWatts(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		want          float64
+		wantAvailable bool
+	}{
+		{
+			name:          "power_now in microwatts",
+			files:         map[string]string{"power_now": "12345000"},
+			want:          12.345,
+			wantAvailable: true,
+		},
+		{
+			name:          "voltage times current",
+			files:         map[string]string{"voltage_now": "12000000", "current_now": "1500000"},
+			want:          18.0, // 12V * 1.5A
+			wantAvailable: true,
+		},
+		{
+			name:          "negative current while discharging",
+			files:         map[string]string{"voltage_now": "11400000", "current_now": "-2000000"},
+			want:          22.8,
+			wantAvailable: true,
+		},
+		{
+			name: "power_now preferred over voltage and current",
+			files: map[string]string{
+				"power_now":   "5000000",
+				"voltage_now": "12000000",
+				"current_now": "1500000",
+			},
+			want:          5.0,
+			wantAvailable: true,
+		},
+		{
+			name: "invalid power_now falls back to voltage and current",
+			files: map[string]string{
+				"power_now":   "unknown",
+				"voltage_now": "10000000",
+				"current_now": "1000000",
+			},
+			want:          10.0,
+			wantAvailable: true,
+		},
+		{
+			name:          "zero power is still a measurement",
+			files:         map[string]string{"power_now": "0"},
+			want:          0,
+			wantAvailable: true,
+		},
+		{
+			name:  "missing current",
+			files: map[string]string{"voltage_now": "12000000"},
+			want:  0,
+		},
+		{
+			name:  "no files",
+			files: map[string]string{},
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &LinuxMonitor{}
+			got, available := m.calculateWatts(writeSysfs(t, tt.files))
+			if math.Abs(got-tt.want) > 1e-9 || available != tt.wantAvailable {
+				t.Errorf("calculateWatts() = %f, %v, want %f, %v", got, available, tt.want, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestLinuxMonitor_CalculateBatteryPercent(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  float64
+	}{
+		{
+			name:  "energy based",
+			files: map[string]string{"energy_now": "30000000", "energy_full": "40000000"},
+			want:  75,
+		},
+		{
+			name:  "charge based",
+			files: map[string]string{"charge_now": "1000000", "charge_full": "4000000"},
+			want:  25,
+		},
+		{
+			name:  "zero full capacity",
+			files: map[string]string{"energy_now": "1000000", "energy_full": "0"},
+			want:  -1,
+		},
+		{
+			name:  "no files",
+			files: map[string]string{},
+			want:  -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &LinuxMonitor{}
+			if got := m.calculateBatteryPercent(writeSysfs(t, tt.files)); got != tt.want {
+				t.Errorf("calculateBatteryPercent() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinuxMonitor_Read(t *testing.T) {
+	t.Run("single battery on AC", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"AC": {"type": "Mains", "online": "1"},
+			"BAT0": {
+				"type":        "Battery",
+				"capacity":    "64",
+				"status":      "Charging",
+				"voltage_now": "12500000",
+				"current_now": "800000",
+			},
+		})
+
+		m := newLinuxMonitorWithRoot(root)
+		if !m.IsSupported() {
+			t.Fatal("expected monitor to be supported")
+		}
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.Watts-10.0) > 1e-9 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want 10", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.BatteryPercent != 64 {
+			t.Errorf("BatteryPercent = %f, want 64", reading.BatteryPercent)
+		}
+		if reading.IsOnBattery || !reading.IsCharging {
+			t.Errorf("expected charging on AC, got %+v", reading)
+		}
+		if reading.PowerKind != PowerKindCharge {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindCharge)
+		}
+		if reading.Source != "linux-sysfs" {
+			t.Errorf("Source = %q, want linux-sysfs", reading.Source)
+		}
+	})
+
+	t.Run("reports battery health", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"BAT0": {
+				"type":               "Battery",
+				"capacity":           "75",
+				"charge_now":         "3450000",
+				"charge_full":        "4600000",
+				"charge_full_design": "5000000",
+			},
+		})
+
+		reading, err := newLinuxMonitorWithRoot(root).Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.BatteryHealthPercent-92) > 1e-9 {
+			t.Errorf("BatteryHealthPercent = %f, want 92", reading.BatteryHealthPercent)
+		}
+	})
+
+	t.Run("estimates time remaining while discharging", func(t *testing.T) {
+		tests := map[string]map[string]string{
+			"energy": {"energy_now": "30000000", "power_now": "10000000"},
+			"charge": {"charge_now": "3000000", "current_now": "1000000"},
+		}
+		for name, files := range tests {
+			t.Run(name, func(t *testing.T) {
+				battery := map[string]string{"type": "Battery", "status": "Discharging"}
+				for k, v := range files {
+					battery[k] = v
+				}
+				root := writeSysfsTree(t, map[string]map[string]string{
+					"AC":   {"type": "Mains", "online": "0"},
+					"BAT0": battery,
+				})
+
+				reading, err := newLinuxMonitorWithRoot(root).Read(context.Background())
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if reading.TimeRemaining != 3*time.Hour {
+					t.Errorf("TimeRemaining = %v, want 3h", reading.TimeRemaining)
+				}
+			})
+		}
+	})
+
+	t.Run("no time remaining while charging", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"BAT0": {"type": "Battery", "status": "Charging", "energy_now": "30000000", "power_now": "10000000"},
+		})
+
+		reading, err := newLinuxMonitorWithRoot(root).Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.TimeRemaining != 0 {
+			t.Errorf("TimeRemaining = %v, want 0", reading.TimeRemaining)
+		}
+	})
+
+	t.Run("desktop without a battery", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"AC": {"type": "Mains", "online": "1"},
+		})
+
+		reading, err := newLinuxMonitorWithRoot(root).Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.BatteryPercent != -1 || reading.Watts != 0 || reading.WattsAvailable || reading.PowerKind != "" {
+			t.Errorf("expected no battery data, got %+v", reading)
+		}
+	})
+
+	t.Run("prefers adapter input power while plugged in", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"AC": {"type": "Mains", "online": "1", "power_now": "45000000"},
+			"BAT0": {
+				"type":      "Battery",
+				"capacity":  "64",
+				"status":    "Charging",
+				"power_now": "10000000",
+			},
+		})
+
+		reading, err := newLinuxMonitorWithRoot(root).Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.Watts-45.0) > 1e-9 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want the adapter's 45", reading.Watts, reading.WattsAvailable)
+		}
+		if !reading.IsCharging || reading.BatteryPercent != 64 {
+			t.Errorf("expected battery state to be kept, got %+v", reading)
+		}
+		if reading.PowerKind != PowerKindAdapter {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindAdapter)
+		}
+	})
+
+	t.Run("computes adapter power from current and voltage", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"ucsi-source-psy-USBC000:001": {
+				"type":        "USB",
+				"online":      "1",
+				"voltage_now": "20000000",
+				"current_now": "1500000",
+			},
+		})
+
+		reading, err := newLinuxMonitorWithRoot(root).Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.Watts-30.0) > 1e-9 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want 30", reading.Watts, reading.WattsAvailable)
+		}
+	})
+
+	t.Run("uses battery power on battery", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "0", "power_now": "0"},
+			"BAT0": {"type": "Battery", "status": "Discharging", "power_now": "8000000"},
+		})
+
+		reading, err := newLinuxMonitorWithRoot(root).Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reading.IsOnBattery || math.Abs(reading.Watts-8.0) > 1e-9 || reading.PowerKind != PowerKindDischarge {
+			t.Errorf("expected 8W discharging from the battery, got %+v", reading)
+		}
+	})
+
+	t.Run("falls back to battery power when the adapter reports none", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "1"},
+			"USB":  {"type": "USB", "online": "0", "voltage_now": "5000000", "current_now": "0"},
+			"BAT0": {"type": "Battery", "status": "Charging", "power_now": "10000000"},
+		})
+
+		m := newLinuxMonitorWithRoot(root)
+		if len(m.acPowerPaths) != 1 {
+			t.Errorf("expected only the USB supply to report power, got %v", m.acPowerPaths)
+		}
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.Watts-10.0) > 1e-9 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want the battery's 10", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.PowerKind != PowerKindCharge {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindCharge)
+		}
+	})
+
+	t.Run("missing power supply directory", func(t *testing.T) {
+		m := newLinuxMonitorWithRoot(filepath.Join(t.TempDir(), "missing"))
+		if m.IsSupported() {
+			t.Error("expected monitor to be unsupported")
+		}
+	})
+}
+
+func TestLinuxMonitor_ReadTemperature(t *testing.T) {
+	t.Run("converts tenths of a degree", func(t *testing.T) {
+		m := &LinuxMonitor{batteryPaths: []string{writeSysfs(t, map[string]string{"capacity": "80", "temp": "312"})}}
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Temperature < 31.19 || reading.Temperature > 31.21 {
+			t.Errorf("Temperature = %f, want 31.2", reading.Temperature)
+		}
+	})
+
+	t.Run("missing temp file", func(t *testing.T) {
+		m := &LinuxMonitor{batteryPaths: []string{writeSysfs(t, map[string]string{"capacity": "80"})}}
+
+		reading, _ := m.Read(context.Background())
+		if reading.Temperature != 0 {
+			t.Errorf("Temperature = %f, want 0", reading.Temperature)
+		}
+	})
+}
+
+func TestLinuxMonitor_ReadCycleCount(t *testing.T) {
+	t.Run("reads cycle_count", func(t *testing.T) {
+		m := &LinuxMonitor{batteryPaths: []string{writeSysfs(t, map[string]string{"capacity": "80", "cycle_count": "412"})}}
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.CycleCount != 412 {
+			t.Errorf("CycleCount = %d, want 412", reading.CycleCount)
+		}
+	})
+
+	t.Run("missing cycle_count file", func(t *testing.T) {
+		m := &LinuxMonitor{batteryPaths: []string{writeSysfs(t, map[string]string{"capacity": "80"})}}
+
+		reading, _ := m.Read(context.Background())
+		if reading.CycleCount != 0 {
+			t.Errorf("CycleCount = %d, want 0", reading.CycleCount)
+		}
+	})
+}
+
+// writeSysfsTree creates a fake /sys/class/power_supply tree with one
+// directory per supply.
+func writeSysfsTree(t *testing.T, supplies map[string]map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, files := range supplies {
+		dir := filepath.Join(root, name)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		for file, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(content+"\n"), 0o644); err != nil {
+				t.Fatalf("failed to write %s/%s: %v", name, file, err)
+			}
+		}
+	}
+	return root
+}
+
+func TestLinuxMonitor_MultipleBatteries(t *testing.T) {
+	t.Run("detects every battery", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "0"},
+			"BAT0": {"type": "Battery"},
+			"BAT1": {"type": "Battery"},
+		})
+
+		m := newLinuxMonitorWithRoot(root)
+		if len(m.batteryPaths) != 2 {
+			t.Fatalf("expected 2 battery paths, got %v", m.batteryPaths)
+		}
+		if !m.IsSupported() {
+			t.Error("expected monitor to be supported")
+		}
+	})
+
+	t.Run("aggregates watts and weights percentage by capacity", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"AC": {"type": "Mains", "online": "0"},
+			"BAT0": {
+				"type":               "Battery",
+				"status":             "Discharging",
+				"capacity":           "90",
+				"power_now":          "6000000",
+				"energy_now":         "21600000",
+				"energy_full":        "24000000",
+				"energy_full_design": "24000000",
+				"temp":               "300",
+				"cycle_count":        "120",
+			},
+			"BAT1": {
+				"type":               "Battery",
+				"status":             "Discharging",
+				"capacity":           "30",
+				"power_now":          "4500000",
+				"energy_now":         "21600000",
+				"energy_full":        "72000000",
+				"energy_full_design": "72000000",
+				"temp":               "325",
+				"cycle_count":        "85",
+			},
+		})
+
+		reading, err := newLinuxMonitorWithRoot(root).Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts < 10.49 || reading.Watts > 10.51 {
+			t.Errorf("Watts = %f, want 10.5", reading.Watts)
+		}
+		// (90*24 + 30*72) / 96 = 45
+		if reading.BatteryPercent < 44.99 || reading.BatteryPercent > 45.01 {
+			t.Errorf("BatteryPercent = %f, want 45", reading.BatteryPercent)
+		}
+		if reading.CapacityNow != 43.2 || reading.CapacityFull != 96 || reading.CapacityUnit != "Wh" {
+			t.Errorf("capacity = %f/%f %s, want 43.2/96 Wh", reading.CapacityNow, reading.CapacityFull, reading.CapacityUnit)
+		}
+		if reading.Temperature != 32.5 {
+			t.Errorf("Temperature = %f, want hottest battery 32.5", reading.Temperature)
+		}
+		if reading.CycleCount != 120 {
+			t.Errorf("CycleCount = %d, want most worn battery 120", reading.CycleCount)
+		}
+		// 43.2Wh left at 10.5W
+		if math.Abs(reading.TimeRemaining.Hours()-43.2/10.5) > 1e-6 {
+			t.Errorf("TimeRemaining = %v, want about 4h6m", reading.TimeRemaining)
+		}
+		if !reading.IsOnBattery || reading.IsCharging {
+			t.Errorf("expected discharging on battery, got %+v", reading)
+		}
+	})
+
+	t.Run("averages percentage when capacity units differ", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"BAT0": {"type": "Battery", "capacity": "80", "energy_full": "50000000"},
+			"BAT1": {"type": "Battery", "capacity": "40", "charge_full": "4000000", "status": "Charging"},
+		})
+
+		reading, err := newLinuxMonitorWithRoot(root).Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.BatteryPercent != 60 {
+			t.Errorf("BatteryPercent = %f, want 60", reading.BatteryPercent)
+		}
+		if reading.CapacityUnit != "" || reading.CapacityFull != 0 || reading.BatteryHealthPercent != 0 {
+			t.Errorf("expected mixed-unit capacity to be dropped, got %f %s", reading.CapacityFull, reading.CapacityUnit)
+		}
+		if !reading.IsCharging {
+			t.Error("expected charging when any battery is charging")
+		}
+	})
+}
+
+func TestRaplPower(t *testing.T) {
+	base := time.Now()
+	sample := func(energy float64, offset time.Duration) raplSample {
+		return raplSample{energy: energy, at: base.Add(offset)}
+	}
+
+	tests := []struct {
+		name     string
+		prev     raplSample
+		cur      raplSample
+		maxRange float64
+		want     float64
+		wantOK   bool
+	}{
+		{"steady counter", sample(1000000, 0), sample(16000000, time.Second), 262143328850, 15, true},
+		{"half a second", sample(0, 0), sample(5000000, 500*time.Millisecond), 262143328850, 10, true},
+		{"wraps around", sample(262139328850, 0), sample(6000000, 2*time.Second), 262143328850, 5, true},
+		{"wrap without a range", sample(9000000, 0), sample(1000000, time.Second), 0, 0, false},
+		{"previous sample beyond the range", sample(500, 0), sample(100, time.Second), 400, 0, false},
+		{"no time elapsed", sample(0, 0), sample(1000000, 0), 262143328850, 0, false},
+		{"clock went backwards", sample(0, time.Second), sample(1000000, 0), 262143328850, 0, false},
+		{"idle", sample(7000000, 0), sample(7000000, time.Second), 262143328850, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := raplPower(tt.prev, tt.cur, tt.maxRange)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("raplPower() = %f, %v, want %f, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLinuxMonitor_RAPL(t *testing.T) {
+	// newMonitor returns a monitor for root whose RAPL counter reads energy
+	// and was last sampled a second ago at 10 J less
+	newMonitor := func(t *testing.T, root string, energy float64) *LinuxMonitor {
+		m := newLinuxMonitorWithRoot(root)
+		m.raplPath = writeSysfs(t, map[string]string{
+			"energy_uj":           strconv.FormatFloat(energy, 'f', -1, 64),
+			"max_energy_range_uj": "262143328850",
+		})
+		m.raplLast = raplSample{energy: energy - 10000000, at: time.Now().Add(-time.Second)}
+		return m
+	}
+
+	t.Run("falls back to package power without battery power", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"AC": {"type": "Mains", "online": "1"},
+		})
+		m := newMonitor(t, root, 50000000)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// About 10 J over a little more than a second
+		if !reading.WattsAvailable || reading.Watts < 9 || reading.Watts > 10 {
+			t.Errorf("Watts = %f (available %v), want about 10", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.Components[ComponentCPU] != reading.Watts || reading.PowerKind != PowerKindSystem {
+			t.Errorf("expected CPU package system power, got %+v", reading)
+		}
+	})
+
+	t.Run("battery power takes priority", func(t *testing.T) {
+		root := writeSysfsTree(t, map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "0"},
+			"BAT0": {"type": "Battery", "status": "Discharging", "power_now": "8000000"},
+		})
+		m := newMonitor(t, root, 50000000)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.Watts-8.0) > 1e-9 || reading.Components != nil {
+			t.Errorf("expected the battery's 8W, got %+v", reading)
+		}
+	})
+
+	t.Run("first sample has nothing to compare against", func(t *testing.T) {
+		m := newMonitor(t, t.TempDir(), 50000000)
+		m.raplLast = raplSample{}
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.WattsAvailable {
+			t.Errorf("expected no watts from a single sample, got %+v", reading)
+		}
+		if !m.IsSupported() {
+			t.Error("expected RAPL alone to make the monitor supported")
+		}
+		if m.raplLast.energy != 50000000 {
+			t.Errorf("expected the sample to be kept for the next read, got %+v", m.raplLast)
+		}
+	})
+}
+
+func TestNewMonitor_Linux(t *testing.T) {
+	m := NewMonitor()
+	if m == nil {
+		t.Fatal("NewMonitor returned nil")
+	}
+	if _, ok := m.(*LinuxMonitor); !ok {
+		t.Errorf("expected *LinuxMonitor, got %T", m)
+	}
+}
@@ -0,0 +1,339 @@
+//go:build netbsd
+
+package power
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NetBSDMonitor reads power information on NetBSD from the acpibat0 and
+// acpiacad0 envsys(4) sensors, as reported by envstat(8).
+type NetBSDMonitor struct {
+	runner commandRunner
+}
+
+// NewNetBSDMonitor creates a new NetBSD power monitor.
+func NewNetBSDMonitor() *NetBSDMonitor {
+	return newNetBSDMonitorWithRunner(execRunner{})
+}
+
+// newNetBSDMonitorWithRunner creates a NetBSD power monitor that runs
+// envstat through runner.
+func newNetBSDMonitorWithRunner(runner commandRunner) *NetBSDMonitor {
+	return &NetBSDMonitor{runner: runner}
+}
+
+// Name returns the name of this monitor.
+func (m *NetBSDMonitor) Name() string {
+	return "netbsd-envstat"
+}
+
+// IsSupported checks if power monitoring is available on this system.
+func (m *NetBSDMonitor) IsSupported() bool {
+	_, err := m.runner.Run(context.Background(), "envstat", "-D")
+	return err == nil
+}
+
+// Close does nothing, since the monitor holds nothing open between reads.
+func (m *NetBSDMonitor) Close() error {
+	return nil
+}
+
+// Read returns the current power consumption reading.
+func (m *NetBSDMonitor) Read(ctx context.Context) (Reading, error) {
+	reading := Reading{
+		BatteryPercent: -1,
+		Source:         m.Name(),
+	}
+
+	out, err := m.runner.Run(ctx, "envstat", "-x")
+	if err != nil {
+		reading.Timestamp = time.Now()
+		return reading, err
+	}
+	devices, err := parseEnvstatXML(string(out))
+	if err != nil {
+		reading.Timestamp = time.Now()
+		return reading, err
+	}
+	parseEnvstatPower(devices, &reading)
+
+	// Stamp the reading when sampling finished, not when it started
+	reading.Timestamp = time.Now()
+
+	return reading, nil
+}
+
+// envstatSensor holds one sensor's scalar properties from envstat -x, such
+// as "description", "type", "state" and "cur-value". Booleans are "true" or
+// "false"; nested dictionaries are left out.
+type envstatSensor map[string]string
+
+// parseEnvstatXML parses envstat -x output, a property list mapping each
+// device to an array of sensor dictionaries, into sensors by device name.
+//
+// Example:
+//
+//	<plist version="1.0">
+//	<dict>
+//		<key>acpiacad0</key>
+//		<array>
+//			<dict>
+//				<key>cur-value</key>
+//				<integer>1</integer>
+//				<key>description</key>
+//				<string>connected</string>
+//				...
+func parseEnvstatXML(output string) (map[string][]envstatSensor, error) {
+	d := xml.NewDecoder(strings.NewReader(output))
+
+	// Find the top-level dictionary of devices
+	for {
+		el, ok, err := nextEnvstatElement(d)
+		if err != nil {
+			return nil, fmt.Errorf("parsing envstat output: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		if el.Name.Local == "dict" {
+			break
+		}
+	}
+
+	devices := map[string][]envstatSensor{}
+	for {
+		name, el, ok, err := nextEnvstatEntry(d)
+		if err != nil {
+			return nil, fmt.Errorf("parsing envstat output: %w", err)
+		}
+		if !ok {
+			return devices, nil
+		}
+		if el.Name.Local != "array" {
+			if err := d.Skip(); err != nil {
+				return nil, fmt.Errorf("parsing envstat output: %w", err)
+			}
+			continue
+		}
+
+		var sensors []envstatSensor
+		for {
+			el, ok, err := nextEnvstatElement(d)
+			if err != nil {
+				return nil, fmt.Errorf("parsing envstat output: %w", err)
+			}
+			if !ok {
+				break
+			}
+			if el.Name.Local != "dict" {
+				if err := d.Skip(); err != nil {
+					return nil, fmt.Errorf("parsing envstat output: %w", err)
+				}
+				continue
+			}
+			sensor, err := parseEnvstatSensor(d)
+			if err != nil {
+				return nil, fmt.Errorf("parsing envstat output: %w", err)
+			}
+			sensors = append(sensors, sensor)
+		}
+		devices[name] = sensors
+	}
+}
+
+// parseEnvstatSensor reads the entries of a sensor dictionary whose start
+// element has just been consumed, up to and including its end element.
+func parseEnvstatSensor(d *xml.Decoder) (envstatSensor, error) {
+	sensor := envstatSensor{}
+	for {
+		key, el, ok, err := nextEnvstatEntry(d)
+		if err != nil || !ok {
+			return sensor, err
+		}
+
+		switch el.Name.Local {
+		case "true", "false":
+			sensor[key] = el.Name.Local
+			err = d.Skip()
+		case "dict", "array":
+			err = d.Skip()
+		default:
+			var value string
+			err = d.DecodeElement(&value, &el)
+			sensor[key] = strings.TrimSpace(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// nextEnvstatEntry reads the next key of a dictionary and returns it with
+// the start element of its value, which the caller must consume. It reports
+// false once the dictionary's end element is reached.
+func nextEnvstatEntry(d *xml.Decoder) (string, xml.StartElement, bool, error) {
+	el, ok, err := nextEnvstatElement(d)
+	if err != nil || !ok {
+		return "", xml.StartElement{}, false, err
+	}
+	if el.Name.Local != "key" {
+		return "", xml.StartElement{}, false, fmt.Errorf("expected <key>, got <%s>", el.Name.Local)
+	}
+	var key string
+	if err := d.DecodeElement(&key, &el); err != nil {
+		return "", xml.StartElement{}, false, err
+	}
+
+	value, ok, err := nextEnvstatElement(d)
+	if err != nil {
+		return "", xml.StartElement{}, false, err
+	}
+	if !ok {
+		return "", xml.StartElement{}, false, fmt.Errorf("missing value for key %q", key)
+	}
+	return key, value, true, nil
+}
+
+// nextEnvstatElement returns the next start element, skipping text,
+// comments and other tokens. It reports false when it reaches an end
+// element instead, which closes the element being read.
+func nextEnvstatElement(d *xml.Decoder) (xml.StartElement, bool, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, false, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return t, true, nil
+		case xml.EndElement:
+			return xml.StartElement{}, false, nil
+		}
+	}
+}
+
+// parseEnvstatPower fills in battery and power data from the acpibat0 and
+// acpiacad0 sensors. envsys reports values in micro-units (µW, µWh, µA,
+// µAh, µV); batteries report either watts and watt-hours, or amps and
+// amp-hours, in which case power is computed from the current voltage.
+// Sensors marked invalid, such as the discharge rate while charging, are
+// ignored.
+func parseEnvstatPower(devices map[string][]envstatSensor, reading *Reading) {
+	adapter, hasAdapter := envstatIndicator(devices["acpiacad0"], "connected")
+
+	if present, ok := envstatIndicator(devices["acpibat0"], "present"); !ok || !present {
+		return
+	}
+	battery := map[string]envstatSensor{}
+	for _, sensor := range devices["acpibat0"] {
+		battery[sensor["description"]] = sensor
+	}
+
+	reading.IsCharging, _ = envstatIndicator(devices["acpibat0"], "charging")
+	if hasAdapter {
+		reading.IsOnBattery = !adapter
+	} else {
+		reading.IsOnBattery = !reading.IsCharging
+	}
+
+	if charge, ok := battery["charge"]; ok {
+		now, nowOK := envstatValue(charge, "cur-value")
+		full, fullOK := envstatValue(charge, "max-value")
+		if nowOK && fullOK && full > 0 {
+			reading.BatteryPercent = now / full * 100
+		}
+	}
+
+	// Discharge and charge rates are only valid in their own direction
+	rate, rateOK := envstatValue(battery["discharge rate"], "cur-value")
+	rateType := battery["discharge rate"]["type"]
+	if !rateOK || rate <= 0 {
+		rate, rateOK = envstatValue(battery["charge rate"], "cur-value")
+		rateType = battery["charge rate"]["type"]
+	}
+	if rateOK && rate > 0 {
+		watts := rate
+		if rateType == "Ampere" {
+			volts, _ := envstatValue(battery["voltage"], "cur-value")
+			watts = rate * volts
+		}
+		if watts > 0 {
+			reading.Watts = watts
+			reading.WattsAvailable = true
+			reading.PowerKind = batteryPowerKind(*reading)
+		}
+	}
+
+	capacities := map[string]*float64{
+		"charge":        &reading.CapacityNow,
+		"last full cap": &reading.CapacityFull,
+		"design cap":    &reading.CapacityDesign,
+	}
+	unit := ""
+	for desc, capacity := range capacities {
+		v, ok := envstatValue(battery[desc], "cur-value")
+		if !ok {
+			continue
+		}
+		switch battery[desc]["type"] {
+		case "Watt hour":
+			*capacity = v
+			unit = "Wh"
+		case "Ampere hour":
+			*capacity = v * 1000 // Ah to mAh
+			unit = "mAh"
+		}
+	}
+	reading.CapacityUnit = unit
+	reading.BatteryHealthPercent = batteryHealthPercent(*reading)
+}
+
+// envstatIndicator returns the value of the indicator sensor with the given
+// description, and whether it was found with a valid value.
+func envstatIndicator(sensors []envstatSensor, desc string) (bool, bool) {
+	for _, sensor := range sensors {
+		if sensor["description"] != desc {
+			continue
+		}
+		if !envstatValid(sensor) {
+			return false, false
+		}
+		v, err := strconv.Atoi(sensor["cur-value"])
+		return v != 0, err == nil
+	}
+	return false, false
+}
+
+// envstatValue returns a sensor property converted from micro-units, and
+// whether the sensor exists with a valid value.
+func envstatValue(sensor envstatSensor, key string) (float64, bool) {
+	if sensor == nil || !envstatValid(sensor) {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(sensor[key], 64)
+	if err != nil {
+		return 0, false
+	}
+	return v / 1e6, true
+}
+
+// envstatValid reports whether a sensor's state says its value can be
+// trusted. Warning and critical states still carry a current value.
+func envstatValid(sensor envstatSensor) bool {
+	switch sensor["state"] {
+	case "invalid", "unknown":
+		return false
+	}
+	return true
+}
+
+// NewMonitor creates the appropriate monitor for this platform.
+func NewMonitor() Monitor {
+	return NewNetBSDMonitor()
+}
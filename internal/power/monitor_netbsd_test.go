@@ -0,0 +1,365 @@
+//go:build netbsd
+
+package power
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// sampleEnvstat is trimmed envstat -x output from a ThinkPad on battery.
+const sampleEnvstat = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>acpiacad0</key>
+	<array>
+		<dict>
+			<key>cur-value</key>
+			<integer>0</integer>
+			<key>description</key>
+			<string>connected</string>
+			<key>index</key>
+			<string>sensor0</string>
+			<key>state</key>
+			<string>valid</string>
+			<key>type</key>
+			<string>Indicator</string>
+		</dict>
+		<dict>
+			<key>device-properties</key>
+			<dict>
+				<key>refresh-timeout</key>
+				<integer>0x1e</integer>
+			</dict>
+		</dict>
+	</array>
+	<key>acpibat0</key>
+	<array>
+		<dict>
+			<key>cur-value</key>
+			<integer>1</integer>
+			<key>description</key>
+			<string>present</string>
+			<key>state</key>
+			<string>valid</string>
+			<key>type</key>
+			<string>Indicator</string>
+		</dict>
+		<dict>
+			<key>cur-value</key>
+			<integer>50450000</integer>
+			<key>description</key>
+			<string>design cap</string>
+			<key>state</key>
+			<string>valid</string>
+			<key>type</key>
+			<string>Watt hour</string>
+		</dict>
+		<dict>
+			<key>cur-value</key>
+			<integer>47520000</integer>
+			<key>description</key>
+			<string>last full cap</string>
+			<key>state</key>
+			<string>valid</string>
+			<key>type</key>
+			<string>Watt hour</string>
+		</dict>
+		<dict>
+			<key>cur-value</key>
+			<integer>12380000</integer>
+			<key>description</key>
+			<string>voltage</string>
+			<key>state</key>
+			<string>valid</string>
+			<key>type</key>
+			<string>Voltage DC</string>
+		</dict>
+		<dict>
+			<key>cur-value</key>
+			<integer>0</integer>
+			<key>description</key>
+			<string>charge rate</string>
+			<key>state</key>
+			<string>invalid</string>
+			<key>type</key>
+			<string>Watts</string>
+		</dict>
+		<dict>
+			<key>cur-value</key>
+			<integer>9880000</integer>
+			<key>description</key>
+			<string>discharge rate</string>
+			<key>state</key>
+			<string>valid</string>
+			<key>type</key>
+			<string>Watts</string>
+		</dict>
+		<dict>
+			<key>critical-capacity</key>
+			<integer>200000</integer>
+			<key>cur-value</key>
+			<integer>35640000</integer>
+			<key>description</key>
+			<string>charge</string>
+			<key>max-value</key>
+			<integer>47520000</integer>
+			<key>monitoring-supported</key>
+			<true/>
+			<key>state</key>
+			<string>valid</string>
+			<key>type</key>
+			<string>Watt hour</string>
+			<key>want-percentage</key>
+			<true/>
+		</dict>
+		<dict>
+			<key>cur-value</key>
+			<integer>0</integer>
+			<key>description</key>
+			<string>charging</string>
+			<key>state</key>
+			<string>valid</string>
+			<key>type</key>
+			<string>Battery charge</string>
+		</dict>
+		<dict>
+			<key>device-properties</key>
+			<dict>
+				<key>refresh-timeout</key>
+				<integer>0x1e</integer>
+			</dict>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+
+// sampleEnvstatAmps is envstat -x output from a battery reporting amps and
+// amp-hours while charging, with no AC adapter sensor.
+const sampleEnvstatAmps = `<plist version="1.0">
+<dict>
+	<key>acpibat0</key>
+	<array>
+		<dict>
+			<key>cur-value</key><integer>1</integer>
+			<key>description</key><string>present</string>
+			<key>type</key><string>Indicator</string>
+		</dict>
+		<dict>
+			<key>cur-value</key><integer>4200000</integer>
+			<key>description</key><string>last full cap</string>
+			<key>type</key><string>Ampere hour</string>
+		</dict>
+		<dict>
+			<key>cur-value</key><integer>8000000</integer>
+			<key>description</key><string>voltage</string>
+			<key>type</key><string>Voltage DC</string>
+		</dict>
+		<dict>
+			<key>cur-value</key><integer>1250000</integer>
+			<key>description</key><string>charge rate</string>
+			<key>type</key><string>Ampere</string>
+		</dict>
+		<dict>
+			<key>cur-value</key><integer>0</integer>
+			<key>description</key><string>discharge rate</string>
+			<key>state</key><string>invalid</string>
+			<key>type</key><string>Ampere</string>
+		</dict>
+		<dict>
+			<key>cur-value</key><integer>2100000</integer>
+			<key>description</key><string>charge</string>
+			<key>max-value</key><integer>4200000</integer>
+			<key>type</key><string>Ampere hour</string>
+		</dict>
+		<dict>
+			<key>cur-value</key><integer>1</integer>
+			<key>description</key><string>charging</string>
+			<key>type</key><string>Battery charge</string>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+
+func TestNetBSDMonitor_Name(t *testing.T) {
+	m := NewNetBSDMonitor()
+	if m.Name() != "netbsd-envstat" {
+		t.Errorf("expected name 'netbsd-envstat', got '%s'", m.Name())
+	}
+}
+
+func TestParseEnvstatXML(t *testing.T) {
+	t.Run("sensors by device", func(t *testing.T) {
+		devices, err := parseEnvstatXML(sampleEnvstat)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(devices) != 2 {
+			t.Fatalf("expected 2 devices, got %d", len(devices))
+		}
+		// Each device ends with a dictionary of its properties
+		if len(devices["acpiacad0"]) != 2 || len(devices["acpibat0"]) != 9 {
+			t.Fatalf("unexpected sensor counts: acpiacad0=%d acpibat0=%d",
+				len(devices["acpiacad0"]), len(devices["acpibat0"]))
+		}
+
+		charge := devices["acpibat0"][6]
+		if charge["description"] != "charge" || charge["cur-value"] != "35640000" ||
+			charge["max-value"] != "47520000" || charge["want-percentage"] != "true" {
+			t.Errorf("unexpected charge sensor: %v", charge)
+		}
+		if _, ok := devices["acpibat0"][8]["device-properties"]; ok {
+			t.Error("expected nested dictionaries to be left out")
+		}
+	})
+
+	t.Run("no devices", func(t *testing.T) {
+		devices, err := parseEnvstatXML(`<plist version="1.0"><dict></dict></plist>`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(devices) != 0 {
+			t.Errorf("expected no devices, got %v", devices)
+		}
+	})
+
+	t.Run("rejects malformed output", func(t *testing.T) {
+		for _, input := range []string{
+			"",
+			"envstat: no drivers registered",
+			`<plist version="1.0"><dict><key>acpibat0</key>`,
+			`<plist version="1.0"><dict><string>acpibat0</string></dict></plist>`,
+		} {
+			if _, err := parseEnvstatXML(input); err == nil {
+				t.Errorf("expected an error for %q", input)
+			}
+		}
+	})
+}
+
+func TestParseEnvstatPower(t *testing.T) {
+	t.Run("watts and watt-hours on battery", func(t *testing.T) {
+		devices, err := parseEnvstatXML(sampleEnvstat)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		reading := Reading{BatteryPercent: -1}
+		parseEnvstatPower(devices, &reading)
+
+		if math.Abs(reading.Watts-9.88) > 1e-9 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want 9.88", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.PowerKind != PowerKindDischarge {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindDischarge)
+		}
+		if !reading.IsOnBattery || reading.IsCharging {
+			t.Errorf("IsOnBattery = %v, IsCharging = %v, want true, false", reading.IsOnBattery, reading.IsCharging)
+		}
+		if math.Abs(reading.BatteryPercent-75) > 1e-9 {
+			t.Errorf("BatteryPercent = %f, want 75", reading.BatteryPercent)
+		}
+		if reading.CapacityNow != 35.64 || reading.CapacityFull != 47.52 || reading.CapacityDesign != 50.45 {
+			t.Errorf("unexpected capacities: now=%f full=%f design=%f",
+				reading.CapacityNow, reading.CapacityFull, reading.CapacityDesign)
+		}
+		if reading.CapacityUnit != "Wh" {
+			t.Errorf("CapacityUnit = %q, want Wh", reading.CapacityUnit)
+		}
+		if want := 47.52 / 50.45 * 100; math.Abs(reading.BatteryHealthPercent-want) > 1e-9 {
+			t.Errorf("BatteryHealthPercent = %f, want %f", reading.BatteryHealthPercent, want)
+		}
+	})
+
+	t.Run("amps and amp-hours while charging", func(t *testing.T) {
+		devices, err := parseEnvstatXML(sampleEnvstatAmps)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		reading := Reading{BatteryPercent: -1}
+		parseEnvstatPower(devices, &reading)
+
+		if math.Abs(reading.Watts-10) > 1e-9 {
+			t.Errorf("Watts = %f, want 10", reading.Watts)
+		}
+		if reading.PowerKind != PowerKindCharge {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindCharge)
+		}
+		if reading.IsOnBattery || !reading.IsCharging {
+			t.Errorf("IsOnBattery = %v, IsCharging = %v, want false, true", reading.IsOnBattery, reading.IsCharging)
+		}
+		if reading.BatteryPercent != 50 {
+			t.Errorf("BatteryPercent = %f, want 50", reading.BatteryPercent)
+		}
+		if reading.CapacityFull != 4200 || reading.CapacityUnit != "mAh" {
+			t.Errorf("CapacityFull = %f %s, want 4200 mAh", reading.CapacityFull, reading.CapacityUnit)
+		}
+	})
+
+	t.Run("no battery", func(t *testing.T) {
+		reading := Reading{BatteryPercent: -1}
+		parseEnvstatPower(map[string][]envstatSensor{
+			"acpiacad0": {{"description": "connected", "cur-value": "1", "state": "valid"}},
+			"acpibat0":  {{"description": "present", "cur-value": "0", "state": "valid"}},
+		}, &reading)
+
+		if reading.WattsAvailable || reading.BatteryPercent != -1 || reading.IsOnBattery || reading.CapacityUnit != "" {
+			t.Errorf("expected an empty reading, got %+v", reading)
+		}
+	})
+}
+
+func TestNetBSDMonitor_Read(t *testing.T) {
+	t.Run("reads envstat", func(t *testing.T) {
+		m := newNetBSDMonitorWithRunner(newFakeRunner(map[string]string{"envstat -x": sampleEnvstat}))
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(reading.Watts-9.88) > 1e-9 {
+			t.Errorf("Watts = %f, want 9.88", reading.Watts)
+		}
+		if reading.Source != "netbsd-envstat" {
+			t.Errorf("Source = %q, want netbsd-envstat", reading.Source)
+		}
+		if reading.Timestamp.IsZero() {
+			t.Error("expected non-zero timestamp")
+		}
+	})
+
+	t.Run("returns error on malformed output", func(t *testing.T) {
+		m := newNetBSDMonitorWithRunner(newFakeRunner(map[string]string{"envstat -x": "not xml"}))
+
+		if _, err := m.Read(context.Background()); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("returns error when envstat fails", func(t *testing.T) {
+		runner := newFakeRunner(nil)
+		runner.errs["envstat"] = errors.New("no drivers registered")
+		m := newNetBSDMonitorWithRunner(runner)
+
+		if _, err := m.Read(context.Background()); err == nil {
+			t.Error("expected error")
+		}
+		if m.IsSupported() {
+			t.Error("expected IsSupported=false")
+		}
+	})
+}
+
+func TestNewMonitor_NetBSD(t *testing.T) {
+	m := NewMonitor()
+	if m == nil {
+		t.Fatal("NewMonitor returned nil")
+	}
+	if _, ok := m.(*NetBSDMonitor); !ok {
+		t.Errorf("expected *NetBSDMonitor, got %T", m)
+	}
+}
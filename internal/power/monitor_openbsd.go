@@ -0,0 +1,180 @@
+//go:build openbsd
+
+package power
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenBSDMonitor reads power information on OpenBSD from apm(8) and the
+// acpibat hw.sensors.
+type OpenBSDMonitor struct {
+	runner commandRunner
+}
+
+// NewOpenBSDMonitor creates a new OpenBSD power monitor.
+func NewOpenBSDMonitor() *OpenBSDMonitor {
+	return newOpenBSDMonitorWithRunner(execRunner{})
+}
+
+// newOpenBSDMonitorWithRunner creates an OpenBSD power monitor that runs apm
+// and sysctl through runner.
+func newOpenBSDMonitorWithRunner(runner commandRunner) *OpenBSDMonitor {
+	return &OpenBSDMonitor{runner: runner}
+}
+
+// Name returns the name of this monitor.
+func (m *OpenBSDMonitor) Name() string {
+	return "openbsd-apm"
+}
+
+// IsSupported checks if power monitoring is available on this system.
+func (m *OpenBSDMonitor) IsSupported() bool {
+	_, err := m.runner.Run(context.Background(), "apm", "-l")
+	return err == nil
+}
+
+// Close does nothing, since the monitor holds nothing open between reads.
+func (m *OpenBSDMonitor) Close() error {
+	return nil
+}
+
+// Read returns the current power consumption reading.
+func (m *OpenBSDMonitor) Read(ctx context.Context) (Reading, error) {
+	reading := Reading{
+		BatteryPercent: -1,
+		Source:         m.Name(),
+	}
+
+	out, err := m.runner.Run(ctx, "apm")
+	if err != nil {
+		reading.Timestamp = time.Now()
+		return reading, err
+	}
+	parseAPM(string(out), &reading)
+
+	// Power is only exposed by some batteries, so a failure here isn't fatal
+	if out, err := m.runner.Run(ctx, "sysctl", "hw.sensors.acpibat0"); err == nil || len(out) > 0 {
+		parseAcpibatSensors(string(out), &reading)
+	}
+
+	// Stamp the reading when sampling finished, not when it started
+	reading.Timestamp = time.Now()
+
+	return reading, nil
+}
+
+// parseAPM parses the default apm output for battery percent, charging state
+// and AC adapter state.
+//
+// Example:
+//
+//	Battery state: high, 87% remaining, 215 minutes life estimate
+//	A/C adapter state: not connected
+//	Performance adjustment mode: auto (800 MHz)
+func parseAPM(output string, reading *Reading) {
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "Battery state":
+			fields := strings.Split(value, ",")
+			state := strings.TrimSpace(fields[0])
+			if state == "absent" || state == "unknown" {
+				continue
+			}
+			reading.IsCharging = state == "charging"
+			for _, field := range fields[1:] {
+				field = strings.TrimSpace(field)
+				if pct, ok := strings.CutSuffix(field, "% remaining"); ok {
+					if v, err := strconv.ParseFloat(pct, 64); err == nil {
+						reading.BatteryPercent = v
+					}
+				}
+			}
+		case "A/C adapter state":
+			reading.IsOnBattery = value == "not connected"
+		}
+	}
+}
+
+// parseAcpibatSensors parses hw.sensors.acpibat0 sysctl output for power and
+// capacity. Batteries report either watts and watt-hours, or amps and
+// amp-hours, in which case power is computed from the current voltage.
+//
+// Example:
+//
+//	hw.sensors.acpibat0.volt1=12.38 VDC (current voltage)
+//	hw.sensors.acpibat0.power0=9.88 W (rate)
+//	hw.sensors.acpibat0.watthour0=47.52 Wh (last full capacity)
+//	hw.sensors.acpibat0.watthour3=41.35 Wh (remaining capacity), OK
+//	hw.sensors.acpibat0.watthour4=50.45 Wh (design capacity)
+func parseAcpibatSensors(output string, reading *Reading) {
+	var volts, amps, watts float64
+	capacities := map[string]float64{}
+	unit := ""
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		sensor := key[strings.LastIndex(key, ".")+1:]
+
+		number, rest, _ := strings.Cut(value, " ")
+		v, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			continue
+		}
+		desc := ""
+		if start := strings.Index(rest, "("); start >= 0 {
+			if end := strings.Index(rest[start:], ")"); end >= 0 {
+				desc = rest[start+1 : start+end]
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(sensor, "power") && desc == "rate":
+			watts = v
+		case strings.HasPrefix(sensor, "current") && desc == "rate":
+			amps = v
+		case strings.HasPrefix(sensor, "volt") && desc == "current voltage":
+			volts = v
+		case strings.HasPrefix(sensor, "watthour"):
+			capacities[desc] = v
+			unit = "Wh"
+		case strings.HasPrefix(sensor, "amphour"):
+			capacities[desc] = v * 1000 // Ah to mAh
+			unit = "mAh"
+		}
+	}
+
+	if watts <= 0 && amps > 0 && volts > 0 {
+		watts = amps * volts
+	}
+	if watts > 0 {
+		reading.Watts = watts
+		reading.WattsAvailable = true
+		reading.PowerKind = batteryPowerKind(*reading)
+	}
+
+	reading.CapacityFull = capacities["last full capacity"]
+	reading.CapacityNow = capacities["remaining capacity"]
+	reading.CapacityDesign = capacities["design capacity"]
+	if reading.CapacityFull > 0 || reading.CapacityNow > 0 || reading.CapacityDesign > 0 {
+		reading.CapacityUnit = unit
+	}
+	reading.BatteryHealthPercent = batteryHealthPercent(*reading)
+}
+
+// NewMonitor creates the appropriate monitor for this platform.
+func NewMonitor() Monitor {
+	return NewOpenBSDMonitor()
+}
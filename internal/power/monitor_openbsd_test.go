@@ -0,0 +1,192 @@
+//go:build openbsd
+
+package power
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// sampleAcpibat is sysctl hw.sensors.acpibat0 output from a ThinkPad.
+const sampleAcpibat = `hw.sensors.acpibat0.volt0=11.10 VDC (voltage)
+hw.sensors.acpibat0.volt1=12.38 VDC (current voltage)
+hw.sensors.acpibat0.power0=9.88 W (rate)
+hw.sensors.acpibat0.watthour0=47.52 Wh (last full capacity)
+hw.sensors.acpibat0.watthour1=2.38 Wh (warning capacity)
+hw.sensors.acpibat0.watthour2=0.20 Wh (low capacity)
+hw.sensors.acpibat0.watthour3=41.35 Wh (remaining capacity), OK
+hw.sensors.acpibat0.watthour4=50.45 Wh (design capacity)
+hw.sensors.acpibat0.raw0=1 (battery discharging), OK`
+
+func TestOpenBSDMonitor_Name(t *testing.T) {
+	m := NewOpenBSDMonitor()
+	if m.Name() != "openbsd-apm" {
+		t.Errorf("expected name 'openbsd-apm', got '%s'", m.Name())
+	}
+}
+
+func TestParseAPM(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantBattery  bool
+		wantPercent  float64
+		wantCharging bool
+	}{
+		{
+			name: "discharging on battery",
+			input: `Battery state: high, 87% remaining, 215 minutes life estimate
+A/C adapter state: not connected
+Performance adjustment mode: auto (800 MHz)`,
+			wantBattery:  true,
+			wantPercent:  87,
+			wantCharging: false,
+		},
+		{
+			name: "charging on AC",
+			input: `Battery state: charging, 42% remaining, unknown life estimate
+A/C adapter state: connected
+Performance adjustment mode: auto (2400 MHz)`,
+			wantBattery:  false,
+			wantPercent:  42,
+			wantCharging: true,
+		},
+		{
+			name: "no battery",
+			input: `Battery state: absent, 0% remaining, unknown life estimate
+A/C adapter state: connected`,
+			wantBattery:  false,
+			wantPercent:  -1,
+			wantCharging: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reading := Reading{BatteryPercent: -1}
+			parseAPM(tt.input, &reading)
+
+			if reading.IsOnBattery != tt.wantBattery {
+				t.Errorf("IsOnBattery = %v, want %v", reading.IsOnBattery, tt.wantBattery)
+			}
+			if reading.BatteryPercent != tt.wantPercent {
+				t.Errorf("BatteryPercent = %f, want %f", reading.BatteryPercent, tt.wantPercent)
+			}
+			if reading.IsCharging != tt.wantCharging {
+				t.Errorf("IsCharging = %v, want %v", reading.IsCharging, tt.wantCharging)
+			}
+		})
+	}
+}
+
+func TestParseAcpibatSensors(t *testing.T) {
+	t.Run("power and watt-hours", func(t *testing.T) {
+		var reading Reading
+		parseAcpibatSensors(sampleAcpibat, &reading)
+
+		if reading.Watts != 9.88 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want 9.88", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.CapacityFull != 47.52 || reading.CapacityNow != 41.35 || reading.CapacityDesign != 50.45 {
+			t.Errorf("unexpected capacities: now=%f full=%f design=%f",
+				reading.CapacityNow, reading.CapacityFull, reading.CapacityDesign)
+		}
+		if reading.CapacityUnit != "Wh" {
+			t.Errorf("CapacityUnit = %q, want Wh", reading.CapacityUnit)
+		}
+		if want := 47.52 / 50.45 * 100; math.Abs(reading.BatteryHealthPercent-want) > 1e-9 {
+			t.Errorf("BatteryHealthPercent = %f, want %f", reading.BatteryHealthPercent, want)
+		}
+	})
+
+	t.Run("current and amp-hours", func(t *testing.T) {
+		var reading Reading
+		parseAcpibatSensors(`hw.sensors.acpibat0.volt0=7.40 VDC (voltage)
+hw.sensors.acpibat0.volt1=8.00 VDC (current voltage)
+hw.sensors.acpibat0.current0=1.25 A (rate)
+hw.sensors.acpibat0.amphour0=4.20 Ah (last full capacity)
+hw.sensors.acpibat0.amphour3=2.10 Ah (remaining capacity), OK`, &reading)
+
+		if reading.Watts != 10 {
+			t.Errorf("Watts = %f, want 10", reading.Watts)
+		}
+		if reading.CapacityFull != 4200 || reading.CapacityUnit != "mAh" {
+			t.Errorf("CapacityFull = %f %s, want 4200 mAh", reading.CapacityFull, reading.CapacityUnit)
+		}
+	})
+
+	t.Run("no sensors", func(t *testing.T) {
+		var reading Reading
+		parseAcpibatSensors("", &reading)
+
+		if reading.Watts != 0 || reading.CapacityUnit != "" {
+			t.Errorf("expected empty reading, got %+v", reading)
+		}
+	})
+}
+
+func TestOpenBSDMonitor_Read(t *testing.T) {
+	t.Run("reads apm and sensors", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"apm":    "Battery state: high, 64% remaining, 180 minutes life estimate\nA/C adapter state: not connected",
+			"sysctl": sampleAcpibat,
+		})
+		m := newOpenBSDMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts != 9.88 {
+			t.Errorf("Watts = %f, want 9.88", reading.Watts)
+		}
+		if reading.BatteryPercent != 64 || !reading.IsOnBattery {
+			t.Errorf("unexpected battery state: %+v", reading)
+		}
+		if reading.PowerKind != PowerKindDischarge {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindDischarge)
+		}
+		if reading.Timestamp.IsZero() {
+			t.Error("expected non-zero timestamp")
+		}
+	})
+
+	t.Run("missing sensors are not fatal", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"apm": "A/C adapter state: connected"})
+		runner.errs["sysctl"] = errors.New("no such node")
+		m := newOpenBSDMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if reading.WattsAvailable {
+			t.Error("expected watts to be unavailable without sensors")
+		}
+	})
+
+	t.Run("returns error when apm fails", func(t *testing.T) {
+		runner := newFakeRunner(nil)
+		runner.errs["apm"] = errors.New("no apm device")
+		m := newOpenBSDMonitorWithRunner(runner)
+
+		if _, err := m.Read(context.Background()); err == nil {
+			t.Error("expected error")
+		}
+		if m.IsSupported() {
+			t.Error("expected IsSupported=false")
+		}
+	})
+}
+
+func TestNewMonitor_OpenBSD(t *testing.T) {
+	m := NewMonitor()
+	if m == nil {
+		t.Fatal("NewMonitor returned nil")
+	}
+	if _, ok := m.(*OpenBSDMonitor); !ok {
+		t.Errorf("expected *OpenBSDMonitor, got %T", m)
+	}
+}
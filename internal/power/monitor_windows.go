@@ -3,8 +3,8 @@
 package power
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"os/exec"
 	"regexp"
 	"strconv"
@@ -13,11 +13,19 @@ import (
 )
 
 // WindowsMonitor reads power information on Windows using WMI/PowerShell.
-type WindowsMonitor struct{}
+type WindowsMonitor struct {
+	runner commandRunner
+}
 
 // NewWindowsMonitor creates a new Windows power monitor.
 func NewWindowsMonitor() *WindowsMonitor {
-	return &WindowsMonitor{}
+	return newWindowsMonitorWithRunner(execRunner{})
+}
+
+// newWindowsMonitorWithRunner creates a Windows power monitor that runs
+// PowerShell through runner.
+func newWindowsMonitorWithRunner(runner commandRunner) *WindowsMonitor {
+	return &WindowsMonitor{runner: runner}
 }
 
 // Name returns the name of this monitor.
@@ -31,10 +39,14 @@ func (m *WindowsMonitor) IsSupported() bool {
 	return err == nil
 }
 
+// Close does nothing, since the monitor holds nothing open between reads.
+func (m *WindowsMonitor) Close() error {
+	return nil
+}
+
 // Read returns the current power consumption reading.
 func (m *WindowsMonitor) Read(ctx context.Context) (Reading, error) {
 	reading := Reading{
-		Timestamp:      time.Now(),
 		BatteryPercent: -1,
 		Source:         m.Name(),
 	}
@@ -45,10 +57,30 @@ func (m *WindowsMonitor) Read(ctx context.Context) (Reading, error) {
 		m.parseBatteryInfo(batteryInfo, &reading)
 	}
 
-	// Get power consumption estimate
-	if watts, err := m.getEstimatedWatts(ctx); err == nil {
-		reading.Watts = watts
+	if reading.Watts > 0 {
+		reading.PowerKind = PowerKindSystem
+	}
+
+	// Fall back to the battery discharge rate without a power meter
+	if reading.Watts == 0 {
+		if watts, err := m.getEstimatedWatts(ctx); err == nil && watts > 0 {
+			reading.Watts = watts
+			reading.PowerKind = batteryPowerKind(reading)
+		}
+	}
+
+	// Plugged-in desktops have neither, so report processor power instead
+	if reading.Watts == 0 {
+		if watts, components, err := m.getProcessorPower(ctx); err == nil && watts > 0 {
+			reading.Watts = watts
+			reading.Components = components
+			reading.PowerKind = PowerKindSystem
+		}
 	}
+	reading.WattsAvailable = reading.Watts > 0
+
+	// Stamp the reading when sampling finished, not when it started
+	reading.Timestamp = time.Now()
 
 	return reading, nil
 }
@@ -60,6 +92,7 @@ func (m *WindowsMonitor) getBatteryInfo(ctx context.Context) (string, error) {
 		if ($battery) {
 			Write-Output "BatteryStatus=$($battery.BatteryStatus)"
 			Write-Output "EstimatedChargeRemaining=$($battery.EstimatedChargeRemaining)"
+			Write-Output "EstimatedRunTime=$($battery.EstimatedRunTime)"
 			Write-Output "DesignCapacity=$($battery.DesignCapacity)"
 			Write-Output "FullChargeCapacity=$($battery.FullChargeCapacity)"
 		}
@@ -68,15 +101,17 @@ func (m *WindowsMonitor) getBatteryInfo(ctx context.Context) (string, error) {
 			Write-Output "CurrentReading=$($power.CurrentReading)"
 		}
 	`
-	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
+	out, err := m.runner.Run(ctx, "powershell", "-NoProfile", "-Command", script)
+	if err != nil {
 		return "", err
 	}
-	return out.String(), nil
+	return string(out), nil
 }
 
+// unknownRunTime is the EstimatedRunTime Win32_Battery reports when it has no
+// estimate, e.g. while on AC.
+const unknownRunTime = 71582788
+
 // parseBatteryInfo parses the PowerShell output.
 func (m *WindowsMonitor) parseBatteryInfo(output string, reading *Reading) {
 	lines := strings.Split(output, "\n")
@@ -96,15 +131,33 @@ func (m *WindowsMonitor) parseBatteryInfo(output string, reading *Reading) {
 
 		switch key {
 		case "BatteryStatus":
-			// 1 = Discharging, 2 = AC, 3-5 = various charging states
+			// 1 = Discharging, 2 = AC, 3 = Fully charged, 4 = Low,
+			// 5 = Critical, 6-9 = Charging (and high, low, critical),
+			// 10 = Undefined, 11 = Partially charged
 			if status, err := strconv.Atoi(value); err == nil {
 				reading.IsOnBattery = status == 1
-				reading.IsCharging = status >= 2 && status <= 5 && status != 2
+				reading.IsCharging = status >= 6 && status <= 9
 			}
 		case "EstimatedChargeRemaining":
 			if pct, err := strconv.ParseFloat(value, 64); err == nil {
 				reading.BatteryPercent = pct
 			}
+		case "EstimatedRunTime":
+			// Minutes of battery left, or unknownRunTime while on AC
+			if mins, err := strconv.Atoi(value); err == nil && mins > 0 && mins < unknownRunTime {
+				reading.TimeRemaining = time.Duration(mins) * time.Minute
+			}
+		case "DesignCapacity":
+			// Capacities are reported in mWh
+			if mwh, err := strconv.ParseFloat(value, 64); err == nil && mwh > 0 {
+				reading.CapacityDesign = mwh / 1000.0
+				reading.CapacityUnit = "Wh"
+			}
+		case "FullChargeCapacity":
+			if mwh, err := strconv.ParseFloat(value, 64); err == nil && mwh > 0 {
+				reading.CapacityFull = mwh / 1000.0
+				reading.CapacityUnit = "Wh"
+			}
 		case "CurrentReading":
 			// Power meter reading in milliwatts
 			if mw, err := strconv.ParseFloat(value, 64); err == nil && mw > 0 {
@@ -112,6 +165,7 @@ func (m *WindowsMonitor) parseBatteryInfo(output string, reading *Reading) {
 			}
 		}
 	}
+	reading.BatteryHealthPercent = batteryHealthPercent(*reading)
 }
 
 // getEstimatedWatts tries to estimate power consumption.
@@ -126,18 +180,15 @@ func (m *WindowsMonitor) getEstimatedWatts(ctx context.Context) (float64, error)
 		# Also try Win32_Battery
 		$bat2 = Get-WmiObject Win32_Battery -ErrorAction SilentlyContinue
 		if ($bat2) {
-			Write-Output "EstimatedRunTime=$($bat2.EstimatedRunTime)"
 			Write-Output "DesignVoltage=$($bat2.DesignVoltage)"
 		}
 	`
-	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
+	out, err := m.runner.Run(ctx, "powershell", "-NoProfile", "-Command", script)
+	if err != nil {
 		return 0, err
 	}
 
-	output := out.String()
+	output := string(out)
 	var dischargeRate, voltage float64
 
 	// Parse discharge rate (in mW)
@@ -166,6 +217,116 @@ func (m *WindowsMonitor) getEstimatedWatts(ctx context.Context) (float64, error)
 	return 0, nil
 }
 
+// processorPowerScript prints processor power sensors, preferring
+// LibreHardwareMonitor's WMI namespace (present while it runs) over the
+// Windows Energy Meter counters that expose Intel RAPL. Values are printed
+// with the invariant culture so decimals always use a dot.
+const processorPowerScript = `
+	$inv = [Globalization.CultureInfo]::InvariantCulture
+	$sensors = Get-CimInstance -Namespace root/LibreHardwareMonitor -ClassName Sensor -Filter "SensorType='Power'" -ErrorAction SilentlyContinue
+	foreach ($s in $sensors) {
+		Write-Output "LHM $($s.Parent)|$($s.Name)=$($s.Value.ToString($inv))"
+	}
+	if (-not $sensors) {
+		$samples = (Get-Counter '\Energy Meter(*)\Power' -ErrorAction SilentlyContinue).CounterSamples
+		foreach ($s in $samples) {
+			Write-Output "RAPL $($s.InstanceName)=$($s.CookedValue.ToString($inv))"
+		}
+	}
+`
+
+// getProcessorPower reads CPU and GPU power from hardware sensors.
+func (m *WindowsMonitor) getProcessorPower(ctx context.Context) (float64, map[string]float64, error) {
+	out, err := m.runner.Run(ctx, "powershell", "-NoProfile", "-Command", processorPowerScript)
+	if err != nil {
+		return 0, nil, err
+	}
+	return parseProcessorPower(string(out))
+}
+
+// parseProcessorPower parses the output of processorPowerScript into total
+// watts and per-component watts.
+//
+// LibreHardwareMonitor lines look like "LHM /intelcpu/0|CPU Package=15.2"
+// and are in watts. The package sensor of each CPU counts as cpu and the
+// package or board power of each discrete GPU counts as gpu; integrated GPUs
+// are skipped because the CPU package already includes them.
+//
+// Energy Meter lines look like "RAPL rapl_package0_pkg=15234" and are in
+// milliwatts. Package domains make up the total, with the core (pp0) and
+// graphics (pp1) domains broken out as cpu and gpu.
+func parseProcessorPower(output string) (float64, map[string]float64, error) {
+	var cpu, packages float64
+	var rapl bool
+	components := map[string]float64{}
+	gpus := map[string]float64{}
+
+	for _, line := range strings.Split(output, "\n") {
+		source, sensor, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		key, value, ok := strings.Cut(sensor, "=")
+		if !ok {
+			continue
+		}
+		watts, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || watts < 0 {
+			continue
+		}
+
+		switch source {
+		case "LHM":
+			parent, name, _ := strings.Cut(key, "|")
+			switch {
+			case name == "CPU Package" && (strings.HasPrefix(parent, "/intelcpu") || strings.HasPrefix(parent, "/amdcpu")):
+				cpu += watts
+			case strings.HasPrefix(parent, "/gpu") && !strings.Contains(parent, "integrated"):
+				// Prefer package power, but take board power if that's all
+				// the GPU reports
+				if name == "GPU Package" || (name == "GPU Power" && gpus[parent] == 0) {
+					gpus[parent] = watts
+				}
+			}
+		case "RAPL":
+			rapl = true
+			switch {
+			case strings.HasSuffix(key, "_pkg"):
+				packages += watts / 1000.0
+			case strings.HasSuffix(key, "_pp0"):
+				components[ComponentCPU] += watts / 1000.0
+			case strings.HasSuffix(key, "_pp1"):
+				components[ComponentGPU] += watts / 1000.0
+			}
+		}
+	}
+
+	if rapl {
+		if packages == 0 {
+			return 0, nil, errors.New("no RAPL package power in Energy Meter counters")
+		}
+		if len(components) == 0 {
+			components = nil
+		}
+		return packages, components, nil
+	}
+
+	var gpu float64
+	for _, w := range gpus {
+		gpu += w
+	}
+	if cpu == 0 && gpu == 0 {
+		return 0, nil, errors.New("no processor power sensors found")
+	}
+	if cpu > 0 {
+		components[ComponentCPU] = cpu
+	}
+	if gpu > 0 {
+		components[ComponentGPU] = gpu
+	}
+	return cpu + gpu, components, nil
+}
+
 // NewMonitor creates the appropriate monitor for this platform.
 func NewMonitor() Monitor {
 	return NewWindowsMonitor()
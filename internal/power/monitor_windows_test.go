@@ -0,0 +1,350 @@
+//go:build windows
+
+package power
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWindowsMonitor_Name(t *testing.T) {
+	m := NewWindowsMonitor()
+	if m.Name() != "windows-wmi" {
+		t.Errorf("expected name 'windows-wmi', got '%s'", m.Name())
+	}
+}
+
+func TestWindowsMonitor_ParseBatteryInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantPercent float64
+		wantFull    float64
+		wantDesign  float64
+		wantUnit    string
+		wantHealth  float64
+	}{
+		{
+			name: "battery with capacities",
+			input: `BatteryStatus=1
+EstimatedChargeRemaining=64
+DesignCapacity=57000
+FullChargeCapacity=52000`,
+			wantPercent: 64,
+			wantFull:    52,
+			wantDesign:  57,
+			wantUnit:    "Wh",
+			wantHealth:  52000.0 / 57000.0 * 100,
+		},
+		{
+			name: "battery without capacities",
+			input: `BatteryStatus=2
+EstimatedChargeRemaining=100
+DesignCapacity=
+FullChargeCapacity=`,
+			wantPercent: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewWindowsMonitor()
+			reading := Reading{BatteryPercent: -1}
+			m.parseBatteryInfo(tt.input, &reading)
+
+			if reading.BatteryPercent != tt.wantPercent {
+				t.Errorf("BatteryPercent = %f, want %f", reading.BatteryPercent, tt.wantPercent)
+			}
+			if reading.CapacityFull != tt.wantFull {
+				t.Errorf("CapacityFull = %f, want %f", reading.CapacityFull, tt.wantFull)
+			}
+			if reading.CapacityDesign != tt.wantDesign {
+				t.Errorf("CapacityDesign = %f, want %f", reading.CapacityDesign, tt.wantDesign)
+			}
+			if reading.CapacityUnit != tt.wantUnit {
+				t.Errorf("CapacityUnit = %q, want %q", reading.CapacityUnit, tt.wantUnit)
+			}
+			if math.Abs(reading.BatteryHealthPercent-tt.wantHealth) > 1e-9 {
+				t.Errorf("BatteryHealthPercent = %f, want %f", reading.BatteryHealthPercent, tt.wantHealth)
+			}
+		})
+	}
+}
+
+func TestWindowsMonitor_ParseEstimatedRunTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "192", want: 3*time.Hour + 12*time.Minute},
+		{value: "71582788"},
+		{value: "0"},
+		{value: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run("EstimatedRunTime="+tt.value, func(t *testing.T) {
+			m := NewWindowsMonitor()
+			reading := Reading{BatteryPercent: -1}
+			m.parseBatteryInfo("EstimatedRunTime="+tt.value, &reading)
+
+			if reading.TimeRemaining != tt.want {
+				t.Errorf("TimeRemaining = %v, want %v", reading.TimeRemaining, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowsMonitor_ParseBatteryStatus(t *testing.T) {
+	tests := []struct {
+		status       string
+		wantBattery  bool
+		wantCharging bool
+	}{
+		{status: "1", wantBattery: true},
+		{status: "2"},
+		{status: "3"},
+		{status: "4"},
+		{status: "5"},
+		{status: "6", wantCharging: true},
+		{status: "7", wantCharging: true},
+		{status: "8", wantCharging: true},
+		{status: "9", wantCharging: true},
+		{status: "10"},
+		{status: "11"},
+		{status: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run("BatteryStatus="+tt.status, func(t *testing.T) {
+			m := NewWindowsMonitor()
+			reading := Reading{BatteryPercent: -1}
+			m.parseBatteryInfo("BatteryStatus="+tt.status, &reading)
+
+			if reading.IsOnBattery != tt.wantBattery {
+				t.Errorf("IsOnBattery = %v, want %v", reading.IsOnBattery, tt.wantBattery)
+			}
+			if reading.IsCharging != tt.wantCharging {
+				t.Errorf("IsCharging = %v, want %v", reading.IsCharging, tt.wantCharging)
+			}
+		})
+	}
+}
+
+func TestWindowsMonitor_Read(t *testing.T) {
+	t.Run("parses battery and discharge rate", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"powershell": `BatteryStatus=1
+EstimatedChargeRemaining=80
+DischargeRate=12000
+Voltage=11500`})
+		m := newWindowsMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts != 12.0 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want 12.0", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.BatteryPercent != 80.0 {
+			t.Errorf("BatteryPercent = %f, want 80.0", reading.BatteryPercent)
+		}
+		if !reading.IsOnBattery {
+			t.Error("expected IsOnBattery=true")
+		}
+		if reading.PowerKind != PowerKindDischarge {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindDischarge)
+		}
+		if runner.Calls("powershell") != 2 {
+			t.Errorf("expected 2 powershell calls, got %d", runner.Calls("powershell"))
+		}
+	})
+
+	t.Run("prefers the power meter over the discharge rate", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{"powershell": `BatteryStatus=2
+CurrentReading=30000
+DischargeRate=12000`})
+		m := newWindowsMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts != 30.0 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want 30.0", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.PowerKind != PowerKindSystem {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindSystem)
+		}
+		if runner.Calls("powershell") != 1 {
+			t.Errorf("expected 1 powershell call, got %d", runner.Calls("powershell"))
+		}
+	})
+
+	t.Run("falls back to processor power on a desktop", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"powershell -NoProfile -Command " + processorPowerScript: sampleLHMSensors,
+		})
+		m := newWindowsMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts != 74.0 || !reading.WattsAvailable {
+			t.Errorf("Watts = %f (available %v), want 74.0", reading.Watts, reading.WattsAvailable)
+		}
+		if reading.Components[ComponentCPU] != 28.5 {
+			t.Errorf("cpu = %f, want 28.5", reading.Components[ComponentCPU])
+		}
+		if reading.IsOnBattery {
+			t.Error("expected IsOnBattery=false")
+		}
+		if reading.PowerKind != PowerKindSystem {
+			t.Errorf("PowerKind = %q, want %q", reading.PowerKind, PowerKindSystem)
+		}
+	})
+
+	t.Run("prefers the discharge rate over processor power", func(t *testing.T) {
+		runner := newFakeRunner(map[string]string{
+			"powershell": "BatteryStatus=1\nDischargeRate=12000",
+			"powershell -NoProfile -Command " + processorPowerScript: sampleLHMSensors,
+		})
+		m := newWindowsMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts != 12.0 {
+			t.Errorf("Watts = %f, want 12.0", reading.Watts)
+		}
+		if reading.Components != nil {
+			t.Errorf("expected no components, got %v", reading.Components)
+		}
+	})
+
+	t.Run("tolerates powershell failure", func(t *testing.T) {
+		runner := newFakeRunner(nil)
+		runner.errs["powershell"] = errors.New("not found")
+		m := newWindowsMonitorWithRunner(runner)
+
+		reading, err := m.Read(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.BatteryPercent != -1 {
+			t.Errorf("BatteryPercent = %f, want -1", reading.BatteryPercent)
+		}
+		if reading.WattsAvailable {
+			t.Error("expected watts to be unavailable")
+		}
+	})
+}
+
+// sampleLHMSensors is processorPowerScript output from a desktop running
+// LibreHardwareMonitor with an Intel CPU and an NVIDIA GPU.
+const sampleLHMSensors = `LHM /intelcpu/0|CPU Package=28.5
+LHM /intelcpu/0|CPU Cores=21.25
+LHM /intelcpu/0|CPU Memory=1.5
+LHM /gpu-intel-integrated/0|GPU Power=2.1
+LHM /gpu-nvidia/0|GPU Power=40
+LHM /gpu-nvidia/0|GPU Package=45.5
+`
+
+// sampleEnergyMeter is processorPowerScript output from the Windows Energy
+// Meter counters on an Intel laptop.
+const sampleEnergyMeter = `RAPL rapl_package0_pkg=15234
+RAPL rapl_package0_pp0=9120
+RAPL rapl_package0_pp1=850
+RAPL rapl_package0_dram=1200
+RAPL _total=26404
+`
+
+func TestParseProcessorPower(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		wantWatts      float64
+		wantComponents map[string]float64
+		wantErr        bool
+	}{
+		{
+			name:           "LibreHardwareMonitor sensors",
+			input:          sampleLHMSensors,
+			wantWatts:      74,
+			wantComponents: map[string]float64{ComponentCPU: 28.5, ComponentGPU: 45.5},
+		},
+		{
+			name:           "LibreHardwareMonitor CPU only",
+			input:          "LHM /amdcpu/0|CPU Package=35.75\r\nLHM /amdcpu/0|CPU Core #1=4.5\r\n",
+			wantWatts:      35.75,
+			wantComponents: map[string]float64{ComponentCPU: 35.75},
+		},
+		{
+			name:           "Energy Meter counters",
+			input:          sampleEnergyMeter,
+			wantWatts:      15.234,
+			wantComponents: map[string]float64{ComponentCPU: 9.12, ComponentGPU: 0.85},
+		},
+		{
+			name:      "Energy Meter package only",
+			input:     "RAPL rapl_package0_pkg=8000\n",
+			wantWatts: 8,
+		},
+		{
+			name:    "Energy Meter without a package",
+			input:   "RAPL rapl_package0_dram=1200\n",
+			wantErr: true,
+		},
+		{
+			name:    "no sensors",
+			input:   "",
+			wantErr: true,
+		},
+		{
+			name:    "unparseable values",
+			input:   "LHM /intelcpu/0|CPU Package=\nLHM /intelcpu/0|CPU Package=n/a\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watts, components, err := parseProcessorPower(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %f", watts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(watts-tt.wantWatts) > 1e-9 {
+				t.Errorf("watts = %f, want %f", watts, tt.wantWatts)
+			}
+			if len(components) != len(tt.wantComponents) {
+				t.Errorf("components = %v, want %v", components, tt.wantComponents)
+			}
+			for name, want := range tt.wantComponents {
+				if math.Abs(components[name]-want) > 1e-9 {
+					t.Errorf("components[%s] = %f, want %f", name, components[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewMonitor_Windows(t *testing.T) {
+	m := NewMonitor()
+	if m == nil {
+		t.Fatal("NewMonitor returned nil")
+	}
+	if _, ok := m.(*WindowsMonitor); !ok {
+		t.Errorf("expected *WindowsMonitor, got %T", m)
+	}
+}
@@ -0,0 +1,200 @@
+package power
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pipeReconnectDelay is how long PipeMonitor waits before reopening its
+// source after a failed open.
+const pipeReconnectDelay = 500 * time.Millisecond
+
+// PipeMonitor reads readings pushed as JSON lines (in the format written by
+// -json) to a named pipe or other file. A background goroutine tails the
+// source and Read returns the most recent reading once; until another line
+// arrives, Read returns ErrNoNewReading rather than repeating it. When the
+// producer closes its end, the source is reopened so a restarted producer
+// can reconnect. Malformed lines are skipped. SetFormat switches to reading
+// lines in a device's own format instead.
+type PipeMonitor struct {
+	path   string
+	open   func() (io.ReadCloser, error)
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	parse     func([]byte) (Reading, error)
+	latest    Reading
+	fresh     bool // latest hasn't been returned by Read yet
+	current   io.ReadCloser
+	malformed int
+}
+
+// NewPipeMonitor creates a monitor that tails the named pipe at path. Call
+// Close to stop tailing.
+func NewPipeMonitor(path string) *PipeMonitor {
+	m := newPipeMonitor(func() (io.ReadCloser, error) { return os.Open(path) })
+	m.path = path
+	return m
+}
+
+// newPipeMonitor creates a monitor that reads lines from sources returned by
+// open, calling it again each time the previous source ends.
+func newPipeMonitor(open func() (io.ReadCloser, error)) *PipeMonitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &PipeMonitor{
+		open:   open,
+		parse:  parseReadingLine,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go m.run(ctx)
+	return m
+}
+
+// Name returns the name of this monitor.
+func (m *PipeMonitor) Name() string {
+	return "pipe"
+}
+
+// IsSupported reports whether the pipe exists.
+func (m *PipeMonitor) IsSupported() bool {
+	if m.path == "" {
+		return true
+	}
+	_, err := os.Stat(m.path)
+	return err == nil
+}
+
+// Read returns the most recent reading received from the producer, or
+// ErrNoNewReading if no line has arrived since the last Read.
+func (m *PipeMonitor) Read(ctx context.Context) (Reading, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.fresh {
+		return Reading{BatteryPercent: -1, Source: m.Name()}, ErrNoNewReading
+	}
+	m.fresh = false
+	return m.latest, nil
+}
+
+// SetFormat sets the format of the lines pushed to the pipe, one of
+// PipeFormats. The default is PipeFormatJSON.
+func (m *PipeMonitor) SetFormat(format string) error {
+	parse, ok := lineParsers[format]
+	if !ok {
+		return fmt.Errorf("unknown pipe format %q (choose from %s)", format, strings.Join(PipeFormats(), ", "))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parse = parse
+	return nil
+}
+
+// Malformed returns how many lines could not be parsed.
+func (m *PipeMonitor) Malformed() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.malformed
+}
+
+// Close stops tailing the source. If no producer is connected, the tailing
+// goroutine exits once one connects.
+func (m *PipeMonitor) Close() error {
+	m.cancel()
+	m.mu.Lock()
+	if m.current != nil {
+		m.current.Close()
+	}
+	m.mu.Unlock()
+	return nil
+}
+
+// run opens the source and consumes it until ctx is canceled, reopening it
+// whenever the producer disconnects.
+func (m *PipeMonitor) run(ctx context.Context) {
+	defer close(m.done)
+	for ctx.Err() == nil {
+		// Opening a named pipe blocks until a producer connects
+		r, err := m.open()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pipeReconnectDelay):
+			}
+			continue
+		}
+
+		m.mu.Lock()
+		if ctx.Err() != nil {
+			m.mu.Unlock()
+			r.Close()
+			return
+		}
+		m.current = r
+		m.mu.Unlock()
+
+		m.consume(r)
+
+		m.mu.Lock()
+		m.current = nil
+		m.mu.Unlock()
+		r.Close()
+	}
+}
+
+// consume reads lines from r until it ends, recording each parsed reading.
+// Lines without a power figure, such as the rest of ipmitool's output, are
+// ignored.
+func (m *PipeMonitor) consume(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		m.mu.Lock()
+		parse := m.parse
+		m.mu.Unlock()
+		reading, err := parse(line)
+
+		m.mu.Lock()
+		switch {
+		case errors.Is(err, ErrNoData):
+			// No power figure on this line
+		case err != nil:
+			m.malformed++
+		default:
+			m.latest = reading
+			m.fresh = true
+		}
+		m.mu.Unlock()
+	}
+}
+
+// parseReadingLine parses one JSON line into a Reading. Missing fields get
+// the same defaults monitors use: no battery, stamped now, source "pipe".
+// Watts count as measured unless the line sets watts_available to false.
+func parseReadingLine(line []byte) (Reading, error) {
+	reading := Reading{BatteryPercent: -1, WattsAvailable: true}
+	if err := json.Unmarshal(line, &reading); err != nil {
+		return Reading{}, fmt.Errorf("parsing reading: %w", err)
+	}
+	if reading.Timestamp.IsZero() {
+		reading.Timestamp = time.Now()
+	}
+	if reading.Source == "" {
+		reading.Source = "pipe"
+	}
+	return reading, nil
+}
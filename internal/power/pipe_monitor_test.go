@@ -0,0 +1,204 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// pipeSource hands out io.Pipe readers to a PipeMonitor, one per connection.
+type pipeSource struct {
+	readers chan io.ReadCloser
+}
+
+func newPipeSource() *pipeSource {
+	return &pipeSource{readers: make(chan io.ReadCloser)}
+}
+
+// open blocks until connect is called, like opening a named pipe.
+func (s *pipeSource) open() (io.ReadCloser, error) {
+	return <-s.readers, nil
+}
+
+// connect starts a new producer connection and returns its write end.
+func (s *pipeSource) connect() *io.PipeWriter {
+	r, w := io.Pipe()
+	s.readers <- r
+	return w
+}
+
+// waitForWatts polls m until it reports watts or the deadline passes.
+func waitForWatts(t *testing.T, m *PipeMonitor, watts float64) Reading {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if r, err := m.Read(context.Background()); err == nil && r.Watts == watts {
+			return r
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for a %.1fW reading", watts)
+	return Reading{}
+}
+
+func TestPipeMonitor(t *testing.T) {
+	t.Run("implements Monitor interface", func(t *testing.T) {
+		var _ Monitor = &PipeMonitor{}
+	})
+
+	t.Run("no data before first line", func(t *testing.T) {
+		m := newPipeMonitor(newPipeSource().open)
+		defer m.Close()
+
+		if _, err := m.Read(context.Background()); !errors.Is(err, ErrNoData) {
+			t.Errorf("expected ErrNoData, got %v", err)
+		}
+	})
+
+	t.Run("emits each line as a reading", func(t *testing.T) {
+		src := newPipeSource()
+		m := newPipeMonitor(src.open)
+		defer m.Close()
+
+		w := src.connect()
+		io.WriteString(w, `{"watts":12.5,"battery_percent":80,"is_charging":true,"source":"ups"}`+"\n")
+		r := waitForWatts(t, m, 12.5)
+		if r.BatteryPercent != 80 || !r.IsCharging || r.Source != "ups" {
+			t.Errorf("unexpected reading: %+v", r)
+		}
+
+		io.WriteString(w, `{"watts":15}`+"\n")
+		r = waitForWatts(t, m, 15)
+		if r.BatteryPercent != -1 || r.Source != "pipe" || r.Timestamp.IsZero() {
+			t.Errorf("expected defaults for missing fields, got %+v", r)
+		}
+		w.Close()
+	})
+
+	t.Run("no data until the next line", func(t *testing.T) {
+		src := newPipeSource()
+		m := newPipeMonitor(src.open)
+		defer m.Close()
+
+		w := src.connect()
+		io.WriteString(w, `{"watts":9}`+"\n")
+		waitForWatts(t, m, 9)
+
+		if r, err := m.Read(context.Background()); !errors.Is(err, ErrNoNewReading) {
+			t.Errorf("expected ErrNoNewReading for a repeated read, got %+v, %v", r, err)
+		}
+
+		io.WriteString(w, `{"watts":10}`+"\n")
+		waitForWatts(t, m, 10)
+		w.Close()
+	})
+
+	t.Run("skips malformed lines", func(t *testing.T) {
+		src := newPipeSource()
+		m := newPipeMonitor(src.open)
+		defer m.Close()
+
+		w := src.connect()
+		io.WriteString(w, "not json\n\n{\"watts\":\n{\"watts\":7}\n")
+		waitForWatts(t, m, 7)
+		if got := m.Malformed(); got != 2 {
+			t.Errorf("Malformed() = %d, want 2", got)
+		}
+		w.Close()
+	})
+
+	t.Run("reads lines in a device format", func(t *testing.T) {
+		src := newPipeSource()
+		m := newPipeMonitor(src.open)
+		defer m.Close()
+		if err := m.SetFormat(PipeFormatIPMI); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		w := src.connect()
+		io.WriteString(w, sampleIPMIPower)
+		r := waitForWatts(t, m, 220)
+		if r.Source != "ipmi" {
+			t.Errorf("expected an ipmi reading, got %+v", r)
+		}
+		// The rest of ipmitool's output has no power figure to parse
+		if got := m.Malformed(); got != 0 {
+			t.Errorf("Malformed() = %d, want 0", got)
+		}
+		w.Close()
+	})
+
+	t.Run("rejects an unknown format", func(t *testing.T) {
+		m := newPipeMonitor(newPipeSource().open)
+		defer m.Close()
+		if err := m.SetFormat("xml"); err == nil {
+			t.Error("expected an error for an unknown format")
+		}
+	})
+
+	t.Run("reconnects after producer closes", func(t *testing.T) {
+		src := newPipeSource()
+		m := newPipeMonitor(src.open)
+		defer m.Close()
+
+		w := src.connect()
+		io.WriteString(w, `{"watts":5}`+"\n")
+		waitForWatts(t, m, 5)
+		w.Close()
+
+		w = src.connect()
+		io.WriteString(w, `{"watts":6}`+"\n")
+		waitForWatts(t, m, 6)
+		w.Close()
+	})
+
+	t.Run("close stops tailing", func(t *testing.T) {
+		src := newPipeSource()
+		m := newPipeMonitor(src.open)
+
+		w := src.connect()
+		io.WriteString(w, `{"watts":5}`+"\n")
+		waitForWatts(t, m, 5)
+		m.Close()
+
+		select {
+		case <-m.done:
+		case <-time.After(time.Second):
+			t.Fatal("expected tailing goroutine to exit after Close")
+		}
+	})
+}
+
+func TestParseReadingLine(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, err := parseReadingLine([]byte(`{"watts":9.5,"timestamp":"2024-01-02T03:04:05Z","is_on_battery":true}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Watts != 9.5 || !r.Timestamp.Equal(ts) || !r.IsOnBattery || !r.WattsAvailable {
+		t.Errorf("unexpected reading: %+v", r)
+	}
+
+	r, err = parseReadingLine([]byte(`{"watts":0,"watts_available":false}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.WattsAvailable {
+		t.Error("expected watts_available=false to be kept")
+	}
+
+	// A smart plug bridge pushing apparent power and power factor
+	r, err = parseReadingLine([]byte(`{"watts":110.2,"apparent_va":119.8,"power_factor":0.92,"source":"shelly-plug"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.ApparentVA != 119.8 || r.PowerFactor != 0.92 || r.Source != "shelly-plug" {
+		t.Errorf("expected apparent power and power factor, got %+v", r)
+	}
+
+	if _, err := parseReadingLine([]byte(`{"watts":"lots"}`)); err == nil {
+		t.Error("expected error for invalid watts")
+	}
+}
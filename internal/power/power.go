@@ -3,28 +3,235 @@ package power
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
 	"time"
 )
 
 // Reading represents a single power consumption measurement.
 type Reading struct {
 	// Watts is the current power consumption in watts.
-	Watts float64
+	Watts float64 `json:"watts"`
+
+	// WattsAvailable reports whether Watts was actually measured. When false,
+	// Watts is 0 because no source could provide a value (e.g. a desktop Mac
+	// without sudo), not because the system drew no power.
+	WattsAvailable bool `json:"watts_available"`
 
 	// Timestamp is when this reading was taken.
-	Timestamp time.Time
+	Timestamp time.Time `json:"timestamp"`
 
 	// IsOnBattery indicates if the device is running on battery power.
-	IsOnBattery bool
+	IsOnBattery bool `json:"is_on_battery"`
 
 	// BatteryPercent is the current battery percentage (0-100), or -1 if not available.
-	BatteryPercent float64
+	BatteryPercent float64 `json:"battery_percent"`
 
 	// IsCharging indicates if the battery is currently charging.
-	IsCharging bool
+	IsCharging bool `json:"is_charging"`
+
+	// CapacityNow is the energy currently stored in the battery, in CapacityUnit, or 0 if unknown.
+	CapacityNow float64 `json:"capacity_now,omitempty"`
+
+	// CapacityFull is the battery's current full-charge capacity, in CapacityUnit, or 0 if unknown.
+	CapacityFull float64 `json:"capacity_full,omitempty"`
+
+	// CapacityDesign is the battery's original design capacity, in CapacityUnit, or 0 if unknown.
+	CapacityDesign float64 `json:"capacity_design,omitempty"`
+
+	// CapacityUnit is the unit of the capacity fields, either "mAh" or "Wh".
+	CapacityUnit string `json:"capacity_unit,omitempty"`
+
+	// BatteryHealthPercent is CapacityFull as a percentage of CapacityDesign,
+	// i.e. how much of its original capacity the battery has kept, or 0 if
+	// unknown.
+	BatteryHealthPercent float64 `json:"battery_health_percent,omitempty"`
+
+	// CycleCount is how many charge cycles the battery has been through, a
+	// measure of its wear, or 0 if unknown.
+	CycleCount int `json:"cycle_count,omitempty"`
+
+	// AdapterMaxWatts is the rated wattage of the connected charger, e.g. 67
+	// for a 67W adapter, or 0 if unknown or on battery.
+	AdapterMaxWatts float64 `json:"adapter_max_watts,omitempty"`
+
+	// TimeRemaining is how long the battery should last at the current draw
+	// while discharging, or 0 if unknown or not discharging.
+	TimeRemaining time.Duration `json:"time_remaining,omitempty"`
+
+	// Temperature is the battery temperature in degrees Celsius, or 0 if unknown.
+	Temperature float64 `json:"temperature,omitempty"`
+
+	// ApparentVA is the apparent power in volt-amperes, or 0 if the source
+	// doesn't report it. Smart plugs and PDUs measuring AC power often do.
+	ApparentVA float64 `json:"apparent_va,omitempty"`
+
+	// PowerFactor is real over apparent power (0-1), or 0 if unknown.
+	PowerFactor float64 `json:"power_factor,omitempty"`
+
+	// PowerKind says what Watts measures, e.g. the whole system's draw or
+	// only the rate the battery is charging at. Empty if unknown.
+	PowerKind PowerKind `json:"power_kind,omitempty"`
+
+	// CPUFreqMHz is the CPU clock speed in MHz while the reading was taken,
+	// or 0 if unknown. Only macOS powermetrics reports it.
+	CPUFreqMHz float64 `json:"cpu_freq_mhz,omitempty"`
+
+	// Components breaks power down by hardware component (see the Component
+	// constants), in watts, when the platform reports it.
+	Components map[string]float64 `json:"components,omitempty"`
 
 	// Source describes where this reading came from (e.g., "macOS-ioreg", "linux-sysfs").
-	Source string
+	Source string `json:"source"`
+
+	// Seq numbers readings in the order History.Add received them, from 1,
+	// so gaps show readings that were dropped along the way. It is 0 for a
+	// reading that hasn't been added to a History.
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// Component names used as keys in Reading.Components.
+const (
+	ComponentCPU         = "cpu"
+	ComponentGPU         = "gpu"
+	ComponentANE         = "ane"
+	ComponentDiscreteGPU = "dgpu"
+)
+
+// PowerKind classifies what a reading's watts measure.
+type PowerKind string
+
+// Kinds of power a reading can measure.
+const (
+	// PowerKindSystem is the system's own draw, e.g. from a power meter or
+	// the hardware's load telemetry.
+	PowerKindSystem PowerKind = "system"
+	// PowerKindPackage is the processor package's draw, e.g. from Intel
+	// Power Gadget, which leaves out the display, storage and the rest of
+	// the system.
+	PowerKindPackage PowerKind = "package"
+	// PowerKindAdapter is what the AC adapter supplies, which includes any
+	// power going into the battery.
+	PowerKindAdapter PowerKind = "adapter"
+	// PowerKindCharge is the rate the battery is charging at.
+	PowerKindCharge PowerKind = "charge"
+	// PowerKindDischarge is the rate the battery is draining at.
+	PowerKindDischarge PowerKind = "discharge"
+)
+
+// batteryPowerKind classifies battery power in r as charge or discharge.
+func batteryPowerKind(r Reading) PowerKind {
+	if r.IsCharging {
+		return PowerKindCharge
+	}
+	return PowerKindDischarge
+}
+
+// batteryHealthPercent returns r's full-charge capacity as a percentage of
+// its design capacity, or 0 if either is unknown.
+func batteryHealthPercent(r Reading) float64 {
+	if r.CapacityFull <= 0 || r.CapacityDesign <= 0 {
+		return 0
+	}
+	return r.CapacityFull / r.CapacityDesign * 100
+}
+
+// DefaultMaxWatts is the highest plausible reading Sanitize allows by
+// default. Nothing powermon monitors draws more, so anything above it is a
+// misparsed value.
+const DefaultMaxWatts = 1000
+
+// Sanitize returns r with implausible values clamped so one bad parse can't
+// skew stats or the graph's scale. Watts is clamped to 0..maxWatts (or
+// DefaultMaxWatts if maxWatts <= 0), BatteryPercent to -1..100 and
+// TimeRemaining to be non-negative. NaN and
+// infinite values are zeroed, except a NaN BatteryPercent, which becomes -1
+// (unknown), and NaN or infinite watts, which also mark the reading
+// unavailable.
+func (r Reading) Sanitize(maxWatts float64) Reading {
+	if maxWatts <= 0 {
+		maxWatts = DefaultMaxWatts
+	}
+
+	if !isFinite(r.Watts) {
+		r.Watts = 0
+		r.WattsAvailable = false
+	}
+	r.Watts = min(maxWatts, max(0, r.Watts))
+
+	if math.IsNaN(r.BatteryPercent) {
+		r.BatteryPercent = -1
+	}
+	r.BatteryPercent = min(100, max(-1, r.BatteryPercent))
+	r.TimeRemaining = max(0, r.TimeRemaining)
+
+	for _, v := range []*float64{
+		&r.CapacityNow, &r.CapacityFull, &r.CapacityDesign, &r.BatteryHealthPercent,
+		&r.Temperature, &r.ApparentVA, &r.PowerFactor, &r.CPUFreqMHz,
+	} {
+		if !isFinite(*v) {
+			*v = 0
+		}
+	}
+
+	// The components map may be shared with the monitor, so fix a copy
+	for _, w := range r.Components {
+		if !isFinite(w) {
+			r.Components = finiteComponents(r.Components)
+			break
+		}
+	}
+	return r
+}
+
+// finiteComponents returns a copy of components with NaN and infinite
+// values zeroed.
+func finiteComponents(components map[string]float64) map[string]float64 {
+	fixed := make(map[string]float64, len(components))
+	for name, w := range components {
+		if !isFinite(w) {
+			w = 0
+		}
+		fixed[name] = w
+	}
+	return fixed
+}
+
+// isFinite reports whether v is neither NaN nor infinite.
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// Errors monitors return, usually wrapped, to explain why a reading has no
+// power data. Check for them with errors.Is.
+var (
+	// ErrNoData is returned when a monitor never produced a usable reading.
+	ErrNoData = errors.New("no power data available")
+	// ErrNeedsPrivilege is returned when power data needs more privileges
+	// than the process has, such as running with sudo.
+	ErrNeedsPrivilege = errors.New("power data needs elevated privileges")
+	// ErrUnsupported is returned when the system has no way to report power.
+	ErrUnsupported = errors.New("power monitoring is not supported")
+	// ErrLogWrite is returned when a reading was taken but a wrapper such as
+	// CSVMonitor couldn't write it out. The reading returned with it is
+	// still valid.
+	ErrLogWrite = errors.New("writing reading")
+	// ErrNoNewReading is returned by monitors fed from outside, such as
+	// PipeMonitor, when nothing has arrived since the last Read. It wraps
+	// ErrNoData, but loops reading on a ticker skip the tick rather than
+	// report it.
+	ErrNoNewReading = fmt.Errorf("%w: nothing new since the last read", ErrNoData)
+)
+
+// ReadFailed reports whether a Read that returned err has no reading to use.
+// It is false for errors wrapping ErrLogWrite, which come with a valid
+// reading.
+func ReadFailed(err error) bool {
+	return err != nil && !errors.Is(err, ErrLogWrite)
 }
 
 // Monitor provides power consumption readings.
@@ -40,91 +247,358 @@ type Monitor interface {
 	Name() string
 }
 
+// Close releases any resources m holds, such as an open stream, if it
+// implements io.Closer, and does nothing otherwise. Monitors that wrap
+// another one forward Close to it.
+func Close(m Monitor) error {
+	if closer, ok := m.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// NeedsSudo reports whether m needs elevated privileges to read power. It is
+// false for monitors that can't tell. Monitors that wrap another one forward
+// NeedsSudo to it.
+func NeedsSudo(m Monitor) bool {
+	if checker, ok := m.(interface{ NeedsSudo() bool }); ok {
+		return checker.NeedsSudo()
+	}
+	return false
+}
+
+// SampleCounter is an optional interface for monitors that can average
+// several hardware samples into a single reading.
+type SampleCounter interface {
+	SetSampleCount(n int)
+}
+
+// Power metrics a PowerMetricSelector can report.
+const (
+	// PowerMetricAdapter prefers adapter input power, which includes
+	// charging overhead while plugged in.
+	PowerMetricAdapter = "adapter"
+	// PowerMetricSystem prefers the system's own load, leaving out power
+	// that goes into charging the battery.
+	PowerMetricSystem = "system"
+	// PowerMetricBattery prefers battery power, even when plugged in.
+	PowerMetricBattery = "battery"
+)
+
+// PowerMetricSelector is an optional interface for monitors that can report
+// more than one power figure.
+type PowerMetricSelector interface {
+	SetPowerMetric(metric string) error
+}
+
+// ComponentPreferrer is an optional interface for monitors that can switch to
+// a source reporting per-component power in Reading.Components, even where
+// they would otherwise use one that doesn't. PreferComponents reports
+// whether such a source is available.
+type ComponentPreferrer interface {
+	PreferComponents() bool
+}
+
+// Streamer is an optional interface for monitors that can keep one sampling
+// process running, reading its latest sample, instead of starting a new one
+// for every reading. Calling StartStreaming again with a new interval
+// restarts the process at that rate. Close stops it.
+type Streamer interface {
+	StartStreaming(interval time.Duration) error
+}
+
+// IntervalSetter is an optional interface for monitors that sample over a
+// stretch of time on every read, which they size from the refresh interval
+// so a read finishes in time for the next one.
+type IntervalSetter interface {
+	SetInterval(interval time.Duration)
+}
+
+// DefaultEMAAlpha is the weight History gives each new reading in its
+// exponential moving average of watts.
+const DefaultEMAAlpha = 0.3
+
 // History stores a rolling window of power readings for trend analysis.
+// It is safe for concurrent use.
 type History struct {
-	readings   []Reading
+	mu sync.RWMutex
+
+	// buf is a ring of maxSize readings stored twice over: each reading is
+	// written to its slot and to the slot maxSize past it, so the count
+	// readings from start are always contiguous and in order, and Add never
+	// has to move or reallocate them. See view.
+	buf        []Reading
+	start      int
+	count      int
 	maxSize    int
 	windowSize time.Duration
+
+	// ema is the exponential moving average of watts, updated by Add
+	emaAlpha float64
+	ema      float64
+	hasEMA   bool
+
+	// seq is the sequence number of the last reading added
+	seq uint64
 }
 
 // NewHistory creates a new History with the specified maximum size and time window.
 func NewHistory(maxSize int, windowSize time.Duration) *History {
 	return &History{
-		readings:   make([]Reading, 0, maxSize),
+		buf:        make([]Reading, 2*max(maxSize, 0)),
 		maxSize:    maxSize,
 		windowSize: windowSize,
+		emaAlpha:   DefaultEMAAlpha,
+	}
+}
+
+// SetEMAAlpha sets the weight given to each new reading in the exponential
+// moving average, from just above 0 (steadiest) to 1 (the latest reading).
+// Values outside that range use DefaultEMAAlpha. The current average is kept.
+func (h *History) SetEMAAlpha(alpha float64) {
+	if alpha <= 0 || alpha > 1 || math.IsNaN(alpha) {
+		alpha = DefaultEMAAlpha
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.emaAlpha = alpha
+}
+
+// EMA returns the exponential moving average of watts across every reading
+// added so far, a steadier figure than the latest reading. Unlike the other
+// stats it isn't limited to the time window. It returns 0 if nothing has
+// been added.
+func (h *History) EMA() float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.ema
+}
+
+// Seq returns the sequence number of the last reading added, or 0 if none
+// has been added since the history was created or cleared.
+func (h *History) Seq() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.seq
+}
+
+// updateEMA folds watts into the exponential moving average, which starts
+// at the first reading. The caller must hold the write lock.
+func (h *History) updateEMA(watts float64) {
+	if !h.hasEMA {
+		h.ema = watts
+		h.hasEMA = true
+		return
 	}
+	h.ema += h.emaAlpha * (watts - h.ema)
 }
 
 // Add adds a new reading to the history, removing old readings outside the time window.
+// The stored reading's Seq is set to the next sequence number.
 func (h *History) Add(r Reading) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	// Remove readings outside the time window
 	h.prune(r.Timestamp)
 
 	// Add the new reading
-	h.readings = append(h.readings, r)
+	h.seq++
+	r.Seq = h.seq
+	h.push(r)
+	h.updateEMA(r.Watts)
+}
+
+// AddAll adds a batch of readings, oldest first, like calling Add for each
+// but pruning the time window once, against the last reading. Readings that
+// the size limit would push straight back out are only counted towards the
+// sequence numbers and moving average. For readings in timestamp order the
+// result is the same as adding them one at a time.
+func (h *History) AddAll(readings []Reading) {
+	if len(readings) == 0 {
+		return
+	}
 
-	// If we exceed max size, remove the oldest
-	if len(h.readings) > h.maxSize {
-		h.readings = h.readings[1:]
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	skip := len(readings) - max(h.maxSize, 0)
+	last := len(readings) - 1
+	for i, r := range readings {
+		h.seq++
+		h.updateEMA(r.Watts)
+		if i == last {
+			// Prune before storing the last reading, as Add would, so it's
+			// kept even with a window of zero
+			h.prune(r.Timestamp)
+		}
+		if i >= skip {
+			r.Seq = h.seq
+			h.push(r)
+		}
 	}
 }
 
+// push stores r as the newest reading, overwriting the oldest if the history
+// is full. The caller must hold the write lock.
+func (h *History) push(r Reading) {
+	if h.maxSize <= 0 {
+		return
+	}
+	if h.count == h.maxSize {
+		h.drop(1)
+	}
+	i := (h.start + h.count) % h.maxSize
+	h.buf[i] = r
+	h.buf[i+h.maxSize] = r
+	h.count++
+}
+
+// drop removes the n oldest readings. The caller must hold the write lock.
+func (h *History) drop(n int) {
+	if n <= 0 {
+		return
+	}
+	h.start = (h.start + n) % h.maxSize
+	h.count -= n
+}
+
+// view returns the stored readings, oldest first, as a slice of buf rather
+// than a copy. The caller must hold the lock and must not modify or keep it.
+func (h *History) view() []Reading {
+	return h.buf[h.start : h.start+h.count]
+}
+
+// PruneNow removes readings that are older than the time window as of now.
+// Add only prunes relative to the reading being added, so a monitor that
+// stops producing readings would otherwise leave stale ones behind.
+func (h *History) PruneNow(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.prune(now)
+}
+
+// Resize changes the maximum size and time window. Shrinking drops the
+// oldest readings that no longer fit, judging age against the newest
+// reading; growing keeps every reading.
+func (h *History) Resize(maxSize int, window time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	readings := h.view()
+	if len(readings) > maxSize {
+		readings = readings[len(readings)-max(maxSize, 0):]
+	}
+	buf := make([]Reading, 2*max(maxSize, 0))
+	copy(buf, readings)
+	copy(buf[max(maxSize, 0):], readings)
+
+	h.buf, h.start, h.count = buf, 0, len(readings)
+	h.maxSize = maxSize
+	h.windowSize = window
+	if h.count > 0 {
+		h.prune(h.buf[h.count-1].Timestamp)
+	}
+}
+
+// Window returns the time window readings are kept for.
+func (h *History) Window() time.Duration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.windowSize
+}
+
 // prune removes readings that are older than the time window.
+// The caller must hold the write lock.
 func (h *History) prune(now time.Time) {
 	cutoff := now.Add(-h.windowSize)
-	startIdx := 0
-	for i, r := range h.readings {
+	expired := 0
+	for _, r := range h.view() {
 		if r.Timestamp.After(cutoff) {
-			startIdx = i
 			break
 		}
-		startIdx = i + 1
-	}
-	if startIdx > 0 && startIdx <= len(h.readings) {
-		h.readings = h.readings[startIdx:]
+		expired++
 	}
+	h.drop(expired)
 }
 
 // Readings returns a copy of all current readings.
 func (h *History) Readings() []Reading {
-	result := make([]Reading, len(h.readings))
-	copy(result, h.readings)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	readings := h.view()
+	result := make([]Reading, len(readings))
+	copy(result, readings)
 	return result
 }
 
 // Len returns the number of readings in history.
 func (h *History) Len() int {
-	return len(h.readings)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.count
 }
 
 // Latest returns the most recent reading, or an empty Reading if history is empty.
 func (h *History) Latest() (Reading, bool) {
-	if len(h.readings) == 0 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.count == 0 {
 		return Reading{}, false
 	}
-	return h.readings[len(h.readings)-1], true
+	return h.view()[h.count-1], true
+}
+
+// LatestWatts returns the watts of the most recent reading, or 0 if history
+// is empty, for embedders that only need the figure. The UI shows the last
+// reading it accepted instead, which survives clearing the history.
+func (h *History) LatestWatts() float64 {
+	latest, _ := h.Latest()
+	return latest.Watts
 }
 
 // Average returns the average power consumption over the stored readings.
 func (h *History) Average() float64 {
-	if len(h.readings) == 0 {
+	return h.AverageOver(0)
+}
+
+// AverageOver returns the average power consumption over the readings within
+// d of the newest one. A d of zero or less covers all stored readings.
+func (h *History) AverageOver(d time.Duration) float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	readings := h.recent(d)
+	if len(readings) == 0 {
 		return 0
 	}
 	var sum float64
-	for _, r := range h.readings {
+	for _, r := range readings {
 		sum += r.Watts
 	}
-	return sum / float64(len(h.readings))
+	return sum / float64(len(readings))
 }
 
 // Min returns the minimum power reading in the history.
 func (h *History) Min() float64 {
-	if len(h.readings) == 0 {
+	return h.MinOver(0)
+}
+
+// MinOver returns the minimum power reading within d of the newest one. A d
+// of zero or less covers all stored readings.
+func (h *History) MinOver(d time.Duration) float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	readings := h.recent(d)
+	if len(readings) == 0 {
 		return 0
 	}
-	minVal := h.readings[0].Watts
-	for _, r := range h.readings[1:] {
+	minVal := readings[0].Watts
+	for _, r := range readings[1:] {
 		if r.Watts < minVal {
 			minVal = r.Watts
 		}
@@ -134,11 +608,21 @@ func (h *History) Min() float64 {
 
 // Max returns the maximum power reading in the history.
 func (h *History) Max() float64 {
-	if len(h.readings) == 0 {
+	return h.MaxOver(0)
+}
+
+// MaxOver returns the maximum power reading within d of the newest one. A d
+// of zero or less covers all stored readings.
+func (h *History) MaxOver(d time.Duration) float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	readings := h.recent(d)
+	if len(readings) == 0 {
 		return 0
 	}
-	maxVal := h.readings[0].Watts
-	for _, r := range h.readings[1:] {
+	maxVal := readings[0].Watts
+	for _, r := range readings[1:] {
 		if r.Watts > maxVal {
 			maxVal = r.Watts
 		}
@@ -146,20 +630,188 @@ func (h *History) Max() float64 {
 	return maxVal
 }
 
+// recent returns the stored readings within d of the newest one, or all of
+// them if d is zero or less. The caller must hold the read lock.
+func (h *History) recent(d time.Duration) []Reading {
+	readings := h.view()
+	if d <= 0 || len(readings) == 0 {
+		return readings
+	}
+	cutoff := readings[len(readings)-1].Timestamp.Add(-d)
+	start := sort.Search(len(readings), func(i int) bool {
+		return !readings[i].Timestamp.Before(cutoff)
+	})
+	return readings[start:]
+}
+
+// Variance returns the population variance of the stored readings' watts.
+// It is computed in a single pass with Welford's algorithm, which stays
+// accurate when readings are large relative to their spread. Fewer than two
+// readings return 0.
+func (h *History) Variance() float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	readings := h.view()
+	if len(readings) < 2 {
+		return 0
+	}
+	var mean, m2 float64
+	for i, r := range readings {
+		delta := r.Watts - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (r.Watts - mean)
+	}
+	return m2 / float64(len(readings))
+}
+
+// StdDev returns the population standard deviation of the stored readings'
+// watts, i.e. how much the power draw jitters around its average.
+func (h *History) StdDev() float64 {
+	return math.Sqrt(h.Variance())
+}
+
 // Trend calculates the trend direction: positive means increasing consumption,
 // negative means decreasing, near zero means stable.
-// Uses a simple linear regression slope.
+// Uses a simple linear regression slope, in watts per reading; see
+// TrendPerMinute for a rate that doesn't depend on the refresh interval.
 func (h *History) Trend() float64 {
-	n := len(h.readings)
+	return h.TrendOver(0)
+}
+
+// TrendOver calculates the trend over the readings within d of the newest
+// one, like Trend. A d of zero or less covers all stored readings.
+func (h *History) TrendOver(d time.Duration) float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return regressionSlope(h.recent(d), func(i int, _ Reading) float64 {
+		return float64(i)
+	}, readingWatts)
+}
+
+// TrendPerMinute calculates the trend like Trend, but in watts per minute
+// using the readings' timestamps, so the same change in power gives the same
+// rate whatever the refresh interval.
+func (h *History) TrendPerMinute() float64 {
+	return h.TrendPerMinuteOver(0)
+}
+
+// TrendPerMinuteOver calculates the trend over the readings within d of the
+// newest one, like TrendPerMinute. A d of zero or less covers all stored
+// readings.
+func (h *History) TrendPerMinuteOver(d time.Duration) float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return trendPerMinute(h.recent(d))
+}
+
+// trendPerMinute returns the regression slope of readings' watts against
+// their timestamps, in watts per minute.
+func trendPerMinute(readings []Reading) float64 {
+	if len(readings) == 0 {
+		return 0
+	}
+	first := readings[0].Timestamp
+	return regressionSlope(readings, func(_ int, r Reading) float64 {
+		return r.Timestamp.Sub(first).Minutes()
+	}, readingWatts)
+}
+
+// BatteryDrainRatePerHour estimates how fast the battery is draining, in
+// percentage points per hour, from the regression slope of BatteryPercent
+// against time over the readings since the system last went on battery. It
+// returns 0 when the latest reading isn't on battery, when fewer than two
+// readings report a battery percentage, or when the battery isn't draining.
+func (h *History) BatteryDrainRatePerHour() float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	// Only the current stretch on battery; a charge in between would flatten
+	// the slope
+	readings := h.view()
+	start := len(readings)
+	for start > 0 {
+		r := readings[start-1]
+		if !r.IsOnBattery || r.BatteryPercent < 0 {
+			break
+		}
+		start--
+	}
+	readings = readings[start:]
+	if len(readings) == 0 {
+		return 0
+	}
+
+	first := readings[0].Timestamp
+	slope := regressionSlope(readings, func(_ int, r Reading) float64 {
+		return r.Timestamp.Sub(first).Hours()
+	}, func(r Reading) float64 {
+		return r.BatteryPercent
+	})
+	return max(0, -slope)
+}
+
+// Stats summarizes the power in a History's readings.
+type Stats struct {
+	Average float64
+	Min     float64
+	Max     float64
+	// TrendPerMinute is the change in watts per minute; see
+	// History.TrendPerMinute.
+	TrendPerMinute float64
+	// Samples is how many readings the stats cover.
+	Samples int
+}
+
+// StatsOver returns the average, min, max and trend over the readings within
+// d of the newest one, all taken from the same readings. A d of zero or less
+// covers all stored readings. An empty history returns zero Stats.
+func (h *History) StatsOver(d time.Duration) Stats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	readings := h.recent(d)
+	if len(readings) == 0 {
+		return Stats{}
+	}
+
+	stats := Stats{
+		Min:            readings[0].Watts,
+		Max:            readings[0].Watts,
+		TrendPerMinute: trendPerMinute(readings),
+		Samples:        len(readings),
+	}
+	var sum float64
+	for _, r := range readings {
+		sum += r.Watts
+		stats.Min = min(stats.Min, r.Watts)
+		stats.Max = max(stats.Max, r.Watts)
+	}
+	stats.Average = sum / float64(len(readings))
+	return stats
+}
+
+// readingWatts returns r's watts, the y value regressionSlope usually fits.
+func readingWatts(r Reading) float64 {
+	return r.Watts
+}
+
+// regressionSlope returns the least-squares slope of the y value yOf gives
+// each reading against the x value xOf gives it, or 0 with fewer than two
+// distinct x values.
+func regressionSlope(readings []Reading, xOf func(int, Reading) float64, yOf func(Reading) float64) float64 {
+	n := len(readings)
 	if n < 2 {
 		return 0
 	}
 
 	// Simple linear regression: calculate slope
 	var sumX, sumY, sumXY, sumX2 float64
-	for i, r := range h.readings {
-		x := float64(i)
-		y := r.Watts
+	for i, r := range readings {
+		x := xOf(i, r)
+		y := yOf(r)
 		sumX += x
 		sumY += y
 		sumXY += x * y
@@ -176,7 +828,61 @@ func (h *History) Trend() float64 {
 	return slope
 }
 
-// Clear removes all readings from history.
+// EnergyWattHours returns the total energy consumed across the stored readings
+// in watt-hours. It integrates watts over time using the trapezoidal rule
+// between adjacent readings, so gaps left by pruning do not contribute.
+func (h *History) EnergyWattHours() float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	readings := h.view()
+	if len(readings) < 2 {
+		return 0
+	}
+
+	var wattSeconds float64
+	for i := 1; i < len(readings); i++ {
+		wattSeconds += trapezoidWattSeconds(readings[i-1], readings[i])
+	}
+
+	return wattSeconds / 3600.0
+}
+
+// CumulativeEnergy returns the running energy total in watt-hours at each
+// stored reading. The first value is always 0 and the series never decreases.
+func (h *History) CumulativeEnergy() []float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	readings := h.view()
+	result := make([]float64, len(readings))
+	var wattSeconds float64
+	for i := 1; i < len(readings); i++ {
+		wattSeconds += trapezoidWattSeconds(readings[i-1], readings[i])
+		result[i] = wattSeconds / 3600.0
+	}
+	return result
+}
+
+// trapezoidWattSeconds returns the energy in watt-seconds between two adjacent
+// readings. Readings that are out of order contribute nothing.
+func trapezoidWattSeconds(prev, cur Reading) float64 {
+	dt := cur.Timestamp.Sub(prev.Timestamp).Seconds()
+	if dt <= 0 {
+		return 0
+	}
+	return (prev.Watts + cur.Watts) / 2 * dt
+}
+
+// Clear removes all readings from history and starts sequence numbers over.
 func (h *History) Clear() {
-	h.readings = h.readings[:0]
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Zero the slots so old readings' Components can be freed
+	clear(h.buf)
+	h.start, h.count = 0, 0
+	h.ema = 0
+	h.hasEMA = false
+	h.seq = 0
 }
@@ -1,6 +1,16 @@
 package power
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -36,6 +46,237 @@ func TestReading(t *testing.T) {
 			t.Errorf("expected Source=test, got %s", r.Source)
 		}
 	})
+
+	t.Run("omits unknown AC fields from JSON", func(t *testing.T) {
+		data, err := json.Marshal(Reading{Watts: 10})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, field := range []string{"apparent_va", "power_factor"} {
+			if strings.Contains(string(data), field) {
+				t.Errorf("expected %s to be omitted, got %s", field, data)
+			}
+		}
+	})
+}
+
+func TestClose(t *testing.T) {
+	t.Run("closes a monitor that implements io.Closer", func(t *testing.T) {
+		mock := NewMockMonitor()
+		if err := Close(mock); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !mock.Closed() {
+			t.Error("expected Close to be called")
+		}
+	})
+
+	t.Run("ignores a monitor without Close", func(t *testing.T) {
+		mock := NewMockMonitor()
+		// Embedding only the Monitor interface hides the mock's Close
+		if err := Close(struct{ Monitor }{mock}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if mock.Closed() {
+			t.Error("expected Close not to reach the hidden monitor")
+		}
+	})
+
+	t.Run("wrappers close the monitor they wrap", func(t *testing.T) {
+		wrappers := map[string]func(Monitor) Monitor{
+			"calibrated": func(m Monitor) Monitor { return NewCalibratedMonitor(m, Calibration{}) },
+			"recording":  func(m Monitor) Monitor { return NewRecordingMonitor(m, &bytes.Buffer{}) },
+			"summary":    func(m Monitor) Monitor { return NewSummaryMonitor(m) },
+			"csv": func(m Monitor) Monitor {
+				csvMonitor, err := NewCSVMonitor(m, &bytes.Buffer{}, false)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return csvMonitor
+			},
+		}
+		for name, wrap := range wrappers {
+			t.Run(name, func(t *testing.T) {
+				mock := NewMockMonitor()
+				if err := Close(wrap(mock)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !mock.Closed() {
+					t.Error("expected the wrapped monitor to be closed")
+				}
+			})
+		}
+	})
+}
+
+func TestReadFailed(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no error", nil, false},
+		{"read error", errors.New("boom"), true},
+		{"wrapped read error", fmt.Errorf("reading: %w", errors.New("boom")), true},
+		{"log write error", fmt.Errorf("%w: disk full", ErrLogWrite), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadFailed(tt.err); got != tt.want {
+				t.Errorf("ReadFailed(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// sudoMonitor is a Monitor that reports whether it needs sudo.
+type sudoMonitor struct {
+	Monitor
+	needsSudo bool
+}
+
+func (m sudoMonitor) NeedsSudo() bool {
+	return m.needsSudo
+}
+
+func TestNeedsSudo(t *testing.T) {
+	t.Run("false for a monitor that can't tell", func(t *testing.T) {
+		if NeedsSudo(NewMockMonitor()) {
+			t.Error("expected false")
+		}
+	})
+
+	t.Run("asks a monitor that can", func(t *testing.T) {
+		if !NeedsSudo(sudoMonitor{Monitor: NewMockMonitor(), needsSudo: true}) {
+			t.Error("expected true")
+		}
+	})
+
+	t.Run("wrappers forward to the monitor they wrap", func(t *testing.T) {
+		m, err := NewCSVMonitor(sudoMonitor{Monitor: NewMockMonitor(), needsSudo: true}, &bytes.Buffer{}, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !NeedsSudo(m) {
+			t.Error("expected the wrapped monitor's answer")
+		}
+	})
+}
+
+func TestBatteryHealthPercent(t *testing.T) {
+	tests := []struct {
+		name    string
+		reading Reading
+		want    float64
+	}{
+		{"faded battery", Reading{CapacityFull: 46, CapacityDesign: 50}, 92},
+		{"new battery", Reading{CapacityFull: 5100, CapacityDesign: 5100}, 100},
+		{"unknown design", Reading{CapacityFull: 46}, 0},
+		{"unknown full", Reading{CapacityDesign: 50}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batteryHealthPercent(tt.reading); got != tt.want {
+				t.Errorf("batteryHealthPercent() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReading_Sanitize(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            Reading
+		maxWatts      float64
+		wantWatts     float64
+		wantAvailable bool
+		wantPercent   float64
+	}{
+		{
+			name:          "plausible reading is unchanged",
+			in:            Reading{Watts: 12.5, WattsAvailable: true, BatteryPercent: 80},
+			wantWatts:     12.5,
+			wantAvailable: true,
+			wantPercent:   80,
+		},
+		{
+			name:          "NaN watts are zeroed and unavailable",
+			in:            Reading{Watts: math.NaN(), WattsAvailable: true, BatteryPercent: math.NaN()},
+			wantWatts:     0,
+			wantAvailable: false,
+			wantPercent:   -1,
+		},
+		{
+			name:          "infinite watts are zeroed and unavailable",
+			in:            Reading{Watts: math.Inf(1), WattsAvailable: true, BatteryPercent: -1},
+			wantWatts:     0,
+			wantAvailable: false,
+			wantPercent:   -1,
+		},
+		{
+			name:          "negative values clamp to the low end",
+			in:            Reading{Watts: -4, WattsAvailable: true, BatteryPercent: -20},
+			wantWatts:     0,
+			wantAvailable: true,
+			wantPercent:   -1,
+		},
+		{
+			name:          "oversized values clamp to the default max",
+			in:            Reading{Watts: 65535, WattsAvailable: true, BatteryPercent: 250},
+			wantWatts:     DefaultMaxWatts,
+			wantAvailable: true,
+			wantPercent:   100,
+		},
+		{
+			name:          "oversized watts clamp to a configured max",
+			in:            Reading{Watts: 150, WattsAvailable: true, BatteryPercent: 50},
+			maxWatts:      100,
+			wantWatts:     100,
+			wantAvailable: true,
+			wantPercent:   50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in.Sanitize(tt.maxWatts)
+			if got.Watts != tt.wantWatts || got.WattsAvailable != tt.wantAvailable {
+				t.Errorf("Watts = %v (available %v), want %v (available %v)",
+					got.Watts, got.WattsAvailable, tt.wantWatts, tt.wantAvailable)
+			}
+			if got.BatteryPercent != tt.wantPercent {
+				t.Errorf("BatteryPercent = %v, want %v", got.BatteryPercent, tt.wantPercent)
+			}
+		})
+	}
+
+	t.Run("zeroes other NaN and infinite fields", func(t *testing.T) {
+		components := map[string]float64{ComponentCPU: math.NaN(), ComponentGPU: 2}
+		got := Reading{
+			Watts:       5,
+			Temperature: math.NaN(),
+			PowerFactor: math.Inf(-1),
+			CPUFreqMHz:  math.Inf(1),
+			Components:  components,
+		}.Sanitize(0)
+
+		if got.Temperature != 0 || got.PowerFactor != 0 || got.CPUFreqMHz != 0 {
+			t.Errorf("expected non-finite fields zeroed, got %+v", got)
+		}
+		if got.Components[ComponentCPU] != 0 || got.Components[ComponentGPU] != 2 {
+			t.Errorf("expected only the NaN component zeroed, got %v", got.Components)
+		}
+		if !math.IsNaN(components[ComponentCPU]) {
+			t.Error("expected the original components map to be left alone")
+		}
+	})
+
+	t.Run("clamps negative time remaining", func(t *testing.T) {
+		if got := (Reading{TimeRemaining: -time.Minute}).Sanitize(0); got.TimeRemaining != 0 {
+			t.Errorf("TimeRemaining = %v, want 0", got.TimeRemaining)
+		}
+	})
 }
 
 func TestNewHistory(t *testing.T) {
@@ -128,6 +369,189 @@ func TestHistory_Add(t *testing.T) {
 	})
 }
 
+func TestHistory_AddAll(t *testing.T) {
+	// sequential returns a history like h with readings added one at a time
+	sequential := func(maxSize int, window time.Duration, seed, readings []Reading) *History {
+		h := NewHistory(maxSize, window)
+		for _, r := range append(seed, readings...) {
+			h.Add(r)
+		}
+		return h
+	}
+	// series returns n readings spaced interval apart
+	series := func(start time.Time, n int, interval time.Duration) []Reading {
+		readings := make([]Reading, n)
+		for i := range readings {
+			readings[i] = Reading{Watts: float64(i%7) + 1, Timestamp: start.Add(time.Duration(i) * interval)}
+		}
+		return readings
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name     string
+		maxSize  int
+		window   time.Duration
+		seed     []Reading
+		readings []Reading
+	}{
+		{"fits", 100, time.Hour, nil, series(now, 10, time.Second)},
+		{"over the size limit", 8, time.Hour, nil, series(now, 50, time.Second)},
+		{"exactly the size limit", 10, time.Hour, nil, series(now, 10, time.Second)},
+		{"over the time window", 100, 10 * time.Second, nil, series(now, 50, time.Second)},
+		{"over both", 5, 10 * time.Second, nil, series(now, 50, time.Second)},
+		{"onto existing readings", 20, 30 * time.Second, series(now, 15, time.Second), series(now.Add(20*time.Second), 15, time.Second)},
+		{"pushes out existing readings", 5, time.Hour, series(now, 3, time.Second), series(now.Add(time.Minute), 4, time.Second)},
+		{"zero window", 10, 0, nil, series(now, 5, time.Second)},
+		{"zero size", 0, time.Hour, nil, series(now, 5, time.Second)},
+		{"empty batch", 10, time.Hour, series(now, 3, time.Second), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := sequential(tt.maxSize, tt.window, tt.seed, tt.readings)
+
+			h := sequential(tt.maxSize, tt.window, tt.seed, nil)
+			h.AddAll(tt.readings)
+
+			if got, want := h.Readings(), want.Readings(); !reflect.DeepEqual(got, want) {
+				t.Errorf("AddAll stored %v, want %v", got, want)
+			}
+			if h.Seq() != want.Seq() {
+				t.Errorf("Seq = %d, want %d", h.Seq(), want.Seq())
+			}
+			if math.Abs(h.EMA()-want.EMA()) > 1e-9 {
+				t.Errorf("EMA = %f, want %f", h.EMA(), want.EMA())
+			}
+
+			// Further readings carry on the same way
+			next := Reading{Watts: 3, Timestamp: now.Add(2 * time.Hour)}
+			h.Add(next)
+			want.Add(next)
+			if got, want := h.Readings(), want.Readings(); !reflect.DeepEqual(got, want) {
+				t.Errorf("after another Add, stored %v, want %v", got, want)
+			}
+		})
+	}
+
+	t.Run("doesn't keep the caller's slice", func(t *testing.T) {
+		readings := series(now, 3, time.Second)
+		h := NewHistory(10, time.Hour)
+		h.AddAll(readings)
+
+		readings[0].Watts = 100
+		if got := h.Readings()[0].Watts; got != 1 {
+			t.Errorf("expected the stored reading to be unaffected, got %f", got)
+		}
+	})
+}
+
+func TestHistory_PruneNow(t *testing.T) {
+	t.Run("empties stale history once the window elapses", func(t *testing.T) {
+		h := NewHistory(100, 2*time.Second)
+		baseTime := time.Now()
+
+		h.Add(Reading{Watts: 10.0, Timestamp: baseTime})
+		h.Add(Reading{Watts: 20.0, Timestamp: baseTime.Add(1 * time.Second)})
+
+		// Only the first reading has left the window so far
+		h.PruneNow(baseTime.Add(2500 * time.Millisecond))
+		if h.Len() != 1 {
+			t.Errorf("expected Len()=1 after the first reading aged out, got %d", h.Len())
+		}
+		if r, _ := h.Latest(); r.Watts != 20.0 {
+			t.Errorf("expected the second reading to remain, got %v", r.Watts)
+		}
+
+		// No new readings arrived, but the window has passed
+		h.PruneNow(baseTime.Add(5 * time.Second))
+		if h.Len() != 0 {
+			t.Errorf("expected Len()=0, got %d", h.Len())
+		}
+	})
+
+	t.Run("keeps readings inside the window", func(t *testing.T) {
+		h := NewHistory(100, time.Minute)
+		now := time.Now()
+
+		h.Add(Reading{Watts: 10.0, Timestamp: now.Add(-30 * time.Second)})
+		h.Add(Reading{Watts: 20.0, Timestamp: now})
+		h.PruneNow(now)
+
+		if h.Len() != 2 {
+			t.Errorf("expected Len()=2, got %d", h.Len())
+		}
+	})
+}
+
+func TestHistory_Resize(t *testing.T) {
+	// newHistory returns a 2 minute history with one reading per second,
+	// the last one at baseTime
+	baseTime := time.Now()
+	newHistory := func() *History {
+		h := NewHistory(200, 2*time.Minute)
+		for i := 119; i >= 0; i-- {
+			h.Add(Reading{Watts: float64(i), Timestamp: baseTime.Add(-time.Duration(i) * time.Second)})
+		}
+		return h
+	}
+
+	t.Run("shrinking the window prunes old readings", func(t *testing.T) {
+		h := newHistory()
+		h.Resize(200, 30*time.Second)
+
+		if h.Window() != 30*time.Second {
+			t.Errorf("expected Window()=30s, got %v", h.Window())
+		}
+		readings := h.Readings()
+		if len(readings) != 30 {
+			t.Fatalf("expected 30 readings, got %d", len(readings))
+		}
+		if readings[0].Watts != 29 || readings[29].Watts != 0 {
+			t.Errorf("expected the newest 30 readings, got %v to %v", readings[0].Watts, readings[29].Watts)
+		}
+	})
+
+	t.Run("shrinking the size drops the oldest readings", func(t *testing.T) {
+		h := newHistory()
+		h.Resize(10, 2*time.Minute)
+
+		readings := h.Readings()
+		if len(readings) != 10 || readings[0].Watts != 9 {
+			t.Errorf("expected the newest 10 readings, got %d starting at %v", len(readings), readings[0].Watts)
+		}
+
+		// The new size also applies to later readings
+		h.Add(Reading{Watts: -1, Timestamp: baseTime.Add(time.Second)})
+		if h.Len() != 10 {
+			t.Errorf("expected Len()=10 after Add, got %d", h.Len())
+		}
+	})
+
+	t.Run("growing preserves readings", func(t *testing.T) {
+		h := newHistory()
+		h.Resize(1000, 10*time.Minute)
+
+		if h.Len() != 120 {
+			t.Errorf("expected all 120 readings, got %d", h.Len())
+		}
+
+		// Readings older than the old window are now kept
+		h.Add(Reading{Watts: 5, Timestamp: baseTime.Add(5 * time.Minute)})
+		if h.Len() != 121 {
+			t.Errorf("expected Len()=121, got %d", h.Len())
+		}
+	})
+
+	t.Run("empty history", func(t *testing.T) {
+		h := NewHistory(10, time.Minute)
+		h.Resize(5, time.Second)
+		if h.Len() != 0 || h.Window() != time.Second {
+			t.Errorf("expected an empty 1s history, got %d readings over %v", h.Len(), h.Window())
+		}
+	})
+}
+
 func TestHistory_Readings(t *testing.T) {
 	t.Run("returns copy of readings", func(t *testing.T) {
 		h := NewHistory(100, 5*time.Minute)
@@ -183,6 +607,25 @@ func TestHistory_Latest(t *testing.T) {
 	})
 }
 
+func TestHistory_LatestWatts(t *testing.T) {
+	h := NewHistory(100, 5*time.Minute)
+	if w := h.LatestWatts(); w != 0 {
+		t.Errorf("expected 0 for empty history, got %f", w)
+	}
+
+	now := time.Now()
+	h.Add(Reading{Watts: 10.0, Timestamp: now})
+	h.Add(Reading{Watts: 30.0, Timestamp: now.Add(time.Second)})
+	if w := h.LatestWatts(); w != 30.0 {
+		t.Errorf("expected LatestWatts=30.0, got %f", w)
+	}
+
+	h.Clear()
+	if w := h.LatestWatts(); w != 0 {
+		t.Errorf("expected 0 after Clear, got %f", w)
+	}
+}
+
 func TestHistory_Average(t *testing.T) {
 	t.Run("calculates correct average", func(t *testing.T) {
 		h := NewHistory(100, 5*time.Minute)
@@ -270,6 +713,54 @@ func TestHistory_Max(t *testing.T) {
 	})
 }
 
+func TestHistory_StdDev(t *testing.T) {
+	t.Run("matches hand-computed value", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+		now := time.Now()
+
+		// Mean 5, squared deviations 9+1+1+1+0+0+4+16 = 32, variance 32/8 = 4
+		for i, w := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+			h.Add(Reading{Watts: w, Timestamp: now.Add(time.Duration(i) * time.Second)})
+		}
+
+		if v := h.Variance(); math.Abs(v-4.0) > 1e-9 {
+			t.Errorf("expected variance=4.0, got %f", v)
+		}
+		if sd := h.StdDev(); math.Abs(sd-2.0) > 1e-9 {
+			t.Errorf("expected stddev=2.0, got %f", sd)
+		}
+	})
+
+	t.Run("stays accurate with a large offset", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+		now := time.Now()
+
+		for i, w := range []float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16} {
+			h.Add(Reading{Watts: w, Timestamp: now.Add(time.Duration(i) * time.Second)})
+		}
+
+		// Deviations from 1e9+10 are -6, -3, 3, 6: variance 90/4
+		if v := h.Variance(); math.Abs(v-22.5) > 1e-6 {
+			t.Errorf("expected variance=22.5, got %f", v)
+		}
+	})
+
+	t.Run("returns 0 for empty and single-reading history", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+		if sd := h.StdDev(); sd != 0 {
+			t.Errorf("expected stddev=0 for empty history, got %f", sd)
+		}
+
+		h.Add(Reading{Watts: 42.0, Timestamp: time.Now()})
+		if sd := h.StdDev(); sd != 0 {
+			t.Errorf("expected stddev=0 for single reading, got %f", sd)
+		}
+		if v := h.Variance(); v != 0 {
+			t.Errorf("expected variance=0 for single reading, got %f", v)
+		}
+	})
+}
+
 func TestHistory_Trend(t *testing.T) {
 	t.Run("detects increasing trend", func(t *testing.T) {
 		h := NewHistory(100, 5*time.Minute)
@@ -341,6 +832,476 @@ func TestHistory_Trend(t *testing.T) {
 	})
 }
 
+func TestHistory_TrendPerMinute(t *testing.T) {
+	// add records watts rising by 2W each reading, spaced interval apart
+	add := func(h *History, interval time.Duration, n int) {
+		now := time.Now()
+		for i := 0; i < n; i++ {
+			h.Add(Reading{Watts: 10 + 2*float64(i), Timestamp: now.Add(time.Duration(i) * interval)})
+		}
+	}
+
+	t.Run("normalizes by reading spacing", func(t *testing.T) {
+		tests := []struct {
+			interval time.Duration
+			want     float64
+		}{
+			{time.Second, 120},
+			{5 * time.Second, 24},
+			{time.Minute, 2},
+		}
+		for _, tt := range tests {
+			t.Run(tt.interval.String(), func(t *testing.T) {
+				h := NewHistory(100, time.Hour)
+				add(h, tt.interval, 10)
+
+				if got := h.TrendPerMinute(); math.Abs(got-tt.want) > 1e-9 {
+					t.Errorf("TrendPerMinute = %f, want %f", got, tt.want)
+				}
+				// Trend stays per reading
+				if got := h.Trend(); math.Abs(got-2) > 1e-9 {
+					t.Errorf("Trend = %f, want 2", got)
+				}
+			})
+		}
+	})
+
+	t.Run("same rate whatever the interval", func(t *testing.T) {
+		// 60W/min sampled every second and every five seconds
+		fast := NewHistory(100, time.Hour)
+		slow := NewHistory(100, time.Hour)
+		now := time.Now()
+		for i := 0; i <= 30; i++ {
+			fast.Add(Reading{Watts: 10 + float64(i), Timestamp: now.Add(time.Duration(i) * time.Second)})
+		}
+		for i := 0; i <= 6; i++ {
+			slow.Add(Reading{Watts: 10 + 5*float64(i), Timestamp: now.Add(time.Duration(i) * 5 * time.Second)})
+		}
+
+		if f, s := fast.TrendPerMinute(), slow.TrendPerMinute(); math.Abs(f-60) > 1e-9 || math.Abs(s-60) > 1e-9 {
+			t.Errorf("expected 60W/min for both, got %f and %f", f, s)
+		}
+	})
+
+	t.Run("covers the window", func(t *testing.T) {
+		h := NewHistory(100, time.Hour)
+		now := time.Now()
+		for i, w := range []float64{50, 40, 30, 20, 25, 35} {
+			h.Add(Reading{Watts: w, Timestamp: now.Add(time.Duration(i) * time.Minute)})
+		}
+		if got := h.TrendPerMinuteOver(time.Minute); math.Abs(got-10) > 1e-9 {
+			t.Errorf("TrendPerMinuteOver = %f, want 10", got)
+		}
+	})
+
+	t.Run("returns 0 without a time span", func(t *testing.T) {
+		h := NewHistory(100, time.Hour)
+		if h.TrendPerMinute() != 0 {
+			t.Error("expected 0 for empty history")
+		}
+
+		now := time.Now()
+		h.Add(Reading{Watts: 10, Timestamp: now})
+		h.Add(Reading{Watts: 20, Timestamp: now})
+		if got := h.TrendPerMinute(); got != 0 {
+			t.Errorf("expected 0 for readings with the same timestamp, got %f", got)
+		}
+	})
+}
+
+func TestHistory_BatteryDrainRatePerHour(t *testing.T) {
+	// drain returns readings on battery every minute, with the percentage
+	// falling by step each time
+	drain := func(from, step float64, n int) []Reading {
+		now := time.Now()
+		readings := make([]Reading, n)
+		for i := range readings {
+			readings[i] = Reading{
+				Watts:          10,
+				IsOnBattery:    true,
+				BatteryPercent: from - step*float64(i),
+				Timestamp:      now.Add(time.Duration(i) * time.Minute),
+			}
+		}
+		return readings
+	}
+	history := func(readings ...Reading) *History {
+		h := NewHistory(100, 24*time.Hour)
+		for _, r := range readings {
+			h.Add(r)
+		}
+		return h
+	}
+
+	t.Run("rate of a steady drain", func(t *testing.T) {
+		// 0.2% a minute is 12% an hour
+		h := history(drain(90, 0.2, 10)...)
+		if got := h.BatteryDrainRatePerHour(); math.Abs(got-12) > 1e-9 {
+			t.Errorf("BatteryDrainRatePerHour = %f, want 12", got)
+		}
+	})
+
+	t.Run("fits whole-percent steps", func(t *testing.T) {
+		// Battery percentages usually come in whole steps: 1% every 5 minutes
+		readings := drain(80, 0, 21)
+		for i := range readings {
+			readings[i].BatteryPercent = 80 - float64(i/5)
+		}
+		got := history(readings...).BatteryDrainRatePerHour()
+		if got < 11 || got > 13 {
+			t.Errorf("BatteryDrainRatePerHour = %f, want about 12", got)
+		}
+	})
+
+	t.Run("only covers the latest stretch on battery", func(t *testing.T) {
+		charging := drain(50, -1, 5)
+		for i := range charging {
+			charging[i].IsOnBattery = false
+			charging[i].Timestamp = charging[i].Timestamp.Add(-time.Hour)
+		}
+		h := history(append(charging, drain(90, 0.5, 5)...)...)
+		if got := h.BatteryDrainRatePerHour(); math.Abs(got-30) > 1e-9 {
+			t.Errorf("BatteryDrainRatePerHour = %f, want 30", got)
+		}
+	})
+
+	t.Run("zero on AC", func(t *testing.T) {
+		readings := drain(90, 0.2, 10)
+		readings[len(readings)-1].IsOnBattery = false
+		if got := history(readings...).BatteryDrainRatePerHour(); got != 0 {
+			t.Errorf("BatteryDrainRatePerHour = %f, want 0", got)
+		}
+	})
+
+	t.Run("zero without enough data", func(t *testing.T) {
+		if got := history().BatteryDrainRatePerHour(); got != 0 {
+			t.Errorf("empty: BatteryDrainRatePerHour = %f, want 0", got)
+		}
+		if got := history(drain(90, 0.2, 1)...).BatteryDrainRatePerHour(); got != 0 {
+			t.Errorf("one reading: BatteryDrainRatePerHour = %f, want 0", got)
+		}
+		readings := drain(90, 0.2, 10)
+		for i := range readings {
+			readings[i].BatteryPercent = -1
+		}
+		if got := history(readings...).BatteryDrainRatePerHour(); got != 0 {
+			t.Errorf("no percentage: BatteryDrainRatePerHour = %f, want 0", got)
+		}
+	})
+
+	t.Run("zero when not draining", func(t *testing.T) {
+		if got := history(drain(90, -0.2, 10)...).BatteryDrainRatePerHour(); got != 0 {
+			t.Errorf("BatteryDrainRatePerHour = %f, want 0 for a rising percentage", got)
+		}
+	})
+}
+
+func TestHistory_StatsOver(t *testing.T) {
+	// One reading a minute for five minutes: power falls for three minutes,
+	// then climbs over the last two
+	h := NewHistory(100, 10*time.Minute)
+	now := time.Now()
+	for i, w := range []float64{50, 40, 30, 20, 25, 35} {
+		h.Add(Reading{Watts: w, Timestamp: now.Add(time.Duration(i) * time.Minute)})
+	}
+
+	tests := []struct {
+		name      string
+		window    time.Duration
+		wantAvg   float64
+		wantMin   float64
+		wantMax   float64
+		wantTrend int // sign of the trend
+	}{
+		{"zero covers everything", 0, 200.0 / 6, 20, 50, -1},
+		{"negative covers everything", -time.Minute, 200.0 / 6, 20, 50, -1},
+		{"longer than the history", time.Hour, 200.0 / 6, 20, 50, -1},
+		{"last two minutes", 2 * time.Minute, 80.0 / 3, 20, 35, 1},
+		{"boundary is inclusive", time.Minute, 30, 25, 35, 1},
+		{"only the newest reading", time.Second, 35, 35, 35, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.AverageOver(tt.window); math.Abs(got-tt.wantAvg) > 1e-9 {
+				t.Errorf("AverageOver = %f, want %f", got, tt.wantAvg)
+			}
+			if got := h.MinOver(tt.window); got != tt.wantMin {
+				t.Errorf("MinOver = %f, want %f", got, tt.wantMin)
+			}
+			if got := h.MaxOver(tt.window); got != tt.wantMax {
+				t.Errorf("MaxOver = %f, want %f", got, tt.wantMax)
+			}
+			trend := h.TrendOver(tt.window)
+			if (tt.wantTrend > 0 && trend <= 0) || (tt.wantTrend < 0 && trend >= 0) || (tt.wantTrend == 0 && trend != 0) {
+				t.Errorf("TrendOver = %f, want sign %d", trend, tt.wantTrend)
+			}
+
+			stats := h.StatsOver(tt.window)
+			if math.Abs(stats.Average-tt.wantAvg) > 1e-9 || stats.Min != tt.wantMin || stats.Max != tt.wantMax {
+				t.Errorf("StatsOver = %+v, want avg %f, min %f, max %f", stats, tt.wantAvg, tt.wantMin, tt.wantMax)
+			}
+			if stats.TrendPerMinute != h.TrendPerMinuteOver(tt.window) {
+				t.Errorf("StatsOver trend = %f, want %f", stats.TrendPerMinute, h.TrendPerMinuteOver(tt.window))
+			}
+		})
+	}
+
+	t.Run("empty history", func(t *testing.T) {
+		h := NewHistory(100, time.Minute)
+		if h.AverageOver(time.Minute) != 0 || h.MinOver(time.Minute) != 0 ||
+			h.MaxOver(time.Minute) != 0 || h.TrendOver(time.Minute) != 0 {
+			t.Error("expected zero stats for empty history")
+		}
+		if stats := h.StatsOver(time.Minute); stats != (Stats{}) {
+			t.Errorf("expected zero Stats for empty history, got %+v", stats)
+		}
+	})
+}
+
+func TestHistory_EnergyWattHours(t *testing.T) {
+	t.Run("integrates constant power", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Hour)
+		now := time.Now()
+
+		h.Add(Reading{Watts: 60.0, Timestamp: now})
+		h.Add(Reading{Watts: 60.0, Timestamp: now.Add(30 * time.Minute)})
+		h.Add(Reading{Watts: 60.0, Timestamp: now.Add(60 * time.Minute)})
+
+		energy := h.EnergyWattHours()
+		if math.Abs(energy-60.0) > 1e-9 {
+			t.Errorf("expected energy=60.0Wh, got %f", energy)
+		}
+	})
+
+	t.Run("uses trapezoidal rule", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Hour)
+		now := time.Now()
+
+		h.Add(Reading{Watts: 10.0, Timestamp: now})
+		h.Add(Reading{Watts: 30.0, Timestamp: now.Add(time.Hour)})
+
+		energy := h.EnergyWattHours()
+		if math.Abs(energy-20.0) > 1e-9 {
+			t.Errorf("expected energy=20.0Wh, got %f", energy)
+		}
+	})
+
+	t.Run("returns 0 for fewer than two readings", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+
+		if energy := h.EnergyWattHours(); energy != 0 {
+			t.Errorf("expected energy=0 for empty history, got %f", energy)
+		}
+
+		h.Add(Reading{Watts: 50.0, Timestamp: time.Now()})
+		if energy := h.EnergyWattHours(); energy != 0 {
+			t.Errorf("expected energy=0 for single reading, got %f", energy)
+		}
+	})
+
+	t.Run("ignores pruned readings", func(t *testing.T) {
+		h := NewHistory(100, 2*time.Second)
+		now := time.Now()
+
+		h.Add(Reading{Watts: 1000.0, Timestamp: now})
+		h.Add(Reading{Watts: 36.0, Timestamp: now.Add(10 * time.Second)})
+		h.Add(Reading{Watts: 36.0, Timestamp: now.Add(11 * time.Second)})
+
+		energy := h.EnergyWattHours()
+		if math.Abs(energy-0.01) > 1e-9 {
+			t.Errorf("expected energy=0.01Wh, got %f", energy)
+		}
+	})
+}
+
+func TestHistory_CumulativeEnergy(t *testing.T) {
+	t.Run("is monotonic non-decreasing", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Hour)
+		now := time.Now()
+
+		watts := []float64{10, 50, 0, 0, 25, 5, 100}
+		for i, w := range watts {
+			h.Add(Reading{Watts: w, Timestamp: now.Add(time.Duration(i) * time.Minute)})
+		}
+
+		series := h.CumulativeEnergy()
+		if len(series) != len(watts) {
+			t.Fatalf("expected %d values, got %d", len(watts), len(series))
+		}
+		if series[0] != 0 {
+			t.Errorf("expected first value=0, got %f", series[0])
+		}
+		for i := 1; i < len(series); i++ {
+			if series[i] < series[i-1] {
+				t.Errorf("series decreased at %d: %f < %f", i, series[i], series[i-1])
+			}
+		}
+	})
+
+	t.Run("final value matches EnergyWattHours", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Hour)
+		now := time.Now()
+
+		h.Add(Reading{Watts: 10.0, Timestamp: now})
+		h.Add(Reading{Watts: 30.0, Timestamp: now.Add(time.Hour)})
+		h.Add(Reading{Watts: 30.0, Timestamp: now.Add(2 * time.Hour)})
+
+		series := h.CumulativeEnergy()
+		if math.Abs(series[1]-20.0) > 1e-9 {
+			t.Errorf("expected series[1]=20.0, got %f", series[1])
+		}
+		if math.Abs(series[2]-h.EnergyWattHours()) > 1e-9 {
+			t.Errorf("expected final value=%f, got %f", h.EnergyWattHours(), series[2])
+		}
+	})
+
+	t.Run("returns empty slice for empty history", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+
+		if series := h.CumulativeEnergy(); len(series) != 0 {
+			t.Errorf("expected empty slice, got %d elements", len(series))
+		}
+	})
+}
+
+func TestHistory_EMA(t *testing.T) {
+	t.Run("empty history", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+		if ema := h.EMA(); ema != 0 {
+			t.Errorf("expected EMA()=0, got %f", ema)
+		}
+	})
+
+	t.Run("starts at the first reading", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+		h.Add(Reading{Watts: 12.0, Timestamp: time.Now()})
+		if ema := h.EMA(); ema != 12.0 {
+			t.Errorf("expected EMA()=12, got %f", ema)
+		}
+	})
+
+	t.Run("converges toward a constant input", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+		now := time.Now()
+		h.Add(Reading{Watts: 0, Timestamp: now})
+
+		prevGap := 20.0
+		for i := 1; i <= 30; i++ {
+			h.Add(Reading{Watts: 20.0, Timestamp: now.Add(time.Duration(i) * time.Second)})
+			gap := 20.0 - h.EMA()
+			if gap <= 0 || gap >= prevGap {
+				t.Fatalf("after %d readings, EMA()=%f should approach 20 from below", i, h.EMA())
+			}
+			prevGap = gap
+		}
+		if gap := 20.0 - h.EMA(); gap > 0.01 {
+			t.Errorf("expected EMA() within 0.01 of 20 after 30 readings, got %f", h.EMA())
+		}
+	})
+
+	t.Run("uses the configured alpha", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+		h.SetEMAAlpha(0.5)
+		now := time.Now()
+		h.Add(Reading{Watts: 10.0, Timestamp: now})
+		h.Add(Reading{Watts: 20.0, Timestamp: now.Add(time.Second)})
+		if ema := h.EMA(); math.Abs(ema-15.0) > 0.001 {
+			t.Errorf("expected EMA()=15 with alpha 0.5, got %f", ema)
+		}
+	})
+
+	t.Run("defaults to 0.3", func(t *testing.T) {
+		for _, alpha := range []float64{0, -1, 1.5, math.NaN()} {
+			h := NewHistory(100, 5*time.Minute)
+			h.SetEMAAlpha(alpha)
+			now := time.Now()
+			h.Add(Reading{Watts: 10.0, Timestamp: now})
+			h.Add(Reading{Watts: 20.0, Timestamp: now.Add(time.Second)})
+			if ema := h.EMA(); math.Abs(ema-13.0) > 0.001 {
+				t.Errorf("alpha %v: expected EMA()=13 with the default alpha, got %f", alpha, ema)
+			}
+		}
+	})
+
+	t.Run("outlives pruned readings", func(t *testing.T) {
+		h := NewHistory(2, 5*time.Minute)
+		now := time.Now()
+		for i, w := range []float64{10, 20, 30} {
+			h.Add(Reading{Watts: w, Timestamp: now.Add(time.Duration(i) * time.Second)})
+		}
+		// 10, then 13, then 13 + 0.3 * 17
+		if ema := h.EMA(); math.Abs(ema-18.1) > 0.001 {
+			t.Errorf("expected EMA()=18.1, got %f", ema)
+		}
+	})
+
+	t.Run("clear starts over", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+		now := time.Now()
+		h.Add(Reading{Watts: 10.0, Timestamp: now})
+		h.Clear()
+		if ema := h.EMA(); ema != 0 {
+			t.Errorf("expected EMA()=0 after Clear(), got %f", ema)
+		}
+		h.Add(Reading{Watts: 30.0, Timestamp: now.Add(time.Second)})
+		if ema := h.EMA(); ema != 30.0 {
+			t.Errorf("expected EMA()=30 after Clear() and Add(), got %f", ema)
+		}
+	})
+}
+
+func TestHistory_Seq(t *testing.T) {
+	t.Run("increments by one per add", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+		if h.Seq() != 0 {
+			t.Errorf("expected Seq()=0 before any add, got %d", h.Seq())
+		}
+
+		now := time.Now()
+		for i := 1; i <= 3; i++ {
+			h.Add(Reading{Watts: 10, Timestamp: now.Add(time.Duration(i) * time.Second), Seq: 99})
+			if h.Seq() != uint64(i) {
+				t.Errorf("after %d adds, expected Seq()=%d, got %d", i, i, h.Seq())
+			}
+		}
+		for i, r := range h.Readings() {
+			if r.Seq != uint64(i+1) {
+				t.Errorf("reading %d has Seq=%d, want %d", i, r.Seq, i+1)
+			}
+		}
+	})
+
+	t.Run("keeps counting as readings are pruned", func(t *testing.T) {
+		h := NewHistory(2, 5*time.Minute)
+		now := time.Now()
+		for i := 0; i < 5; i++ {
+			h.Add(Reading{Watts: 10, Timestamp: now.Add(time.Duration(i) * time.Second)})
+		}
+		readings := h.Readings()
+		if h.Seq() != 5 || readings[0].Seq != 4 || readings[1].Seq != 5 {
+			t.Errorf("expected readings 4 and 5 with Seq()=5, got %d and %d with Seq()=%d",
+				readings[0].Seq, readings[1].Seq, h.Seq())
+		}
+	})
+
+	t.Run("resets on clear", func(t *testing.T) {
+		h := NewHistory(100, 5*time.Minute)
+		now := time.Now()
+		h.Add(Reading{Watts: 10, Timestamp: now})
+		h.Add(Reading{Watts: 20, Timestamp: now.Add(time.Second)})
+
+		h.Clear()
+		if h.Seq() != 0 {
+			t.Errorf("expected Seq()=0 after Clear(), got %d", h.Seq())
+		}
+		h.Add(Reading{Watts: 30, Timestamp: now.Add(2 * time.Second)})
+		if latest, _ := h.Latest(); latest.Seq != 1 {
+			t.Errorf("expected Seq=1 for the first reading after Clear(), got %d", latest.Seq)
+		}
+	})
+}
+
 func TestHistory_Clear(t *testing.T) {
 	t.Run("clears all readings", func(t *testing.T) {
 		h := NewHistory(100, 5*time.Minute)
@@ -357,7 +1318,163 @@ func TestHistory_Clear(t *testing.T) {
 	})
 }
 
+func TestHistory_Concurrent(t *testing.T) {
+	t.Run("supports concurrent readers and writer", func(t *testing.T) {
+		h := NewHistory(50, 5*time.Minute)
+		now := time.Now()
+
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					_ = h.Readings()
+					_ = h.Len()
+					_, _ = h.Latest()
+					_ = h.Average()
+					_ = h.Min()
+					_ = h.Max()
+					_ = h.Trend()
+					_ = h.EnergyWattHours()
+					_ = h.CumulativeEnergy()
+				}
+			}()
+		}
+
+		for i := 0; i < 1000; i++ {
+			h.Add(Reading{Watts: float64(i % 100), Timestamp: now.Add(time.Duration(i) * time.Millisecond)})
+			if i%250 == 0 {
+				h.Clear()
+			}
+		}
+		close(done)
+		wg.Wait()
+
+		if h.Len() == 0 || h.Len() > 50 {
+			t.Errorf("expected 1-50 readings, got %d", h.Len())
+		}
+	})
+}
+
+// TestHistory_RingBufferStress checks the ring buffer against a plain slice
+// model through many wraparounds, with readings arriving at irregular
+// intervals so the time window and the size limit both trim them, and with
+// occasional resizes and clears. History used to trim by reslicing, which
+// reallocated the backing array every so often; BenchmarkHistory_Add went
+// from about 110ns and 660B per reading to about 65ns and no allocations
+// with the ring buffer.
+func TestHistory_RingBufferStress(t *testing.T) {
+	const window = 10 * time.Second
+	maxSize := 7
+	h := NewHistory(maxSize, window)
+	var model []Reading
+
+	now := time.Unix(0, 0)
+	for i := 0; i < 5000; i++ {
+		// Mostly steady readings with the odd long gap
+		step := time.Duration(1+i%3) * time.Second
+		if i%97 == 0 {
+			step = 2 * window
+		}
+		now = now.Add(step)
+		r := Reading{Watts: float64(i), Timestamp: now}
+		h.Add(r)
+
+		cutoff := now.Add(-window)
+		for len(model) > 0 && !model[0].Timestamp.After(cutoff) {
+			model = model[1:]
+		}
+		model = append(model, r)
+		if len(model) > maxSize {
+			model = model[len(model)-maxSize:]
+		}
+
+		switch {
+		case i%611 == 0:
+			maxSize = 3 + i%11
+			h.Resize(maxSize, window)
+			if len(model) > maxSize {
+				model = model[len(model)-maxSize:]
+			}
+		case i%1499 == 0:
+			h.Clear()
+			model = nil
+		}
+
+		got := h.Readings()
+		if len(got) != len(model) {
+			t.Fatalf("after reading %d: got %d readings, want %d", i, len(got), len(model))
+		}
+		for j := range got {
+			if got[j].Watts != model[j].Watts || !got[j].Timestamp.Equal(model[j].Timestamp) {
+				t.Fatalf("after reading %d: reading %d = %v, want %v", i, j, got[j].Watts, model[j].Watts)
+			}
+		}
+		if latest, ok := h.Latest(); len(model) > 0 && (!ok || latest.Watts != model[len(model)-1].Watts) {
+			t.Fatalf("after reading %d: Latest = %v, want %v", i, latest.Watts, model[len(model)-1].Watts)
+		}
+	}
+
+	t.Run("adding to a full history doesn't allocate", func(t *testing.T) {
+		h := NewHistory(100, time.Hour)
+		now := time.Now()
+		i := 0
+		allocs := testing.AllocsPerRun(1000, func() {
+			h.Add(Reading{Watts: float64(i), Timestamp: now.Add(time.Duration(i) * time.Millisecond)})
+			i++
+		})
+		if allocs != 0 {
+			t.Errorf("Add allocated %v times per reading, want 0", allocs)
+		}
+	})
+}
+
 // Benchmark tests
+// readCanceled reads from m with a canceled context and fails unless Read
+// returns promptly with context.Canceled.
+func readCanceled(t *testing.T, m Monitor) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	_, err := m.Read(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Read took %v, expected it to stop once canceled", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestWrappers_ReadCanceled(t *testing.T) {
+	// Each wrapper around a monitor whose reads block until canceled
+	wrappers := map[string]func(Monitor) Monitor{
+		"csv": func(m Monitor) Monitor {
+			csv, _ := NewCSVMonitor(m, io.Discard, false)
+			return csv
+		},
+		"record":     func(m Monitor) Monitor { return NewRecordingMonitor(m, io.Discard) },
+		"summary":    func(m Monitor) Monitor { return NewSummaryMonitor(m) },
+		"filter":     func(m Monitor) Monitor { return NewMedianFilterMonitor(m, 3) },
+		"calibrated": func(m Monitor) Monitor { return NewCalibratedMonitor(m, Calibration{}) },
+	}
+
+	for name, wrap := range wrappers {
+		t.Run(name, func(t *testing.T) {
+			readCanceled(t, wrap(NewMockMonitor().WithReadDelay(time.Minute)))
+		})
+	}
+}
+
 func BenchmarkHistory_Add(b *testing.B) {
 	h := NewHistory(1000, 5*time.Minute)
 	now := time.Now()
@@ -368,6 +1485,31 @@ func BenchmarkHistory_Add(b *testing.B) {
 	}
 }
 
+// BenchmarkHistory_Load compares loading a batch with AddAll to adding its
+// readings one at a time.
+func BenchmarkHistory_Load(b *testing.B) {
+	now := time.Now()
+	readings := make([]Reading, 10000)
+	for i := range readings {
+		readings[i] = Reading{Watts: float64(i % 100), Timestamp: now.Add(time.Duration(i) * time.Second)}
+	}
+
+	b.Run("Add", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := NewHistory(1000, 5*time.Minute)
+			for _, r := range readings {
+				h.Add(r)
+			}
+		}
+	})
+	b.Run("AddAll", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := NewHistory(1000, 5*time.Minute)
+			h.AddAll(readings)
+		}
+	})
+}
+
 func BenchmarkHistory_Average(b *testing.B) {
 	h := NewHistory(1000, 5*time.Minute)
 	now := time.Now()
@@ -0,0 +1,20 @@
+package power
+
+// raplPackagePath is the RAPL powercap domain for the first CPU package on
+// Linux. AMD CPUs appear under the same intel-rapl name.
+const raplPackagePath = "/sys/class/powercap/intel-rapl:0"
+
+// energyDelta returns how far an energy counter advanced from prev to cur.
+// The counter wraps to zero after maxRange, so a smaller cur means it
+// wrapped once in between; without a known range that can't be accounted
+// for and the pair is rejected.
+func energyDelta(prev, cur, maxRange float64) (float64, bool) {
+	delta := cur - prev
+	if delta < 0 {
+		if maxRange <= 0 || prev > maxRange {
+			return 0, false
+		}
+		delta += maxRange
+	}
+	return delta, true
+}
@@ -0,0 +1,79 @@
+package power
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// traceEntry is one line of a recorded trace: the reading as -json writes
+// it, plus the error if the read failed.
+type traceEntry struct {
+	SchemaVersion int `json:"schema_version,omitempty"`
+	Reading
+	Error string `json:"error,omitempty"`
+}
+
+// JSONLine formats a reading as a single line of JSON, as -json writes it,
+// tagged with the SchemaVersion.
+func JSONLine(r Reading) []byte {
+	data, err := json.Marshal(traceEntry{SchemaVersion: SchemaVersion, Reading: r})
+	if err != nil {
+		// Readings only hold plain values, so this cannot happen in practice
+		return nil
+	}
+	return append(data, '\n')
+}
+
+// RecordingMonitor wraps another Monitor and tees every read, including
+// failed ones, to a writer as JSON lines. Successful lines use the same
+// format as -json, so a trace can be replayed through -input-pipe.
+type RecordingMonitor struct {
+	Monitor
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecordingMonitor wraps monitor so that every read is recorded to w.
+func NewRecordingMonitor(monitor Monitor, w io.Writer) *RecordingMonitor {
+	return &RecordingMonitor{Monitor: monitor, enc: json.NewEncoder(w)}
+}
+
+// NeedsSudo reports whether the wrapped monitor needs sudo.
+func (m *RecordingMonitor) NeedsSudo() bool {
+	return NeedsSudo(m.Monitor)
+}
+
+// Close closes the wrapped monitor.
+func (m *RecordingMonitor) Close() error {
+	return Close(m.Monitor)
+}
+
+// Read reads from the wrapped monitor and records the result. Each line is
+// written with a single call, so nothing is left buffered between reads.
+func (m *RecordingMonitor) Read(ctx context.Context) (Reading, error) {
+	reading, err := m.Monitor.Read(ctx)
+
+	entry := traceEntry{SchemaVersion: SchemaVersion, Reading: reading}
+	if err != nil {
+		entry.Error = err.Error()
+		if entry.Timestamp.IsZero() {
+			entry.Timestamp = time.Now()
+		}
+	}
+
+	m.mu.Lock()
+	werr := m.enc.Encode(entry)
+	m.mu.Unlock()
+
+	if err != nil {
+		return reading, err
+	}
+	if werr != nil {
+		return reading, fmt.Errorf("%w: %w", ErrLogWrite, werr)
+	}
+	return reading, nil
+}
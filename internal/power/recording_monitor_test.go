@@ -0,0 +1,109 @@
+package power
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordingMonitor(t *testing.T) {
+	t.Run("implements Monitor interface", func(t *testing.T) {
+		var _ Monitor = &RecordingMonitor{}
+	})
+
+	t.Run("records one line per reading", func(t *testing.T) {
+		ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		readings := []Reading{
+			{Watts: 12.5, Timestamp: ts, BatteryPercent: 80, Source: "mock"},
+			{Watts: 14.0, Timestamp: ts.Add(time.Second), BatteryPercent: 79, IsOnBattery: true, Source: "mock"},
+			{Watts: 9.25, Timestamp: ts.Add(2 * time.Second), BatteryPercent: 79, Temperature: 31.5, Source: "mock"},
+		}
+		var buf bytes.Buffer
+		m := NewRecordingMonitor(NewMockMonitor().WithReadings(readings...), &buf)
+
+		for range readings {
+			if _, err := m.Read(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != len(readings) {
+			t.Fatalf("expected %d lines, got %d: %q", len(readings), len(lines), buf.String())
+		}
+		for i, line := range lines {
+			got, err := parseReadingLine([]byte(line))
+			if err != nil {
+				t.Fatalf("line %d: %v", i, err)
+			}
+			want := readings[i]
+			if !got.Timestamp.Equal(want.Timestamp) || got.Watts != want.Watts ||
+				got.BatteryPercent != want.BatteryPercent || got.IsOnBattery != want.IsOnBattery ||
+				got.Temperature != want.Temperature || got.Source != want.Source {
+				t.Errorf("line %d = %+v, want %+v", i, got, want)
+			}
+		}
+	})
+
+	t.Run("records failed reads", func(t *testing.T) {
+		var buf bytes.Buffer
+		m := NewRecordingMonitor(NewMockMonitor().WithError(errors.New("powermetrics failed")), &buf)
+
+		if _, err := m.Read(context.Background()); err == nil {
+			t.Fatal("expected read error to pass through")
+		}
+
+		var entry traceEntry
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid trace line %q: %v", buf.String(), err)
+		}
+		if entry.Error != "powermetrics failed" {
+			t.Errorf("Error = %q, want powermetrics failed", entry.Error)
+		}
+		if entry.Timestamp.IsZero() {
+			t.Error("expected failed read to be timestamped")
+		}
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		m := NewRecordingMonitor(NewMockMonitor(), failingWriter{})
+
+		if _, err := m.Read(context.Background()); !errors.Is(err, ErrLogWrite) {
+			t.Errorf("expected an ErrLogWrite error, got %v", err)
+		}
+	})
+
+	t.Run("forwards name and support", func(t *testing.T) {
+		m := NewRecordingMonitor(NewMockMonitor().WithSupported(false), &bytes.Buffer{})
+		if m.Name() != "mock" || m.IsSupported() {
+			t.Errorf("expected wrapped name and support, got %q %v", m.Name(), m.IsSupported())
+		}
+	})
+}
+
+func TestJSONLine(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	line := JSONLine(Reading{Watts: 12.5, Timestamp: ts, Source: "mock"})
+
+	if !bytes.HasSuffix(line, []byte("\n")) || bytes.Count(line, []byte("\n")) != 1 {
+		t.Fatalf("expected a single newline-terminated line, got %q", line)
+	}
+
+	var got Reading
+	if err := json.Unmarshal(line, &got); err != nil {
+		t.Fatalf("unexpected error decoding %q: %v", line, err)
+	}
+	if got.Watts != 12.5 || !got.Timestamp.Equal(ts) || got.Source != "mock" {
+		t.Errorf("unexpected round trip: %+v", got)
+	}
+	if !strings.Contains(string(line), `"watts":12.5`) {
+		t.Errorf("expected watts field, got %q", line)
+	}
+	if !strings.Contains(string(line), `"schema_version":1`) {
+		t.Errorf("expected schema version, got %q", line)
+	}
+}
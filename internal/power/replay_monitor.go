@@ -0,0 +1,191 @@
+package power
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrReplayDone is returned by ReplayMonitor once every reading has been
+// replayed and looping is off.
+var ErrReplayDone = errors.New("replay finished")
+
+// ReplayMonitor plays back readings recorded earlier, e.g. with -json, -log
+// or -record, one per Read. It is a file-backed cousin of MockMonitor for
+// development and demos on machines without power telemetry. Readings are
+// restamped with the time they are replayed, so the UI paces them by its own
+// refresh interval rather than the recording's.
+type ReplayMonitor struct {
+	mu       sync.Mutex
+	readings []Reading
+	loop     bool
+	next     int
+	now      func() time.Time
+}
+
+// NewReplayMonitor creates a monitor that returns readings in order. With
+// loop set it starts over after the last one; otherwise further reads
+// return ErrReplayDone.
+func NewReplayMonitor(readings []Reading, loop bool) *ReplayMonitor {
+	return &ReplayMonitor{readings: readings, loop: loop, now: time.Now}
+}
+
+// WithClock makes Read stamp readings with now instead of time.Now, so tests
+// can control the passage of time.
+func (m *ReplayMonitor) WithClock(now func() time.Time) *ReplayMonitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+	return m
+}
+
+// Name returns the name of this monitor.
+func (m *ReplayMonitor) Name() string {
+	return "replay"
+}
+
+// IsSupported reports whether there is anything to replay.
+func (m *ReplayMonitor) IsSupported() bool {
+	return len(m.readings) > 0
+}
+
+// Close does nothing, since the monitor holds nothing open between reads.
+func (m *ReplayMonitor) Close() error {
+	return nil
+}
+
+// Read returns the next recorded reading, stamped with the current time.
+func (m *ReplayMonitor) Read(ctx context.Context) (Reading, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.next >= len(m.readings) {
+		if !m.loop || len(m.readings) == 0 {
+			return Reading{BatteryPercent: -1, Source: m.Name()}, ErrReplayDone
+		}
+		m.next = 0
+	}
+	reading := m.readings[m.next]
+	m.next++
+	reading.Timestamp = m.now()
+	return reading, nil
+}
+
+// ReadReplay parses a recording into readings. It accepts the JSON lines
+// written by -json and -record, where failed reads are skipped, and the CSV
+// written by -log, telling them apart by the CSV header.
+func ReadReplay(r io.Reader) ([]Reading, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var readings []Reading
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(csvHeader[0]+",")) {
+		readings, err = readReplayCSV(data)
+	} else {
+		readings, err = readReplayJSON(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(readings) == 0 {
+		return nil, ErrNoData
+	}
+	return readings, nil
+}
+
+// readReplayJSON parses JSON lines into readings, skipping failed reads
+// recorded by RecordingMonitor. Lines with an unknown schema version are an
+// error rather than being misread.
+func readReplayJSON(data []byte) ([]Reading, error) {
+	var readings []Reading
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry traceEntry
+		entry.Reading = Reading{BatteryPercent: -1, WattsAvailable: true}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("line %d: parsing reading: %w", lineNum, err)
+		}
+		if err := checkSchemaVersion(entry.SchemaVersion); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if entry.Error != "" {
+			continue
+		}
+		readings = append(readings, entry.Reading)
+	}
+	return readings, scanner.Err()
+}
+
+// readReplayCSV parses a CSV log written by CSVMonitor into readings.
+func readReplayCSV(data []byte) ([]Reading, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing CSV: %w", err)
+	}
+
+	// The first record is the header
+	readings := make([]Reading, 0, len(records)-1)
+	for i, record := range records[1:] {
+		reading, err := parseCSVRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+2, err)
+		}
+		readings = append(readings, reading)
+	}
+	return readings, nil
+}
+
+// parseCSVRecord is the inverse of csvRecord.
+func parseCSVRecord(record []string) (Reading, error) {
+	if len(record) != len(csvHeader) {
+		return Reading{}, fmt.Errorf("expected %d fields, got %d", len(csvHeader), len(record))
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, record[0])
+	if err != nil {
+		return Reading{}, fmt.Errorf("parsing timestamp: %w", err)
+	}
+	watts, err := strconv.ParseFloat(record[1], 64)
+	if err != nil {
+		return Reading{}, fmt.Errorf("parsing watts: %w", err)
+	}
+	percent, err := strconv.ParseFloat(record[2], 64)
+	if err != nil {
+		return Reading{}, fmt.Errorf("parsing battery percent: %w", err)
+	}
+	charging, err := strconv.ParseBool(record[3])
+	if err != nil {
+		return Reading{}, fmt.Errorf("parsing is_charging: %w", err)
+	}
+	onBattery, err := strconv.ParseBool(record[4])
+	if err != nil {
+		return Reading{}, fmt.Errorf("parsing is_on_battery: %w", err)
+	}
+
+	return Reading{
+		Watts:          watts,
+		WattsAvailable: true,
+		Timestamp:      timestamp,
+		BatteryPercent: percent,
+		IsCharging:     charging,
+		IsOnBattery:    onBattery,
+		Source:         record[5],
+	}, nil
+}
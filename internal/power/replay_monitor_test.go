@@ -0,0 +1,199 @@
+package power
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayMonitor(t *testing.T) {
+	t.Run("implements Monitor interface", func(t *testing.T) {
+		var _ Monitor = NewReplayMonitor(nil, false)
+	})
+
+	t.Run("replays readings in order then finishes", func(t *testing.T) {
+		m := NewReplayMonitor([]Reading{{Watts: 5}, {Watts: 7}}, false)
+		ctx := context.Background()
+
+		for _, want := range []float64{5, 7} {
+			r, err := m.Read(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r.Watts != want {
+				t.Errorf("expected %v, got %v", want, r.Watts)
+			}
+		}
+		if _, err := m.Read(ctx); !errors.Is(err, ErrReplayDone) {
+			t.Errorf("expected ErrReplayDone, got %v", err)
+		}
+	})
+
+	t.Run("loops when asked", func(t *testing.T) {
+		m := NewReplayMonitor([]Reading{{Watts: 5}, {Watts: 7}}, true)
+
+		var got []float64
+		for i := 0; i < 5; i++ {
+			r, err := m.Read(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = append(got, r.Watts)
+		}
+		if want := []float64{5, 7, 5, 7, 5}; !slices.Equal(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("stamps readings with the replay time", func(t *testing.T) {
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		recorded := now.Add(-24 * time.Hour)
+		m := NewReplayMonitor([]Reading{{Watts: 5, Timestamp: recorded}}, false).
+			WithClock(func() time.Time { return now })
+
+		r, _ := m.Read(context.Background())
+		if !r.Timestamp.Equal(now) {
+			t.Errorf("expected timestamp %v, got %v", now, r.Timestamp)
+		}
+	})
+
+	t.Run("unsupported without readings", func(t *testing.T) {
+		if NewReplayMonitor(nil, true).IsSupported() {
+			t.Error("expected IsSupported=false with nothing to replay")
+		}
+		if _, err := NewReplayMonitor(nil, true).Read(context.Background()); !errors.Is(err, ErrReplayDone) {
+			t.Errorf("expected ErrReplayDone, got %v", err)
+		}
+	})
+}
+
+func TestReadReplay(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	recorded := []Reading{
+		{Watts: 12.5, WattsAvailable: true, Timestamp: ts, BatteryPercent: 80, IsOnBattery: true, Source: "mock"},
+		{Watts: 14, WattsAvailable: true, Timestamp: ts.Add(time.Second), BatteryPercent: 79, IsCharging: true, Source: "mock"},
+	}
+
+	// record runs readings through a logging wrapper and returns the log
+	record := func(t *testing.T, wrap func(Monitor, *bytes.Buffer) Monitor) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		m := wrap(NewMockMonitor().WithReadings(recorded...), &buf)
+		for range recorded {
+			if _, err := m.Read(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		return buf.Bytes()
+	}
+
+	check := func(t *testing.T, got []Reading) {
+		t.Helper()
+		if len(got) != len(recorded) {
+			t.Fatalf("expected %d readings, got %d", len(recorded), len(got))
+		}
+		for i, want := range recorded {
+			r := got[i]
+			if r.Watts != want.Watts || !r.Timestamp.Equal(want.Timestamp) ||
+				r.BatteryPercent != want.BatteryPercent || r.IsCharging != want.IsCharging ||
+				r.IsOnBattery != want.IsOnBattery || r.Source != want.Source || !r.WattsAvailable {
+				t.Errorf("reading %d: expected %+v, got %+v", i, want, r)
+			}
+		}
+	}
+
+	t.Run("round-trips the -json log", func(t *testing.T) {
+		var log []byte
+		for _, r := range recorded {
+			log = append(log, JSONLine(r)...)
+		}
+
+		got, err := ReadReplay(bytes.NewReader(log))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		check(t, got)
+	})
+
+	t.Run("round-trips the -log CSV", func(t *testing.T) {
+		log := record(t, func(m Monitor, buf *bytes.Buffer) Monitor {
+			csvMonitor, err := NewCSVMonitor(m, buf, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return csvMonitor
+		})
+
+		got, err := ReadReplay(bytes.NewReader(log))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		check(t, got)
+	})
+
+	t.Run("skips failed reads in a -record trace", func(t *testing.T) {
+		trace := `{"watts":5,"timestamp":"2024-01-02T03:04:05Z","source":"mock"}
+{"watts":0,"timestamp":"2024-01-02T03:04:06Z","source":"mock","error":"read failed"}
+
+{"watts":7,"timestamp":"2024-01-02T03:04:07Z","source":"mock"}
+`
+		got, err := ReadReplay(strings.NewReader(trace))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 2 || got[0].Watts != 5 || got[1].Watts != 7 {
+			t.Errorf("expected the 5W and 7W readings, got %+v", got)
+		}
+		if got[0].BatteryPercent != -1 {
+			t.Errorf("expected missing battery percent to default to -1, got %v", got[0].BatteryPercent)
+		}
+	})
+
+	t.Run("reads versioned lines", func(t *testing.T) {
+		trace := `{"schema_version":1,"watts":5,"timestamp":"2024-01-02T03:04:05Z","source":"mock"}
+{"watts":7,"timestamp":"2024-01-02T03:04:06Z","source":"mock"}
+`
+		got, err := ReadReplay(strings.NewReader(trace))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 2 || got[0].Watts != 5 || got[1].Watts != 7 {
+			t.Errorf("expected the 5W and 7W readings, got %+v", got)
+		}
+	})
+
+	t.Run("rejects an unknown schema version", func(t *testing.T) {
+		trace := `{"schema_version":1,"watts":5}
+{"schema_version":999,"watts":7}
+`
+		_, err := ReadReplay(strings.NewReader(trace))
+		if !errors.Is(err, ErrUnknownSchemaVersion) {
+			t.Fatalf("expected ErrUnknownSchemaVersion, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("expected the error to name the line, got %q", err)
+		}
+	})
+
+	t.Run("rejects bad input", func(t *testing.T) {
+		tests := map[string]string{
+			"malformed JSON":    "{\"watts\":5}\nnot json\n",
+			"short CSV row":     "timestamp,watts,battery_percent,is_charging,is_on_battery,source\n2024-01-02T03:04:05Z,5\n",
+			"bad CSV watts":     "timestamp,watts,battery_percent,is_charging,is_on_battery,source\n2024-01-02T03:04:05Z,lots,80.0,false,true,mock\n",
+			"empty":             "",
+			"CSV header only":   "timestamp,watts,battery_percent,is_charging,is_on_battery,source\n",
+			"only failed reads": "{\"error\":\"read failed\"}\n",
+		}
+		for name, input := range tests {
+			t.Run(name, func(t *testing.T) {
+				if _, err := ReadReplay(strings.NewReader(input)); err == nil {
+					t.Errorf("expected error for %q", input)
+				}
+			})
+		}
+	})
+}
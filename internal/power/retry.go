@@ -0,0 +1,47 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FirstReading reads from the monitor until it returns a usable reading,
+// retrying up to retries additional times and waiting delay between attempts.
+// A reading is unusable if Read fails or it has no timestamp; one that only
+// couldn't be logged is returned with its ErrLogWrite error. If every attempt
+// fails, the returned error wraps ErrNoData and the last read error. Errors
+// wrapping ErrNeedsPrivilege or ErrUnsupported end the retries early, since
+// waiting won't fix them, while ErrNoNewReading doesn't use one up: a
+// producer that hasn't pushed anything yet is waited for until ctx is done.
+func FirstReading(ctx context.Context, m Monitor, retries int, delay time.Duration) (Reading, error) {
+	var lastErr error
+	for attempt := 0; ; {
+		reading, err := m.Read(ctx)
+		if !ReadFailed(err) && !reading.Timestamp.IsZero() {
+			return reading, err
+		}
+		lastErr = err
+		if errors.Is(err, ErrNeedsPrivilege) || errors.Is(err, ErrUnsupported) {
+			return Reading{}, fmt.Errorf("%w after %d attempts: %w", ErrNoData, attempt+1, err)
+		}
+		if !errors.Is(err, ErrNoNewReading) {
+			attempt++
+		}
+		if attempt > retries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return Reading{}, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	if lastErr != nil {
+		return Reading{}, fmt.Errorf("%w after %d attempts: %w", ErrNoData, retries+1, lastErr)
+	}
+	return Reading{}, fmt.Errorf("%w after %d attempts", ErrNoData, retries+1)
+}
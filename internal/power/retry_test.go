@@ -0,0 +1,115 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFirstReading(t *testing.T) {
+	t.Run("returns first successful reading", func(t *testing.T) {
+		m := NewMockMonitor()
+
+		reading, err := FirstReading(context.Background(), m, 3, time.Millisecond)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts != 10.0 {
+			t.Errorf("expected Watts=10.0, got %f", reading.Watts)
+		}
+		if m.ReadCount() != 1 {
+			t.Errorf("expected ReadCount=1, got %d", m.ReadCount())
+		}
+	})
+
+	t.Run("retries then succeeds", func(t *testing.T) {
+		m := NewMockMonitor().WithFailures(2, errors.New("not ready"))
+
+		reading, err := FirstReading(context.Background(), m, 3, time.Millisecond)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts != 10.0 {
+			t.Errorf("expected Watts=10.0, got %f", reading.Watts)
+		}
+		if m.ReadCount() != 3 {
+			t.Errorf("expected ReadCount=3, got %d", m.ReadCount())
+		}
+	})
+
+	t.Run("retries then fails", func(t *testing.T) {
+		readErr := errors.New("not ready")
+		m := NewMockMonitor().WithError(readErr)
+
+		_, err := FirstReading(context.Background(), m, 2, time.Millisecond)
+		if !errors.Is(err, ErrNoData) {
+			t.Errorf("expected ErrNoData, got %v", err)
+		}
+		if !errors.Is(err, readErr) {
+			t.Errorf("expected error to wrap read error, got %v", err)
+		}
+		if m.ReadCount() != 3 {
+			t.Errorf("expected ReadCount=3, got %d", m.ReadCount())
+		}
+	})
+
+	t.Run("does not retry errors that won't go away", func(t *testing.T) {
+		for _, sentinel := range []error{ErrNeedsPrivilege, ErrUnsupported} {
+			m := NewMockMonitor().WithError(fmt.Errorf("reading: %w", sentinel))
+
+			_, err := FirstReading(context.Background(), m, 3, time.Hour)
+			if !errors.Is(err, ErrNoData) || !errors.Is(err, sentinel) {
+				t.Errorf("expected ErrNoData wrapping %v, got %v", sentinel, err)
+			}
+			if m.ReadCount() != 1 {
+				t.Errorf("%v: expected ReadCount=1, got %d", sentinel, m.ReadCount())
+			}
+		}
+	})
+
+	t.Run("waits for pushed readings without using up retries", func(t *testing.T) {
+		m := NewMockMonitor().WithFailures(5, ErrNoNewReading)
+
+		reading, err := FirstReading(context.Background(), m, 0, time.Millisecond)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reading.Watts != 10.0 {
+			t.Errorf("expected Watts=10.0, got %f", reading.Watts)
+		}
+		if m.ReadCount() != 6 {
+			t.Errorf("expected ReadCount=6, got %d", m.ReadCount())
+		}
+	})
+
+	t.Run("treats readings without timestamp as empty", func(t *testing.T) {
+		empty := &emptyMonitor{MockMonitor: NewMockMonitor()}
+
+		_, err := FirstReading(context.Background(), empty, 1, time.Millisecond)
+		if !errors.Is(err, ErrNoData) {
+			t.Errorf("expected ErrNoData, got %v", err)
+		}
+	})
+
+	t.Run("stops when context is canceled", func(t *testing.T) {
+		m := NewMockMonitor().WithError(errors.New("not ready"))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := FirstReading(ctx, m, 5, time.Hour)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+// emptyMonitor returns zero-value readings without an error.
+type emptyMonitor struct {
+	*MockMonitor
+}
+
+func (m *emptyMonitor) Read(context.Context) (Reading, error) {
+	return Reading{}, nil
+}
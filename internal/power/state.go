@@ -0,0 +1,148 @@
+package power
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SchemaVersion is the version of the JSON that powermon writes for readings
+// (-json and -record lines) and saved history (-state). Input without a
+// version predates versioning and is read as version 1.
+const SchemaVersion = 1
+
+// ErrUnknownSchemaVersion is returned when reading JSON with a schema version
+// this build doesn't understand, such as a file written by a newer powermon.
+var ErrUnknownSchemaVersion = errors.New("unknown schema version")
+
+// checkSchemaVersion returns an error wrapping ErrUnknownSchemaVersion
+// unless v is a version this build can read. Zero means unversioned.
+func checkSchemaVersion(v int) error {
+	if v < 0 || v > SchemaVersion {
+		return fmt.Errorf("%w %d (this powermon reads up to version %d)", ErrUnknownSchemaVersion, v, SchemaVersion)
+	}
+	return nil
+}
+
+// historyState is the persisted form of a History.
+type historyState struct {
+	Version  int       `json:"version"`
+	Readings []Reading `json:"readings"`
+}
+
+// MarshalJSON encodes the readings in h, oldest first.
+func (h *History) MarshalJSON() ([]byte, error) {
+	return json.Marshal(historyState{Version: SchemaVersion, Readings: h.Readings()})
+}
+
+// UnmarshalJSON replaces the readings in h with decoded ones, keeping h's
+// size and time window. Readings that are outside the window as of now are
+// dropped, so state saved long ago doesn't show up as recent history. The
+// moving average starts over from the restored readings, and sequence
+// numbers carry on from the newest one. State with an unknown schema version
+// is rejected without touching h.
+func (h *History) UnmarshalJSON(data []byte) error {
+	var state historyState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if err := checkSchemaVersion(state.Version); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clear(h.buf)
+	h.start, h.count = 0, 0
+	for _, r := range state.Readings {
+		h.push(r)
+	}
+	h.prune(time.Now())
+
+	h.ema, h.hasEMA = 0, false
+	h.seq = 0
+	for _, r := range h.view() {
+		h.updateEMA(r.Watts)
+		h.seq = max(h.seq, r.Seq)
+	}
+	return nil
+}
+
+// StateFile persists a History across restarts. Saves and clears are
+// serialized, so a save can't write back readings that a later clear removed.
+type StateFile struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewStateFile returns a StateFile stored at path.
+func NewStateFile(path string) *StateFile {
+	return &StateFile{path: path}
+}
+
+// Load replaces the readings in h with the saved ones. A missing file is not
+// an error; there's just no previous session to restore.
+func (s *StateFile) Load(h *History) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, h)
+}
+
+// Save atomically replaces the file with the current readings in h. They are
+// written to a temporary file in the same directory and renamed into place,
+// so an interrupted save never leaves a truncated file behind.
+func (s *StateFile) Save(h *History) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// Clear removes the saved readings, so the next Load starts fresh.
+func (s *StateFile) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package power
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistory_JSON(t *testing.T) {
+	t.Run("round-trips readings in order", func(t *testing.T) {
+		now := time.Now()
+		src := NewHistory(100, time.Hour)
+		for i := 0; i < 5; i++ {
+			src.Add(Reading{
+				Watts:          float64(10 + i),
+				WattsAvailable: true,
+				Timestamp:      now.Add(time.Duration(i-5) * time.Second),
+				BatteryPercent: float64(80 - i),
+				Components:     map[string]float64{ComponentCPU: float64(i)},
+				Source:         "mock",
+			})
+		}
+
+		data, err := json.Marshal(src)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		dst := NewHistory(100, time.Hour)
+		if err := json.Unmarshal(data, dst); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want, got := src.Readings(), dst.Readings()
+		if len(got) != len(want) {
+			t.Fatalf("expected %d readings, got %d", len(want), len(got))
+		}
+		for i := range want {
+			if got[i].Watts != want[i].Watts || !got[i].Timestamp.Equal(want[i].Timestamp) ||
+				got[i].BatteryPercent != want[i].BatteryPercent || got[i].Source != want[i].Source ||
+				got[i].Components[ComponentCPU] != want[i].Components[ComponentCPU] {
+				t.Errorf("reading %d = %+v, want %+v", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("drops readings outside the window", func(t *testing.T) {
+		now := time.Now()
+		data, err := json.Marshal(historyState{Readings: []Reading{
+			{Watts: 1, Timestamp: now.Add(-2 * time.Hour)},
+			{Watts: 2, Timestamp: now.Add(-30 * time.Minute)},
+			{Watts: 3, Timestamp: now.Add(-time.Minute)},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		h := NewHistory(100, time.Hour)
+		if err := json.Unmarshal(data, h); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		readings := h.Readings()
+		if len(readings) != 2 || readings[0].Watts != 2 || readings[1].Watts != 3 {
+			t.Errorf("expected the last two readings, got %+v", readings)
+		}
+	})
+
+	t.Run("keeps the newest readings up to the max size", func(t *testing.T) {
+		now := time.Now()
+		src := NewHistory(10, time.Hour)
+		for i := 0; i < 10; i++ {
+			src.Add(Reading{Watts: float64(i), Timestamp: now.Add(time.Duration(i-10) * time.Second)})
+		}
+		data, err := json.Marshal(src)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		h := NewHistory(3, time.Hour)
+		if err := json.Unmarshal(data, h); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		readings := h.Readings()
+		if len(readings) != 3 || readings[0].Watts != 7 || readings[2].Watts != 9 {
+			t.Errorf("expected readings 7-9, got %+v", readings)
+		}
+	})
+
+	t.Run("replaces existing readings", func(t *testing.T) {
+		h := NewHistory(10, time.Hour)
+		h.Add(Reading{Watts: 99, Timestamp: time.Now()})
+		if err := json.Unmarshal([]byte(`{"readings":[]}`), h); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if h.Len() != 0 {
+			t.Errorf("expected no readings, got %d", h.Len())
+		}
+		if ema := h.EMA(); ema != 0 {
+			t.Errorf("expected EMA()=0, got %f", ema)
+		}
+	})
+
+	t.Run("rebuilds the moving average from restored readings", func(t *testing.T) {
+		now := time.Now()
+		src := NewHistory(10, time.Hour)
+		for i, w := range []float64{10, 20, 30} {
+			src.Add(Reading{Watts: w, Timestamp: now.Add(time.Duration(i-3) * time.Second)})
+		}
+		data, err := json.Marshal(src)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		h := NewHistory(10, time.Hour)
+		if err := json.Unmarshal(data, h); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if h.EMA() != src.EMA() {
+			t.Errorf("EMA() = %f, want %f", h.EMA(), src.EMA())
+		}
+	})
+
+	t.Run("continues sequence numbers", func(t *testing.T) {
+		now := time.Now()
+		src := NewHistory(10, time.Hour)
+		for i := 0; i < 3; i++ {
+			src.Add(Reading{Watts: 10, Timestamp: now.Add(time.Duration(i-3) * time.Second)})
+		}
+		data, err := json.Marshal(src)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		h := NewHistory(10, time.Hour)
+		if err := json.Unmarshal(data, h); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if h.Seq() != 3 {
+			t.Errorf("expected Seq()=3 after restoring, got %d", h.Seq())
+		}
+		h.Add(Reading{Watts: 10, Timestamp: now})
+		if latest, _ := h.Latest(); latest.Seq != 4 {
+			t.Errorf("expected the next reading to get Seq=4, got %d", latest.Seq)
+		}
+	})
+
+	t.Run("rejects invalid JSON", func(t *testing.T) {
+		h := NewHistory(10, time.Hour)
+		if err := json.Unmarshal([]byte(`{"readings":`), h); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("writes the schema version", func(t *testing.T) {
+		data, err := json.Marshal(NewHistory(10, time.Hour))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(data), `"version":1`) {
+			t.Errorf("expected version 1, got %s", data)
+		}
+	})
+
+	t.Run("reads version 1 and unversioned state", func(t *testing.T) {
+		ts := time.Now().Add(-time.Second).UTC().Format(time.RFC3339Nano)
+		for _, data := range []string{
+			`{"version":1,"readings":[{"watts":5,"timestamp":"` + ts + `"}]}`,
+			`{"readings":[{"watts":5,"timestamp":"` + ts + `"}]}`,
+		} {
+			h := NewHistory(10, time.Hour)
+			if err := json.Unmarshal([]byte(data), h); err != nil {
+				t.Fatalf("unexpected error for %s: %v", data, err)
+			}
+			if latest, ok := h.Latest(); !ok || latest.Watts != 5 {
+				t.Errorf("expected the 5W reading from %s, got %+v", data, latest)
+			}
+		}
+	})
+
+	t.Run("rejects an unknown version and keeps existing readings", func(t *testing.T) {
+		h := NewHistory(10, time.Hour)
+		h.Add(Reading{Watts: 3, Timestamp: time.Now()})
+
+		err := json.Unmarshal([]byte(`{"version":999,"readings":[{"watts":5}]}`), h)
+		if !errors.Is(err, ErrUnknownSchemaVersion) {
+			t.Fatalf("expected ErrUnknownSchemaVersion, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "999") {
+			t.Errorf("expected the error to name the version, got %q", err)
+		}
+		if latest, _ := h.Latest(); h.Len() != 1 || latest.Watts != 3 {
+			t.Errorf("expected the existing reading to be kept, got %+v", h.Readings())
+		}
+	})
+}
+
+func TestStateFile(t *testing.T) {
+	newHistory := func(watts ...float64) *History {
+		h := NewHistory(100, time.Hour)
+		now := time.Now()
+		for i, w := range watts {
+			h.Add(Reading{Watts: w, Timestamp: now.Add(time.Duration(i-len(watts)) * time.Second)})
+		}
+		return h
+	}
+
+	t.Run("saves and loads", func(t *testing.T) {
+		state := NewStateFile(filepath.Join(t.TempDir(), "state.json"))
+		if err := state.Save(newHistory(1, 2, 3)); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+
+		h := newHistory()
+		if err := state.Load(h); err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		readings := h.Readings()
+		if len(readings) != 3 || readings[0].Watts != 1 || readings[2].Watts != 3 {
+			t.Errorf("expected readings 1-3, got %+v", readings)
+		}
+	})
+
+	t.Run("loading a missing file leaves history empty", func(t *testing.T) {
+		state := NewStateFile(filepath.Join(t.TempDir(), "missing.json"))
+		h := newHistory()
+		if err := state.Load(h); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if h.Len() != 0 {
+			t.Errorf("expected no readings, got %d", h.Len())
+		}
+	})
+
+	t.Run("save leaves no temporary files", func(t *testing.T) {
+		dir := t.TempDir()
+		state := NewStateFile(filepath.Join(dir, "state.json"))
+		for i := 0; i < 3; i++ {
+			if err := state.Save(newHistory(float64(i))); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("expected only the state file, got %v", entries)
+		}
+	})
+
+	t.Run("clear empties the saved readings", func(t *testing.T) {
+		state := NewStateFile(filepath.Join(t.TempDir(), "state.json"))
+		if err := state.Save(newHistory(1, 2, 3)); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if err := state.Clear(); err != nil {
+			t.Fatalf("Clear: %v", err)
+		}
+
+		h := newHistory(42)
+		if err := state.Load(h); err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if h.Len() != 1 {
+			t.Errorf("expected the history to be left alone, got %d readings", h.Len())
+		}
+
+		// Clearing twice is fine
+		if err := state.Clear(); err != nil {
+			t.Errorf("second Clear: %v", err)
+		}
+	})
+
+	t.Run("concurrent saves and clears leave a valid file", func(t *testing.T) {
+		state := NewStateFile(filepath.Join(t.TempDir(), "state.json"))
+		h := newHistory(1, 2, 3)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := state.Save(h); err != nil {
+					t.Errorf("Save: %v", err)
+				}
+			}()
+		}
+		// Clear the history and then the file, as the clear key does
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.Clear()
+			if err := state.Clear(); err != nil {
+				t.Errorf("Clear: %v", err)
+			}
+		}()
+		wg.Wait()
+
+		restored := newHistory()
+		if err := state.Load(restored); err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		// Saves that raced the clear either ran before it and were removed,
+		// or ran after it and wrote the emptied history
+		if restored.Len() != 0 {
+			t.Errorf("expected the cleared readings to stay gone, got %d", restored.Len())
+		}
+	})
+}
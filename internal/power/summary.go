@@ -0,0 +1,106 @@
+package power
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// MinuteSummary aggregates the readings taken within one wall-clock minute.
+type MinuteSummary struct {
+	Start     time.Time
+	Avg       float64
+	Max       float64
+	WattHours float64
+	Samples   int
+}
+
+// SummaryMonitor wraps another Monitor and aggregates every successful reading
+// into per-minute buckets for an end-of-session breakdown. Unlike History it
+// keeps the whole session, but only one small record per minute.
+type SummaryMonitor struct {
+	Monitor
+	mu      sync.Mutex
+	minutes []MinuteSummary
+	sums    []float64
+	last    Reading
+	hasLast bool
+}
+
+// NewSummaryMonitor wraps monitor so that readings are summarized per minute.
+func NewSummaryMonitor(monitor Monitor) *SummaryMonitor {
+	return &SummaryMonitor{Monitor: monitor}
+}
+
+// NeedsSudo reports whether the wrapped monitor needs sudo.
+func (m *SummaryMonitor) NeedsSudo() bool {
+	return NeedsSudo(m.Monitor)
+}
+
+// Close closes the wrapped monitor.
+func (m *SummaryMonitor) Close() error {
+	return Close(m.Monitor)
+}
+
+// Read reads from the wrapped monitor and records the reading on success.
+func (m *SummaryMonitor) Read(ctx context.Context) (Reading, error) {
+	reading, err := m.Monitor.Read(ctx)
+	if ReadFailed(err) {
+		return reading, err
+	}
+	m.Add(reading)
+	return reading, err
+}
+
+// Add records a reading in the bucket for its minute. Readings are expected
+// in chronological order. Energy between two readings is credited to the
+// bucket of the later one.
+func (m *SummaryMonitor) Add(r Reading) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start := r.Timestamp.Truncate(time.Minute)
+	i := len(m.minutes) - 1
+	if i < 0 || !m.minutes[i].Start.Equal(start) {
+		m.minutes = append(m.minutes, MinuteSummary{Start: start})
+		m.sums = append(m.sums, 0)
+		i++
+	}
+
+	b := &m.minutes[i]
+	if b.Samples == 0 || r.Watts > b.Max {
+		b.Max = r.Watts
+	}
+	b.Samples++
+	m.sums[i] += r.Watts
+	b.Avg = m.sums[i] / float64(b.Samples)
+
+	if m.hasLast {
+		b.WattHours += trapezoidWattSeconds(m.last, r) / 3600.0
+	}
+	m.last = r
+	m.hasLast = true
+}
+
+// Minutes returns a copy of the per-minute summaries in chronological order.
+func (m *SummaryMonitor) Minutes() []MinuteSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]MinuteSummary, len(m.minutes))
+	copy(result, m.minutes)
+	return result
+}
+
+// WriteMinuteTable writes minutes to w as an aligned text table.
+func WriteMinuteTable(w io.Writer, minutes []MinuteSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Minute\tAvg (W)\tMax (W)\tEnergy (Wh)\tSamples\t")
+	for _, b := range minutes {
+		fmt.Fprintf(tw, "%s\t%.2f\t%.2f\t%.3f\t%d\t\n",
+			b.Start.Local().Format("15:04"), b.Avg, b.Max, b.WattHours, b.Samples)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,104 @@
+package power
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummaryMonitor(t *testing.T) {
+	t.Run("implements Monitor interface", func(t *testing.T) {
+		var _ Monitor = &SummaryMonitor{}
+	})
+
+	t.Run("buckets readings per minute", func(t *testing.T) {
+		m := NewSummaryMonitor(NewMockMonitor())
+		start := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+
+		// Three minutes of readings every 30s: 10W, 20W, then 30W and 50W
+		watts := []float64{10, 10, 20, 20, 30, 50}
+		for i, w := range watts {
+			m.Add(Reading{Watts: w, Timestamp: start.Add(time.Duration(i) * 30 * time.Second)})
+		}
+
+		minutes := m.Minutes()
+		if len(minutes) != 3 {
+			t.Fatalf("expected 3 rows, got %d", len(minutes))
+		}
+
+		tests := []struct {
+			start     time.Time
+			avg       float64
+			max       float64
+			wattHours float64
+			samples   int
+		}{
+			// The first reading has no predecessor, so only 30s of 10W counts
+			{start, 10, 10, 10 * 30 / 3600.0, 2},
+			// 10W->20W and 20W->20W, each over 30s
+			{start.Add(time.Minute), 20, 20, (15*30 + 20*30) / 3600.0, 2},
+			// 20W->30W and 30W->50W, each over 30s
+			{start.Add(2 * time.Minute), 40, 50, (25*30 + 40*30) / 3600.0, 2},
+		}
+		for i, tt := range tests {
+			got := minutes[i]
+			if !got.Start.Equal(tt.start) {
+				t.Errorf("row %d: Start = %v, want %v", i, got.Start, tt.start)
+			}
+			if got.Avg != tt.avg || got.Max != tt.max || got.Samples != tt.samples {
+				t.Errorf("row %d: got avg=%f max=%f samples=%d, want avg=%f max=%f samples=%d",
+					i, got.Avg, got.Max, got.Samples, tt.avg, tt.max, tt.samples)
+			}
+			if math.Abs(got.WattHours-tt.wattHours) > 1e-9 {
+				t.Errorf("row %d: WattHours = %f, want %f", i, got.WattHours, tt.wattHours)
+			}
+		}
+	})
+
+	t.Run("records successful reads only", func(t *testing.T) {
+		m := NewSummaryMonitor(NewMockMonitor().WithReadings(Reading{Watts: 5, Timestamp: time.Now()}))
+		if _, err := m.Read(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		failing := NewSummaryMonitor(NewMockMonitor().WithError(errors.New("test error")))
+		_, _ = failing.Read(context.Background())
+
+		if len(m.Minutes()) != 1 {
+			t.Errorf("expected 1 row, got %d", len(m.Minutes()))
+		}
+		if len(failing.Minutes()) != 0 {
+			t.Errorf("expected no rows after failed read, got %d", len(failing.Minutes()))
+		}
+	})
+}
+
+func TestWriteMinuteTable(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 0, 0, time.Local)
+	var buf bytes.Buffer
+
+	err := WriteMinuteTable(&buf, []MinuteSummary{
+		{Start: start, Avg: 12.5, Max: 20, WattHours: 0.208, Samples: 60},
+		{Start: start.Add(time.Minute), Avg: 8, Max: 9.25, WattHours: 0.133, Samples: 60},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d lines: %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[1], "03:04") || !strings.Contains(lines[1], "12.50") || !strings.Contains(lines[1], "0.208") {
+		t.Errorf("unexpected first row: %q", lines[1])
+	}
+	for _, line := range lines[1:] {
+		if len(line) != len(lines[0]) {
+			t.Errorf("expected aligned rows, got %q vs header %q", line, lines[0])
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package power
+
+import (
+	"context"
+	"time"
+)
+
+// ReadingResult is the outcome of one read sent by Watch.
+type ReadingResult struct {
+	Reading
+	Err error
+}
+
+// Watch reads from m immediately and then every interval, sending each result
+// on the returned channel until ctx is canceled, when the channel is closed.
+// Reads never overlap: if the receiver falls behind, ticks are dropped rather
+// than queued. Read errors are sent as results rather than stopping the watch.
+// Like time.NewTicker, it panics if interval is not positive.
+func Watch(ctx context.Context, m Monitor, interval time.Duration) <-chan ReadingResult {
+	results := make(chan ReadingResult)
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer close(results)
+		defer ticker.Stop()
+
+		for {
+			reading, err := m.Read(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			select {
+			case results <- ReadingResult{Reading: reading, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results
+}
@@ -0,0 +1,95 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	t.Run("sends readings at the interval", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		mock := NewMockMonitor().WithAutoIncrement(10)
+
+		results := Watch(ctx, mock, 5*time.Millisecond)
+		for i := 0; i < 3; i++ {
+			select {
+			case r := <-results:
+				if r.Err != nil {
+					t.Fatalf("result %d: unexpected error: %v", i, r.Err)
+				}
+				if r.Watts != 10+float64(i) {
+					t.Errorf("result %d: Watts = %f, want %f", i, r.Watts, 10+float64(i))
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for result %d", i)
+			}
+		}
+	})
+
+	t.Run("sends read errors without stopping", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		readErr := errors.New("read failed")
+		mock := NewMockMonitor().WithFailures(1, readErr)
+
+		results := Watch(ctx, mock, time.Millisecond)
+		if r := <-results; !errors.Is(r.Err, readErr) {
+			t.Errorf("expected read error, got %v", r.Err)
+		}
+		if r := <-results; r.Err != nil {
+			t.Errorf("expected recovery after the failure, got %v", r.Err)
+		}
+	})
+
+	t.Run("closes the channel when the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		results := Watch(ctx, NewMockMonitor(), time.Millisecond)
+
+		<-results
+		cancel()
+
+		deadline := time.After(time.Second)
+		for {
+			select {
+			case _, ok := <-results:
+				if !ok {
+					return
+				}
+			case <-deadline:
+				t.Fatal("channel was not closed after cancel")
+			}
+		}
+	})
+
+	t.Run("closes immediately for an already canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		select {
+		case _, ok := <-Watch(ctx, NewMockMonitor(), time.Hour):
+			if ok {
+				t.Error("expected no results for a canceled context")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("channel was not closed")
+		}
+	})
+
+	t.Run("stops a slow read when canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		results := Watch(ctx, NewMockMonitor().WithReadDelay(time.Minute), time.Millisecond)
+
+		cancel()
+		select {
+		case _, ok := <-results:
+			if ok {
+				t.Error("expected the canceled read not to be sent")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("channel was not closed")
+		}
+	})
+}
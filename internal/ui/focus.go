@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"sort"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+// FocusAll shows the total power, the default. The other focuses show a
+// single component's power in its place.
+const FocusAll = "all"
+
+// focusComponents maps the names accepted for Config.Focus to the
+// Reading.Components key they show, or "" for the total.
+var focusComponents = map[string]string{
+	FocusAll: "",
+	"cpu":    power.ComponentCPU,
+	"gpu":    power.ComponentGPU,
+	"ane":    power.ComponentANE,
+}
+
+// Focuses returns the names accepted for Config.Focus, sorted.
+func Focuses() []string {
+	names := make([]string, 0, len(focusComponents))
+	for name := range focusComponents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsFocus reports whether name is a known focus.
+func IsFocus(name string) bool {
+	_, ok := focusComponents[name]
+	return ok
+}
+
+// focusReading returns r with Watts replaced by component's power. A reading
+// without that component, e.g. from a source that doesn't break power down,
+// is marked unavailable rather than showing the total. An empty component
+// leaves r as it is.
+func focusReading(r power.Reading, component string) power.Reading {
+	if component == "" {
+		return r
+	}
+	watts, ok := r.Components[component]
+	r.Watts = watts
+	r.WattsAvailable = r.WattsAvailable && ok
+	return r
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+func TestFocusReading(t *testing.T) {
+	reading := power.Reading{
+		Watts:          9,
+		WattsAvailable: true,
+		Components: map[string]float64{
+			power.ComponentCPU: 4,
+			power.ComponentGPU: 3.5,
+			power.ComponentANE: 1.5,
+		},
+	}
+
+	tests := []struct {
+		focus         string
+		wantWatts     float64
+		wantAvailable bool
+	}{
+		{FocusAll, 9, true},
+		{"cpu", 4, true},
+		{"gpu", 3.5, true},
+		{"ane", 1.5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.focus, func(t *testing.T) {
+			got := focusReading(reading, focusComponents[tt.focus])
+			if got.Watts != tt.wantWatts || got.WattsAvailable != tt.wantAvailable {
+				t.Errorf("got %v W (available %v), want %v W (available %v)",
+					got.Watts, got.WattsAvailable, tt.wantWatts, tt.wantAvailable)
+			}
+		})
+	}
+
+	t.Run("missing component is unavailable", func(t *testing.T) {
+		got := focusReading(power.Reading{Watts: 9, WattsAvailable: true}, power.ComponentGPU)
+		if got.Watts != 0 || got.WattsAvailable {
+			t.Errorf("expected an unavailable reading, got %v W (available %v)", got.Watts, got.WattsAvailable)
+		}
+	})
+
+	t.Run("names", func(t *testing.T) {
+		want := []string{"all", "ane", "cpu", "gpu"}
+		if got := Focuses(); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Focuses() = %v, want %v", got, want)
+		}
+		if !IsFocus("gpu") || IsFocus("dsp") {
+			t.Error("IsFocus gave the wrong answer")
+		}
+	})
+}
+
+func TestModel_Focus(t *testing.T) {
+	cfg := DefaultConfig(power.NewMockMonitor())
+	cfg.Focus = "gpu"
+	m := NewModel(cfg)
+	m.ready = true
+
+	now := time.Now()
+	for i, gpu := range []float64{2, 6} {
+		newM, _ := m.Update(readingMsg{reading: power.Reading{
+			Watts:          20,
+			WattsAvailable: true,
+			Timestamp:      now.Add(time.Duration(i) * time.Second),
+			Components:     map[string]float64{power.ComponentCPU: 10, power.ComponentGPU: gpu},
+		}})
+		m = newM.(Model)
+	}
+
+	if avg := m.history.Average(); avg != 4 {
+		t.Errorf("expected the history to track GPU power, got average %v", avg)
+	}
+	if m.lastReading.Watts != 6 {
+		t.Errorf("expected the current power to show GPU power, got %v", m.lastReading.Watts)
+	}
+	if !strings.Contains(m.renderGraph(), "gpu only") {
+		t.Error("expected the graph header to name the focus")
+	}
+}
@@ -0,0 +1,66 @@
+package ui
+
+import "sort"
+
+// DefaultGraphChars is the graph character set used when none is configured.
+const DefaultGraphChars = "blocks"
+
+// graphBlocks are the sparkline characters from lowest to highest.
+var graphBlocks = []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+// Reference line characters, drawn in the cells of a row that no bar covers.
+const (
+	avgLineChar   = '╌'
+	alertLineChar = '┅'
+)
+
+// graphCharsets maps the names accepted for Config.GraphChars to their
+// characters, from lowest to highest. The last character fills a whole cell
+// in graphs taller than one row.
+var graphCharsets = map[string][]rune{
+	"blocks": graphBlocks,
+	// Plain ASCII for terminals and SSH sessions with limited fonts
+	"ascii": []rune(".:-=+*#@"),
+	// Braille dots filling in from the bottom of the cell
+	"braille": {'⡀', '⣀', '⣄', '⣤', '⣦', '⣶', '⣷', '⣿'},
+}
+
+// GraphCharsets returns the names accepted for Config.GraphChars, sorted.
+func GraphCharsets() []string {
+	names := make([]string, 0, len(graphCharsets))
+	for name := range graphCharsets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsGraphCharset reports whether name is a known graph character set.
+func IsGraphCharset(name string) bool {
+	_, ok := graphCharsets[name]
+	return ok
+}
+
+// graphCharset returns the characters for name, falling back to the
+// default set for empty or unknown names.
+func graphCharset(name string) []rune {
+	if chars, ok := graphCharsets[name]; ok {
+		return chars
+	}
+	return graphCharsets[DefaultGraphChars]
+}
+
+// graphCell returns the character from chars for one row of a graph column
+// that fills level steps of a cell, counting rows up from 0 at the bottom.
+// Rows below the top of the column are full and rows above it are blank.
+func graphCell(chars []rune, level, row int) rune {
+	fill := level - row*len(chars)
+	switch {
+	case fill <= 0:
+		return ' '
+	case fill >= len(chars):
+		return chars[len(chars)-1]
+	default:
+		return chars[fill-1]
+	}
+}
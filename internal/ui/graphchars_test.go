@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+func TestGraphCell(t *testing.T) {
+	tests := []struct {
+		level, row int
+		want       rune
+	}{
+		{level: 1, row: 0, want: '▁'},
+		{level: 8, row: 0, want: '█'},
+		{level: 12, row: 0, want: '█'},
+		{level: 12, row: 1, want: '▄'},
+		{level: 12, row: 2, want: ' '},
+		{level: 16, row: 1, want: '█'},
+	}
+	for _, tt := range tests {
+		if got := graphCell(graphBlocks, tt.level, tt.row); got != tt.want {
+			t.Errorf("graphCell(%d, %d) = %q, want %q", tt.level, tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestGraphCharsets(t *testing.T) {
+	t.Run("min and max map to the first and last characters", func(t *testing.T) {
+		for _, name := range GraphCharsets() {
+			chars := graphCharset(name)
+			if got := graphCell(chars, 1, 0); got != chars[0] {
+				t.Errorf("%s: lowest level = %q, want %q", name, got, chars[0])
+			}
+			if got := graphCell(chars, len(chars), 0); got != chars[len(chars)-1] {
+				t.Errorf("%s: highest level = %q, want %q", name, got, chars[len(chars)-1])
+			}
+		}
+	})
+
+	t.Run("names", func(t *testing.T) {
+		want := []string{"ascii", "blocks", "braille"}
+		if got := GraphCharsets(); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("GraphCharsets() = %v, want %v", got, want)
+		}
+		if !IsGraphCharset("ascii") || IsGraphCharset("emoji") {
+			t.Error("IsGraphCharset gave the wrong answer")
+		}
+	})
+
+	t.Run("unknown names use the default", func(t *testing.T) {
+		for _, name := range []string{"", "emoji"} {
+			if got := graphCharset(name); string(got) != string(graphBlocks) {
+				t.Errorf("graphCharset(%q) = %q, want the default blocks", name, string(got))
+			}
+		}
+	})
+
+	t.Run("graph only uses the configured characters", func(t *testing.T) {
+		for _, name := range GraphCharsets() {
+			cfg := DefaultConfig(power.NewMockMonitor())
+			cfg.GraphChars = name
+			cfg.GraphHeight = 3
+			cfg.NoColor = true
+			m := NewModel(cfg)
+			now := time.Now()
+			for i, w := range []float64{5, 40, 12, 80, 33, 60} {
+				m.history.Add(power.Reading{Watts: w, Timestamp: now.Add(time.Duration(i) * time.Second)})
+			}
+
+			lines := strings.Split(m.renderGraph(), "\n")
+			allowed := string(graphCharset(name)) + " "
+			for _, row := range lines[1 : len(lines)-1] {
+				if strings.Trim(row, allowed) != "" {
+					t.Errorf("%s: unexpected characters in row %q", name, row)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Action is something a key press can trigger in the UI.
+type Action string
+
+// Actions that can be bound to keys.
+const (
+	ActionQuit   Action = "quit"
+	ActionClear  Action = "clear"
+	ActionPause  Action = "pause"
+	ActionEnergy Action = "energy"
+	ActionUnit   Action = "unit"
+	// ActionLonger and ActionShorter step the history window through
+	// HistoryWindows.
+	ActionLonger  Action = "longer"
+	ActionShorter Action = "shorter"
+	ActionHelp    Action = "help"
+	// ActionFaster and ActionSlower halve and double the refresh interval,
+	// between MinRefreshInterval and MaxRefreshInterval.
+	ActionFaster Action = "faster"
+	ActionSlower Action = "slower"
+	// ActionBaseline sets the average power as a baseline that the current
+	// reading and graph are shown relative to, or clears it if one is set.
+	ActionBaseline Action = "baseline"
+)
+
+// actionDescriptions lists every action in the order the help overlay
+// shows them, with what each one does.
+var actionDescriptions = []struct {
+	action      Action
+	description string
+}{
+	{ActionQuit, "quit"},
+	{ActionPause, "pause or resume sampling"},
+	{ActionClear, "clear history"},
+	{ActionEnergy, "toggle the energy graph"},
+	{ActionUnit, "change units"},
+	{ActionLonger, "lengthen the history window"},
+	{ActionShorter, "shorten the history window"},
+	{ActionFaster, "refresh twice as often"},
+	{ActionSlower, "refresh half as often"},
+	{ActionBaseline, "show power relative to the average, or stop"},
+	{ActionHelp, "show or hide this help"},
+}
+
+// forceQuitKey always quits, regardless of the keymap, so a bad keymap can
+// never trap the user.
+const forceQuitKey = "ctrl+c"
+
+// helpCloseKey also closes the help overlay, alongside ActionHelp's keys.
+const helpCloseKey = "esc"
+
+// KeyMap maps actions to the keys that trigger them. Keys use Bubble Tea's
+// key names (e.g. "q", "ctrl+x", " " for space).
+type KeyMap map[Action][]string
+
+// DefaultKeyMap returns the built-in key bindings.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		ActionQuit:   {"q"},
+		ActionClear:  {"c"},
+		ActionPause:  {"p", " "},
+		ActionEnergy: {"e"},
+		ActionUnit:   {"u"},
+		// "=" shares a key with "+" on most layouts, so shift isn't needed
+		ActionLonger:  {"+", "="},
+		ActionShorter: {"-"},
+		ActionHelp:    {"?"},
+		ActionFaster:  {"["},
+		ActionSlower:  {"]"},
+		// Baseline subtraction, for the marginal cost of a workload
+		ActionBaseline: {"b"},
+	}
+}
+
+// Validate checks that every action is known, has at least one key, and
+// that no key is bound to more than one action.
+func (k KeyMap) Validate() error {
+	known := DefaultKeyMap()
+	owner := map[string]Action{forceQuitKey: ActionQuit}
+	for _, action := range k.actions() {
+		if _, ok := known[action]; !ok {
+			return fmt.Errorf("unknown action %q", action)
+		}
+		if len(k[action]) == 0 {
+			return fmt.Errorf("action %q has no keys", action)
+		}
+		for _, key := range k[action] {
+			if other, ok := owner[key]; ok && other != action {
+				return fmt.Errorf("key %q is bound to both %q and %q", displayKey(key), other, action)
+			}
+			owner[key] = action
+		}
+	}
+	return nil
+}
+
+// LoadKeyMap reads key bindings from r, starting from the defaults. Each
+// non-empty line has the form "action = key[, key...]"; lines starting with
+// "#" are comments. "space" may be used for the space bar.
+func LoadKeyMap(r io.Reader) (KeyMap, error) {
+	keys := DefaultKeyMap()
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"action = key\"", lineNum)
+		}
+
+		var bound []string
+		for _, key := range strings.Split(value, ",") {
+			key = strings.TrimSpace(key)
+			if key == "space" {
+				key = " "
+			}
+			if key != "" {
+				bound = append(bound, key)
+			}
+		}
+		keys[Action(strings.TrimSpace(name))] = bound
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := keys.Validate(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// lookup returns a key-to-action table for use in Update.
+func (k KeyMap) lookup() map[string]Action {
+	table := map[string]Action{forceQuitKey: ActionQuit}
+	for action, keys := range k {
+		for _, key := range keys {
+			table[key] = action
+		}
+	}
+	return table
+}
+
+// primaryKey returns the display name of the first key bound to action.
+func (k KeyMap) primaryKey(action Action) string {
+	if keys := k[action]; len(keys) > 0 {
+		return displayKey(keys[0])
+	}
+	return ""
+}
+
+// actions returns the bound actions in a stable order.
+func (k KeyMap) actions() []Action {
+	actions := make([]Action, 0, len(k))
+	for action := range k {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i] < actions[j] })
+	return actions
+}
+
+// displayKeys returns the human-readable names of every key bound to
+// action, separated by slashes.
+func (k KeyMap) displayKeys(action Action) string {
+	names := make([]string, len(k[action]))
+	for i, key := range k[action] {
+		names[i] = displayKey(key)
+	}
+	return strings.Join(names, "/")
+}
+
+// displayKey returns a human-readable name for a key.
+func displayKey(key string) string {
+	if key == " " {
+		return "space"
+	}
+	return key
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultKeyMap(t *testing.T) {
+	if err := DefaultKeyMap().Validate(); err != nil {
+		t.Errorf("expected default keymap to be valid, got %v", err)
+	}
+}
+
+func TestActionDescriptions(t *testing.T) {
+	described := map[Action]bool{}
+	for _, d := range actionDescriptions {
+		described[d.action] = true
+	}
+	for action := range DefaultKeyMap() {
+		if !described[action] {
+			t.Errorf("action %q has no help description", action)
+		}
+	}
+}
+
+func TestLoadKeyMap(t *testing.T) {
+	t.Run("overrides listed actions only", func(t *testing.T) {
+		keys, err := LoadKeyMap(strings.NewReader("# comment\n\nquit = x, ctrl+q\npause=space\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		lookup := keys.lookup()
+		tests := map[string]Action{
+			"x":      ActionQuit,
+			"ctrl+q": ActionQuit,
+			"ctrl+c": ActionQuit,
+			" ":      ActionPause,
+			"c":      ActionClear,
+			"e":      ActionEnergy,
+		}
+		for key, want := range tests {
+			if got := lookup[key]; got != want {
+				t.Errorf("key %q: got action %q, want %q", key, got, want)
+			}
+		}
+		if _, ok := lookup["q"]; ok {
+			t.Error("expected 'q' to be unbound")
+		}
+	})
+
+	t.Run("rejects invalid keymaps", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input string
+		}{
+			{"conflicting keys", "quit = c"},
+			{"ctrl+c rebound", "clear = ctrl+c"},
+			{"unknown action", "explode = x"},
+			{"no keys", "quit ="},
+			{"missing equals", "quit x"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, err := LoadKeyMap(strings.NewReader(tt.input)); err == nil {
+					t.Errorf("expected error for %q", tt.input)
+				}
+			})
+		}
+	})
+}
+
+func TestKeyMap_PrimaryKey(t *testing.T) {
+	keys := KeyMap{ActionPause: {" ", "p"}}
+
+	if got := keys.primaryKey(ActionPause); got != "space" {
+		t.Errorf("expected space, got %q", got)
+	}
+	if got := keys.primaryKey(ActionQuit); got != "" {
+		t.Errorf("expected empty key for unbound action, got %q", got)
+	}
+}
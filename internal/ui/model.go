@@ -3,10 +3,16 @@ package ui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,116 +26,403 @@ const (
 	DefaultGraphWidth = 60
 	// DefaultGraphHeight is the default height of the power graph in characters.
 	DefaultGraphHeight = 12
+	// DefaultMaxGraphWidth and DefaultMaxGraphHeight cap how far the graph
+	// grows to fill the terminal, in characters.
+	DefaultMaxGraphWidth  = 1000
+	DefaultMaxGraphHeight = 200
 	// DefaultRefreshInterval is the default interval between power readings.
 	DefaultRefreshInterval = 1 * time.Second
 	// DefaultHistoryDuration is how long to keep readings for the graph.
 	DefaultHistoryDuration = 2 * time.Minute
+	// DefaultPeakWindow is how far back the stats line looks for the peak.
+	DefaultPeakWindow = 10 * time.Second
+	// DefaultBatteryHighThreshold and DefaultBatteryLowThreshold are the
+	// battery percentages at and above which the battery indicator turns
+	// from the low to the medium color and from medium to high.
+	DefaultBatteryHighThreshold = 60.0
+	DefaultBatteryLowThreshold  = 20.0
+	// DefaultReadTimeout is the longest a single reading may take.
+	DefaultReadTimeout = 5 * time.Second
+	// MinRefreshInterval and MaxRefreshInterval bound the refresh interval
+	// the faster and slower keys step through.
+	MinRefreshInterval = 250 * time.Millisecond
+	MaxRefreshInterval = 10 * time.Second
 )
 
-// Colors and styles
-var (
-	// Title style
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4")).
-			MarginBottom(1)
-
-	// Box style for the main display
-	boxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#7D56F4")).
-			Padding(1, 2)
-
-	// Current power display
-	powerStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#00FF00"))
-
-	// Stats labels
-	labelStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888"))
-
-	// Stats values
-	valueStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FFFFFF"))
-
-	// Trend indicators
-	trendUpStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FF5555"))
-
-	trendDownStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#55FF55"))
-
-	trendStableStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FFFF55"))
-
-	// Graph colors
-	graphBarStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#7D56F4"))
-
-	graphAxisStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#555555"))
-
-	// Battery indicator colors
-	batteryHighStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("#55FF55"))
-
-	batteryMedStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FFFF55"))
-
-	batteryLowStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FF5555"))
-
-	// Error style
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FF5555"))
-
-	// Help style
-	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#555555")).
-			MarginTop(1)
+// minReadTimeout is the shortest read timeout derived from the refresh
+// interval, so fast intervals still leave time to spawn a process.
+const minReadTimeout = 2 * time.Second
+
+// GraphScale selects how values map to bar heights.
+type GraphScale string
+
+const (
+	// GraphScaleLinear maps values to bar heights linearly.
+	GraphScaleLinear GraphScale = "linear"
+	// GraphScaleLog maps values logarithmically, so variation at low power
+	// stays visible next to bursts that are orders of magnitude higher.
+	GraphScaleLog GraphScale = "log"
 )
 
+// logScaleFloor is the smallest value the log scale distinguishes; zero and
+// anything below it are drawn as the lowest bar.
+const logScaleFloor = 0.01
+
+// graphMode selects which series the graph plots.
+type graphMode int
+
+const (
+	// graphModePower plots instantaneous power in watts.
+	graphModePower graphMode = iota
+	// graphModeEnergy plots cumulative energy in watt-hours.
+	graphModeEnergy
+)
+
+// staticText holds the parts of a frame that only depend on the theme and
+// keymap. They're rendered once in NewModel so View doesn't restyle them on
+// every frame.
+type staticText struct {
+	title      string
+	help       string
+	pausedHelp string
+	sudoTip    string
+	// helpOverlay is the full key binding list shown in place of the
+	// normal view while help is open.
+	helpOverlay string
+
+	avgLabel     string
+	minLabel     string
+	maxLabel     string
+	peakLabel    string
+	peakShort    string
+	samplesLabel string
+	energyLabel  string
+	energyShort  string
+	sourceLabel  string
+	monitorLabel string
+	batteryLabel string
+	healthLabel  string
+	cyclesLabel  string
+	adapterLabel string
+	drainLabel   string
+	acLabel      string
+	onBattery    string
+	onAC         string
+
+	trendUp     string
+	trendDown   string
+	trendStable string
+
+	// Border pieces for renderBox
+	border      lipgloss.Border
+	borderStyle lipgloss.Style
+	borderLeft  string
+	borderRight string
+
+	// gradientOpen holds the escape sequence that switches to each color of
+	// the graph gradient and gradientClose the one that switches back, so
+	// runs of bars can be colored without a render each
+	gradientOpen  [gradientSteps]string
+	gradientClose string
+}
+
+// newStaticText renders the static parts of a frame.
+func newStaticText(theme Theme, keyMap KeyMap) staticText {
+	help := fmt.Sprintf("Press '%s' to quit • '%s' to pause • '%s' to clear history • '%s' to toggle energy graph • '%s' to change units • '%s'/'%s' to change the window • '%s' for all keys",
+		keyMap.primaryKey(ActionQuit), keyMap.primaryKey(ActionPause),
+		keyMap.primaryKey(ActionClear), keyMap.primaryKey(ActionEnergy),
+		keyMap.primaryKey(ActionUnit), keyMap.primaryKey(ActionLonger),
+		keyMap.primaryKey(ActionShorter), keyMap.primaryKey(ActionHelp))
+	pausedHelp := fmt.Sprintf("⏸ paused • Press '%s' to resume • '%s' to quit",
+		keyMap.primaryKey(ActionPause), keyMap.primaryKey(ActionQuit))
+
+	border := theme.box.GetBorderStyle()
+	borderStyle := lipgloss.NewStyle().Foreground(theme.box.GetBorderTopForeground())
+
+	title := theme.title.Render("⚡ Power Monitor")
+
+	var gradientOpen [gradientSteps]string
+	var gradientClose string
+	for i := range gradientOpen {
+		style := theme.graphBar.Foreground(gradientColor(float64(i) / (gradientSteps - 1)))
+		gradientOpen[i], gradientClose, _ = strings.Cut(style.Render("x"), "x")
+	}
+
+	return staticText{
+		title:       title,
+		help:        theme.help.Render(help),
+		pausedHelp:  theme.help.Render(pausedHelp),
+		helpOverlay: newHelpOverlay(theme, keyMap, title),
+		sudoTip: theme.label.Render("💡 Tip: Run with sudo for power data on desktop Macs:") + "\n" +
+			theme.value.Render("   sudo powermon"),
+
+		avgLabel:     theme.label.Render("Avg: "),
+		minLabel:     theme.label.Render("Min: "),
+		maxLabel:     theme.label.Render("Max: "),
+		samplesLabel: theme.label.Render("Samples: "),
+		energyLabel:  theme.label.Render("Energy: "),
+		energyShort:  theme.label.Render("E: "),
+		peakShort:    theme.label.Render("Peak: "),
+		sourceLabel:  theme.label.Render("Source: "),
+		monitorLabel: theme.label.Render("Monitor: "),
+		acLabel:      theme.label.Render("AC: "),
+		batteryLabel: theme.label.Render("Battery: "),
+		healthLabel:  theme.label.Render("Health: "),
+		cyclesLabel:  theme.label.Render("Cycles: "),
+		adapterLabel: theme.label.Render("Adapter: "),
+		drainLabel:   theme.label.Render("Drain: "),
+		onBattery:    theme.value.Render("Battery"),
+		onAC:         theme.value.Render("AC Power"),
+
+		trendUp:     theme.trendUp.Render(" ▲ increasing"),
+		trendDown:   theme.trendDown.Render(" ▼ decreasing"),
+		trendStable: theme.trendStable.Render(" ● stable"),
+
+		border:      border,
+		borderStyle: borderStyle,
+		borderLeft:  borderStyle.Render(border.Left),
+		borderRight: borderStyle.Render(border.Right),
+
+		gradientOpen:  gradientOpen,
+		gradientClose: gradientClose,
+	}
+}
+
+// newHelpOverlay renders the help overlay: every action with the keys bound
+// to it, in the order of actionDescriptions.
+func newHelpOverlay(theme Theme, keyMap KeyMap, title string) string {
+	type binding struct{ keys, description string }
+	bindings := make([]binding, 0, len(actionDescriptions)+1)
+	for _, d := range actionDescriptions {
+		if keys := keyMap.displayKeys(d.action); keys != "" {
+			bindings = append(bindings, binding{keys, d.description})
+		}
+	}
+	bindings = append(bindings, binding{forceQuitKey, "always quit"})
+
+	keysWidth := 0
+	for _, b := range bindings {
+		keysWidth = max(keysWidth, lipgloss.Width(b.keys))
+	}
+
+	var b strings.Builder
+	b.WriteString(title)
+	b.WriteString("\n\n")
+	b.WriteString(theme.label.Render("Key bindings"))
+	b.WriteString("\n\n")
+	for _, binding := range bindings {
+		pad := strings.Repeat(" ", keysWidth-lipgloss.Width(binding.keys))
+		b.WriteString("  ")
+		b.WriteString(theme.value.Render(binding.keys))
+		b.WriteString(pad)
+		b.WriteString("  ")
+		b.WriteString(binding.description)
+		b.WriteString("\n")
+	}
+	b.WriteString(theme.help.Render(fmt.Sprintf("Press '%s' or '%s' to close",
+		keyMap.primaryKey(ActionHelp), helpCloseKey)))
+	return b.String()
+}
+
 // tickMsg is sent periodically to trigger power reading updates.
 type tickMsg time.Time
 
+// slowReadFraction is the fraction of the refresh interval a read may take
+// before the UI warns that timestamps may be skewed.
+const slowReadFraction = 0.5
+
 // readingMsg contains a new power reading.
 type readingMsg struct {
 	reading power.Reading
 	err     error
+	latency time.Duration
+}
+
+// durationElapsedMsg is sent once Config.Duration has passed.
+type durationElapsedMsg struct{}
+
+// stateClearedMsg reports the result of emptying the state file.
+type stateClearedMsg struct {
+	err error
+}
+
+// intervalChangedMsg reports the result of Config.OnIntervalChange.
+type intervalChangedMsg struct {
+	err error
 }
 
 // Model represents the UI state.
 type Model struct {
 	monitor         power.Monitor
 	history         *power.History
+	filter          *power.OutlierFilter
 	spinner         spinner.Model
 	width           int
 	height          int
 	graphWidth      int
 	graphHeight     int
+	maxGraphWidth   int
+	maxGraphHeight  int
 	refreshInterval time.Duration
+	readTimeout     time.Duration
+	autoReadTimeout bool // True if readTimeout follows refreshInterval
+	graphWindow     time.Duration
+	statsWindow     time.Duration
+	peakWindow      time.Duration
+	graphScale      GraphScale
+	graphChars      []rune
+	referenceLines  bool
+	maxWatts        float64
+	focus           string // Component shown in place of the total, if any
+	graphMode       graphMode
+	unit            wattUnit
+	titleMetric     string
+	graphValue      bool
+	smoothWindow    int
+	highlightAvg    bool
+	overhead        float64
+	alertThreshold  float64
+	alertActive     bool // True while readings stay above alertThreshold
+	baseline        float64
+	baselineSet     bool // True while power is shown relative to baseline
+	batteryHigh     float64
+	batteryLow      float64
+	clearStateFile  *power.StateFile
+	onReading       func(power.Reading)
+	onInterval      func(time.Duration) error
+	ctx             context.Context // Parent of each read's timeout
+	duration        time.Duration   // Quit after this long, if positive
 	lastReading     power.Reading
 	lastError       error
+	lastLatency     time.Duration
+	paused          bool
+	showHelp        bool // True while the help overlay replaces the view
+	theme           Theme
+	static          staticText
+	keys            map[string]Action
 	quitting        bool
 	ready           bool
 	needsSudo       bool // True if running on desktop Mac without sudo
+	quiet           bool // Hide the sudo tip and dim warnings
 }
 
 // Config holds configuration options for the UI.
 type Config struct {
-	Monitor         power.Monitor
+	Monitor power.Monitor
+	// GraphWidth and GraphHeight size the graph until the terminal's size is
+	// known. From then on it fills the terminal, up to MaxGraphWidth and
+	// MaxGraphHeight; zero maxima use DefaultMaxGraphWidth and
+	// DefaultMaxGraphHeight.
 	GraphWidth      int
 	GraphHeight     int
+	MaxGraphWidth   int
+	MaxGraphHeight  int
 	RefreshInterval time.Duration
 	HistoryDuration time.Duration
 	MaxHistorySize  int
+	// MaxAbsDelta and MaxRelDelta configure outlier rejection. A reading is
+	// dropped when it differs from the recent median by more than both
+	// MaxAbsDelta watts and the MaxRelDelta fraction. Zero disables a check.
+	MaxAbsDelta float64
+	MaxRelDelta float64
+	// Focus shows a single component's power in place of the total; see
+	// Focuses. Readings without that component show as unavailable. Empty
+	// or FocusAll shows the total.
+	Focus string
+	// MaxWatts is the highest plausible reading; higher ones are clamped to
+	// it before they reach the history. See power.Reading.Sanitize; zero uses
+	// power.DefaultMaxWatts.
+	MaxWatts float64
+	// TitleMetric names a secondary value to show next to the title. See
+	// TitleMetrics for accepted names; empty shows nothing.
+	TitleMetric string
+	// KeyMap overrides the default key bindings. It should already be
+	// validated; nil uses DefaultKeyMap.
+	KeyMap KeyMap
+	// NoColor renders the UI with PlainTheme instead of the colored default.
+	NoColor bool
+	// Quiet hides the tip to run with sudo and shows read errors and slow
+	// read warnings as a single dim line, e.g. for screenshots and
+	// recordings. Power data still renders.
+	Quiet bool
+	// GraphValue appends the latest value as text after the graph's last bar.
+	GraphValue bool
+	// SmoothWindow is the width of the centered moving average applied to the
+	// graph. Values of 1 or less disable smoothing; stats are unaffected.
+	SmoothWindow int
+	// HighlightAverage colors graph bars above the average differently from
+	// those at or below it, using AboveAverageColor and BelowAverageColor.
+	// Empty colors use the theme defaults; NoColor ignores them.
+	HighlightAverage  bool
+	AboveAverageColor string
+	BelowAverageColor string
+	// GraphWindow limits the graph to the most recent readings within this
+	// duration, while stats keep using the whole HistoryDuration. Zero plots
+	// the whole history.
+	GraphWindow time.Duration
+	// StatsWindow limits the average, min, max and trend to the readings
+	// within this duration of the newest one, while the graph keeps showing
+	// the whole history. Zero uses the whole history for both.
+	StatsWindow time.Duration
+	// PeakWindow shows the highest reading within this duration of the
+	// newest one next to the stats, to catch short spikes that the overall
+	// max would hide once a bigger one is in the history. Zero hides it.
+	PeakWindow time.Duration
+	// EMAAlpha weights each new reading in the history's exponential moving
+	// average, shown by the "ema" title metric; see power.History.SetEMAAlpha.
+	// Zero uses power.DefaultEMAAlpha.
+	EMAAlpha float64
+	// GraphScale is GraphScaleLinear or GraphScaleLog; empty means linear.
+	GraphScale GraphScale
+	// ReferenceLines draws a dashed line across a multi-row power graph at
+	// the average, and another at AlertThreshold when it's in range.
+	ReferenceLines bool
+	// GraphChars names the characters bars are drawn with; see
+	// GraphCharsets. Empty or unknown names use DefaultGraphChars.
+	GraphChars string
+	// ReadTimeout bounds each monitor read. Zero derives it from
+	// RefreshInterval; see ReadTimeoutFor.
+	ReadTimeout time.Duration
+	// Context is the parent of every monitor read. Canceling it, e.g. when
+	// the program quits, abandons any read in flight; background samplers
+	// started alongside the UI should share it so they stop too. Nil uses
+	// context.Background.
+	Context context.Context
+	// Overhead is powermon's own estimated draw in watts, measured with
+	// -calibrate and already subtracted from readings. Zero hides it.
+	Overhead float64
+	// AlertThreshold highlights the current power in the alert color and
+	// rings the terminal bell once each time a reading rises above it. Zero
+	// disables alerts.
+	AlertThreshold float64
+	// BatteryHighThreshold and BatteryLowThreshold color the battery
+	// indicator: the high color at or above BatteryHighThreshold percent,
+	// the low color below BatteryLowThreshold, and the medium color in
+	// between. Zero uses DefaultBatteryHighThreshold and
+	// DefaultBatteryLowThreshold.
+	BatteryHighThreshold float64
+	BatteryLowThreshold  float64
+	// History holds the readings to show, e.g. ones restored from a
+	// StateFile. Nil starts an empty history from HistoryDuration and
+	// MaxHistorySize.
+	History *power.History
+	// ClearStateFile, if set, is emptied along with the history by the clear
+	// key, so a restart doesn't bring cleared readings back.
+	ClearStateFile *power.StateFile
+	// Duration quits the UI once this much time has passed since it started.
+	// Zero runs until the user quits.
+	Duration time.Duration
+	// OnReading, if set, is called with each reading as it's added to the
+	// history, so a program embedding the UI can act on every sample.
+	// Failed reads, rejected outliers and readings that arrive while paused
+	// are skipped. It runs on the UI's update loop, so it should return
+	// quickly.
+	OnReading func(power.Reading)
+	// OnIntervalChange, if set, is called in the background with the new
+	// refresh interval whenever it's changed from the keyboard, e.g. to
+	// restart a sampler running at the old rate. An error it returns is
+	// shown in place of the last read error.
+	OnIntervalChange func(time.Duration) error
 }
 
 // DefaultConfig returns a Config with default values.
@@ -141,9 +434,30 @@ func DefaultConfig(monitor power.Monitor) Config {
 		RefreshInterval: DefaultRefreshInterval,
 		HistoryDuration: DefaultHistoryDuration,
 		MaxHistorySize:  300, // 5 minutes at 1s intervals
+		PeakWindow:      DefaultPeakWindow,
+		EMAAlpha:        power.DefaultEMAAlpha,
+
+		BatteryHighThreshold: DefaultBatteryHighThreshold,
+		BatteryLowThreshold:  DefaultBatteryLowThreshold,
 	}
 }
 
+// HistoryWindows are the history windows the longer and shorter keys step
+// through.
+var HistoryWindows = []time.Duration{
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+}
+
+// HistorySize returns how many readings a history window holds at interval,
+// with some slack for readings that arrive early.
+func HistorySize(window, interval time.Duration) int {
+	return int(window.Seconds()/interval.Seconds()) + 100
+}
+
 // SudoChecker is an optional interface for monitors that may need sudo.
 type SudoChecker interface {
 	NeedsSudo() bool
@@ -153,7 +467,19 @@ type SudoChecker interface {
 func NewModel(cfg Config) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+
+	theme := DefaultTheme()
+	if cfg.NoColor {
+		theme = PlainTheme()
+	} else {
+		if cfg.AboveAverageColor != "" {
+			theme.graphAbove = theme.graphAbove.Foreground(lipgloss.Color(cfg.AboveAverageColor))
+		}
+		if cfg.BelowAverageColor != "" {
+			theme.graphBelow = theme.graphBelow.Foreground(lipgloss.Color(cfg.BelowAverageColor))
+		}
+	}
+	s.Style = theme.accent
 
 	// Check if monitor needs sudo for full functionality
 	var needsSudo bool
@@ -161,14 +487,94 @@ func NewModel(cfg Config) Model {
 		needsSudo = checker.NeedsSudo()
 	}
 
+	// Only filter readings if a threshold was configured
+	var filter *power.OutlierFilter
+	if cfg.MaxAbsDelta > 0 || cfg.MaxRelDelta > 0 {
+		filter = power.NewOutlierFilter(power.DefaultFilterWindow, cfg.MaxAbsDelta, cfg.MaxRelDelta)
+	}
+
+	keyMap := cfg.KeyMap
+	if keyMap == nil {
+		keyMap = DefaultKeyMap()
+	}
+
+	history := cfg.History
+	if history == nil {
+		history = power.NewHistory(cfg.MaxHistorySize, cfg.HistoryDuration)
+	}
+	history.SetEMAAlpha(cfg.EMAAlpha)
+
+	readTimeout := cfg.ReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = ReadTimeoutFor(cfg.RefreshInterval)
+	}
+
+	ctx := cfg.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	maxGraphWidth := cfg.MaxGraphWidth
+	if maxGraphWidth <= 0 {
+		maxGraphWidth = DefaultMaxGraphWidth
+	}
+	maxGraphHeight := cfg.MaxGraphHeight
+	if maxGraphHeight <= 0 {
+		maxGraphHeight = DefaultMaxGraphHeight
+	}
+
+	batteryHigh := cfg.BatteryHighThreshold
+	if batteryHigh <= 0 {
+		batteryHigh = DefaultBatteryHighThreshold
+	}
+	batteryLow := cfg.BatteryLowThreshold
+	if batteryLow <= 0 {
+		batteryLow = DefaultBatteryLowThreshold
+	}
+
+	// The peak label depends on the window, so it's rendered here rather
+	// than with the rest of the static text
+	static := newStaticText(theme, keyMap)
+	static.peakLabel = theme.label.Render("Peak(" + formatDuration(cfg.PeakWindow) + "): ")
+
 	return Model{
 		monitor:         cfg.Monitor,
-		history:         power.NewHistory(cfg.MaxHistorySize, cfg.HistoryDuration),
+		filter:          filter,
+		theme:           theme,
+		static:          static,
+		keys:            keyMap.lookup(),
+		history:         history,
 		spinner:         s,
 		graphWidth:      cfg.GraphWidth,
 		graphHeight:     cfg.GraphHeight,
+		maxGraphWidth:   maxGraphWidth,
+		maxGraphHeight:  maxGraphHeight,
 		refreshInterval: cfg.RefreshInterval,
+		readTimeout:     readTimeout,
+		autoReadTimeout: cfg.ReadTimeout <= 0,
+		graphWindow:     cfg.GraphWindow,
+		statsWindow:     cfg.StatsWindow,
+		peakWindow:      cfg.PeakWindow,
+		graphScale:      cfg.GraphScale,
+		graphChars:      graphCharset(cfg.GraphChars),
+		referenceLines:  cfg.ReferenceLines,
+		maxWatts:        cfg.MaxWatts,
+		focus:           focusComponents[cfg.Focus],
+		titleMetric:     cfg.TitleMetric,
+		graphValue:      cfg.GraphValue,
+		smoothWindow:    cfg.SmoothWindow,
+		highlightAvg:    cfg.HighlightAverage,
+		overhead:        cfg.Overhead,
+		alertThreshold:  cfg.AlertThreshold,
+		batteryHigh:     batteryHigh,
+		batteryLow:      batteryLow,
+		clearStateFile:  cfg.ClearStateFile,
+		onReading:       cfg.OnReading,
+		onInterval:      cfg.OnIntervalChange,
+		ctx:             ctx,
+		duration:        cfg.Duration,
 		needsSudo:       needsSudo,
+		quiet:           cfg.Quiet,
 	}
 }
 
@@ -177,9 +583,21 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.spinner.Tick,
 		m.tickCmd(),
+		m.durationCmd(),
 	)
 }
 
+// durationCmd returns a command that sends a durationElapsedMsg once the
+// run's duration has passed, or nil to run until the user quits.
+func (m Model) durationCmd() tea.Cmd {
+	if m.duration <= 0 {
+		return nil
+	}
+	return tea.Tick(m.duration, func(time.Time) tea.Msg {
+		return durationElapsedMsg{}
+	})
+}
+
 // tickCmd returns a command that sends a tick message after the refresh interval.
 func (m Model) tickCmd() tea.Cmd {
 	return tea.Tick(m.refreshInterval, func(t time.Time) tea.Msg {
@@ -187,13 +605,39 @@ func (m Model) tickCmd() tea.Cmd {
 	})
 }
 
+// ReadTimeoutFor returns the read timeout for a refresh interval: 90% of the
+// interval, capped at DefaultReadTimeout and never below 2s.
+func ReadTimeoutFor(interval time.Duration) time.Duration {
+	return min(DefaultReadTimeout, max(minReadTimeout, interval*9/10))
+}
+
 // readPowerCmd returns a command that reads power and returns a readingMsg.
 func (m Model) readPowerCmd() tea.Cmd {
 	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(m.ctx, m.readTimeout)
 		defer cancel()
+		start := time.Now()
 		reading, err := m.monitor.Read(ctx)
-		return readingMsg{reading: reading, err: err}
+		return readingMsg{reading: reading, err: err, latency: time.Since(start)}
+	}
+}
+
+// clearStateCmd returns a command that empties the state file.
+func (m Model) clearStateCmd() tea.Cmd {
+	return func() tea.Msg {
+		return stateClearedMsg{err: m.clearStateFile.Clear()}
+	}
+}
+
+// intervalChangedCmd returns a command that passes the new refresh interval
+// to Config.OnIntervalChange, or nil if it isn't set.
+func (m Model) intervalChangedCmd() tea.Cmd {
+	if m.onInterval == nil {
+		return nil
+	}
+	interval := m.refreshInterval
+	return func() tea.Msg {
+		return intervalChangedMsg{err: m.onInterval(interval)}
 	}
 }
 
@@ -201,32 +645,114 @@ func (m Model) readPowerCmd() tea.Cmd {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
+		if m.showHelp && msg.String() == helpCloseKey {
+			m.showHelp = false
+			return m, nil
+		}
+		switch m.keys[msg.String()] {
+		case ActionQuit:
 			m.quitting = true
 			return m, tea.Quit
-		case "c":
+		case ActionClear:
 			m.history.Clear()
+			if m.filter != nil {
+				m.filter.Reset()
+			}
+			if m.clearStateFile != nil {
+				return m, m.clearStateCmd()
+			}
+			return m, nil
+		case ActionPause:
+			m.paused = !m.paused
+			return m, nil
+		case ActionUnit:
+			m.unit = m.unit.next()
+			return m, nil
+		case ActionLonger:
+			m.resizeHistory(1)
+			return m, nil
+		case ActionShorter:
+			m.resizeHistory(-1)
+			return m, nil
+		case ActionFaster:
+			if m.stepRefreshInterval(-1) {
+				return m, m.intervalChangedCmd()
+			}
+			return m, nil
+		case ActionSlower:
+			if m.stepRefreshInterval(1) {
+				return m, m.intervalChangedCmd()
+			}
+			return m, nil
+		case ActionHelp:
+			m.showHelp = !m.showHelp
+			return m, nil
+		case ActionBaseline:
+			m.toggleBaseline()
+			return m, nil
+		case ActionEnergy:
+			if m.graphMode == graphModeEnergy {
+				m.graphMode = graphModePower
+			} else {
+				m.graphMode = graphModeEnergy
+			}
 			return m, nil
 		}
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		// Adjust graph size based on terminal size
-		m.graphWidth = min(DefaultGraphWidth, msg.Width-20)
-		m.graphHeight = min(DefaultGraphHeight, msg.Height-15)
+		// Fill the terminal, leaving room for the box, axis and stats
+		m.graphWidth = max(1, min(m.maxGraphWidth, msg.Width-20))
+		m.graphHeight = max(1, min(m.maxGraphHeight, msg.Height-15))
 		m.ready = true
 		return m, nil
 
 	case tickMsg:
+		if m.paused {
+			return m, m.tickCmd()
+		}
+		// Age out old readings even if reads have stopped succeeding
+		m.history.PruneNow(time.Time(msg))
 		return m, tea.Batch(m.readPowerCmd(), m.tickCmd())
 
 	case readingMsg:
+		// Discard readings that were in flight when we paused
+		if m.paused {
+			return m, nil
+		}
+		// Keep showing the last reading until a pushed source sends another
+		if errors.Is(msg.err, power.ErrNoNewReading) && !m.lastReading.Timestamp.IsZero() {
+			return m, nil
+		}
 		m.lastError = msg.err
-		if msg.err == nil {
+		m.lastLatency = msg.latency
+		msg.reading = focusReading(msg.reading, m.focus).Sanitize(m.maxWatts)
+		if !power.ReadFailed(msg.err) && (m.filter == nil || m.filter.Accept(msg.reading)) {
 			m.lastReading = msg.reading
 			m.history.Add(msg.reading)
+			if m.onReading != nil {
+				m.onReading(msg.reading)
+			}
+			if m.updateAlert() {
+				return m, ringBell
+			}
+		}
+		return m, nil
+
+	case durationElapsedMsg:
+		m.quitting = true
+		return m, tea.Quit
+
+	case stateClearedMsg:
+		if msg.err != nil {
+			m.lastError = fmt.Errorf("clearing saved history: %w", msg.err)
+		}
+		return m, nil
+
+	case intervalChangedMsg:
+		if msg.err != nil {
+			m.lastError = fmt.Errorf("changing the sampling interval: %w", msg.err)
 		}
 		return m, nil
 
@@ -239,6 +765,103 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// toggleBaseline clears the baseline if one is set, and otherwise sets it to
+// the average power over the stats window. Without readings to average
+// there's nothing to set. Clearing the history keeps the baseline, so a
+// workload can be measured from a fresh graph.
+func (m *Model) toggleBaseline() {
+	if m.baselineSet {
+		m.baseline, m.baselineSet = 0, false
+		return
+	}
+	if m.history.Len() == 0 {
+		return
+	}
+	m.baseline = m.history.AverageOver(m.statsWindow)
+	m.baselineSet = true
+}
+
+// powerOffset returns how much to subtract from watts before showing them:
+// the baseline while one is set, otherwise 0. The energy graph is always
+// absolute.
+func (m Model) powerOffset() float64 {
+	if !m.baselineSet || m.graphMode == graphModeEnergy {
+		return 0
+	}
+	return m.baseline
+}
+
+// resizeHistory moves the history window to the next longer (step > 0) or
+// shorter (step < 0) preset in HistoryWindows, staying put at either end.
+// A window between presets moves to the nearest one in that direction.
+func (m *Model) resizeHistory(step int) {
+	current := m.history.Window()
+	window := current
+	if step > 0 {
+		for _, w := range HistoryWindows {
+			if w > current {
+				window = w
+				break
+			}
+		}
+	} else {
+		for i := len(HistoryWindows) - 1; i >= 0; i-- {
+			if HistoryWindows[i] < current {
+				window = HistoryWindows[i]
+				break
+			}
+		}
+	}
+	if window != current {
+		m.history.Resize(HistorySize(window, m.refreshInterval), window)
+	}
+}
+
+// stepRefreshInterval doubles (step > 0) or halves (step < 0) the refresh
+// interval from the next tick on, clamped to MinRefreshInterval and
+// MaxRefreshInterval. An interval already past a bound, e.g. from -interval,
+// stays put rather than jumping the other way. The history keeps its window
+// but is resized to hold it at the new rate, and a derived read timeout
+// follows the interval. It reports whether the interval changed.
+func (m *Model) stepRefreshInterval(step int) bool {
+	interval := m.refreshInterval
+	switch {
+	case step > 0 && interval < MaxRefreshInterval:
+		interval = min(MaxRefreshInterval, interval*2)
+	case step < 0 && interval > MinRefreshInterval:
+		interval = max(MinRefreshInterval, interval/2)
+	default:
+		return false
+	}
+
+	m.refreshInterval = interval
+	if m.autoReadTimeout {
+		m.readTimeout = ReadTimeoutFor(interval)
+	}
+	window := m.history.Window()
+	m.history.Resize(HistorySize(window, interval), window)
+	return true
+}
+
+// updateAlert tracks whether the last reading is above the alert threshold
+// and reports whether it just crossed it, so the bell rings once per
+// crossing rather than on every reading.
+func (m *Model) updateAlert() bool {
+	if m.alertThreshold <= 0 {
+		return false
+	}
+	wasActive := m.alertActive
+	m.alertActive = m.lastReading.WattsAvailable && m.lastReading.Watts > m.alertThreshold
+	return m.alertActive && !wasActive
+}
+
+// ringBell rings the terminal bell. It writes to stderr so it doesn't
+// interfere with the renderer's output on stdout.
+func ringBell() tea.Msg {
+	fmt.Fprint(os.Stderr, "\a")
+	return nil
+}
+
 // View renders the UI.
 func (m Model) View() string {
 	if m.quitting {
@@ -249,10 +872,19 @@ func (m Model) View() string {
 		return fmt.Sprintf("%s Loading...\n", m.spinner.View())
 	}
 
+	if m.showHelp {
+		return m.renderBox(m.static.helpOverlay)
+	}
+
 	var b strings.Builder
+	b.Grow(4096)
 
 	// Title
-	b.WriteString(titleStyle.Render("⚡ Power Monitor"))
+	title := m.static.title
+	if metric := m.renderTitleMetric(); metric != "" {
+		title = lipgloss.JoinHorizontal(lipgloss.Top, title, "  ", metric)
+	}
+	b.WriteString(title)
 	b.WriteString("\n\n")
 
 	// Current power reading
@@ -267,46 +899,141 @@ func (m Model) View() string {
 	b.WriteString(m.renderStats())
 	b.WriteString("\n")
 
-	// Error display
-	if m.lastError != nil {
+	// Error and slow read warnings
+	b.WriteString(m.renderWarnings())
+
+	// Sudo hint for desktop Macs
+	if m.needsSudo && m.lastReading.Watts == 0 && !m.quiet {
 		b.WriteString("\n")
-		b.WriteString(errorStyle.Render(fmt.Sprintf("⚠ Error: %v", m.lastError)))
+		b.WriteString(m.static.sudoTip)
 		b.WriteString("\n")
 	}
 
-	// Sudo hint for desktop Macs
-	if m.needsSudo && m.lastReading.Watts == 0 {
-		b.WriteString("\n")
-		b.WriteString(labelStyle.Render("💡 Tip: Run with sudo for power data on desktop Macs:"))
+	// Help
+	if m.paused {
+		b.WriteString(m.static.pausedHelp)
+	} else {
+		b.WriteString(m.static.help)
+	}
+
+	return m.renderBox(b.String())
+}
+
+// renderWarnings renders the last read error and the slow read warning, each
+// on its own line after a blank one. When quiet, only the first of them is
+// shown, dimmed and cut to a single line.
+func (m Model) renderWarnings() string {
+	var warnings []string
+	if m.lastError != nil {
+		warnings = append(warnings, m.formatError(m.lastError))
+	}
+	if m.isSlowRead() {
+		warnings = append(warnings, fmt.Sprintf("⏱ Slow reading: took %s of a %s interval",
+			m.lastLatency.Round(time.Millisecond), m.refreshInterval))
+	}
+	if len(warnings) == 0 {
+		return ""
+	}
+
+	if m.quiet {
+		line, _, _ := strings.Cut(warnings[0], "\n")
+		return "\n" + m.theme.label.Render(line) + "\n"
+	}
+	var b strings.Builder
+	for _, warning := range warnings {
 		b.WriteString("\n")
-		b.WriteString(valueStyle.Render("   sudo powermon"))
+		b.WriteString(m.theme.error.Render(warning))
 		b.WriteString("\n")
 	}
+	return b.String()
+}
 
-	// Help
-	b.WriteString(helpStyle.Render("Press 'q' to quit • 'c' to clear history"))
+// renderBox draws content with the theme's box padding and border. It
+// matches m.theme.box.Render but reuses the pre-rendered border sides, which
+// otherwise account for most of a frame's allocations.
+func (m Model) renderBox(content string) string {
+	lines := strings.Split(content, "\n")
+	width := 0
+	for _, line := range lines {
+		width = max(width, lipgloss.Width(line))
+	}
+
+	padTop, padRight, padBottom, padLeft := m.theme.box.GetPadding()
+	inner := padLeft + width + padRight
+	border := m.static.border
+
+	var b strings.Builder
+	b.Grow(len(content) + (len(lines)+padTop+padBottom+2)*(inner+2*len(m.static.borderLeft)))
+
+	b.WriteString(m.static.borderStyle.Render(border.TopLeft + strings.Repeat(border.Top, inner) + border.TopRight))
+	b.WriteString("\n")
+	for i := 0; i < padTop; i++ {
+		m.writeBoxLine(&b, "", 0, inner)
+	}
+	for _, line := range lines {
+		m.writeBoxLine(&b, line, padLeft, inner)
+	}
+	for i := 0; i < padBottom; i++ {
+		m.writeBoxLine(&b, "", 0, inner)
+	}
+	b.WriteString(m.static.borderStyle.Render(border.BottomLeft + strings.Repeat(border.Bottom, inner) + border.BottomRight))
+
+	return b.String()
+}
+
+// writeBoxLine writes one bordered line of renderBox, padding line to inner
+// columns after indenting it by padLeft.
+func (m Model) writeBoxLine(b *strings.Builder, line string, padLeft, inner int) {
+	b.WriteString(m.static.borderLeft)
+	writeSpaces(b, padLeft)
+	b.WriteString(line)
+	writeSpaces(b, inner-padLeft-lipgloss.Width(line))
+	b.WriteString(m.static.borderRight)
+	b.WriteString("\n")
+}
+
+// writeSpaces writes n spaces to b.
+func writeSpaces(b *strings.Builder, n int) {
+	for i := 0; i < n; i++ {
+		b.WriteByte(' ')
+	}
+}
 
-	return boxStyle.Render(b.String())
+// isSlowRead reports whether the last read took a large fraction of the
+// refresh interval, which skews rate and energy calculations.
+func (m Model) isSlowRead() bool {
+	return m.refreshInterval > 0 &&
+		float64(m.lastLatency) > float64(m.refreshInterval)*slowReadFraction
 }
 
 // renderCurrentPower renders the current power consumption display.
 func (m Model) renderCurrentPower() string {
 	var b strings.Builder
 
-	// Current watts
-	watts := m.lastReading.Watts
-	wattsStr := fmt.Sprintf("%.1f W", watts)
-	b.WriteString(powerStyle.Render(wattsStr))
+	// Current watts, unless the last reading couldn't measure them. With a
+	// baseline set they're shown as the difference from it.
+	wattsStr := powerText(m.lastReading, m.unit)
+	baselined := m.baselineSet && (m.lastReading.Timestamp.IsZero() || m.lastReading.WattsAvailable)
+	if baselined {
+		wattsStr = signedWatts(m.lastReading.Watts-m.baseline, m.unit) + " " + m.unit.String() + powerKindLabel(m.lastReading.PowerKind)
+	}
+	if m.alertActive {
+		b.WriteString(m.theme.alert.Render(wattsStr))
+	} else {
+		b.WriteString(m.theme.power.Render(wattsStr))
+	}
+	if baselined {
+		b.WriteString(" ")
+		b.WriteString(m.theme.note.Render("vs " + formatWatts(m.baseline, m.unit) + " baseline"))
+	}
 
 	// Trend indicator
-	trend := m.history.Trend()
-	trendStr := ""
-	if trend > 0.5 {
-		trendStr = trendUpStyle.Render(" ▲ increasing")
-	} else if trend < -0.5 {
-		trendStr = trendDownStyle.Render(" ▼ decreasing")
-	} else {
-		trendStr = trendStableStyle.Render(" ● stable")
+	trendStr := m.static.trendStable
+	switch trendDirection(m.history.TrendPerMinuteOver(m.statsWindow)) {
+	case 1:
+		trendStr = m.static.trendUp
+	case -1:
+		trendStr = m.static.trendDown
 	}
 	b.WriteString("  " + trendStr)
 
@@ -322,20 +1049,7 @@ func (m Model) renderCurrentPower() string {
 // renderBatteryIndicator renders the battery status.
 func (m Model) renderBatteryIndicator() string {
 	pct := m.lastReading.BatteryPercent
-
-	// Choose style based on battery level
-	var style lipgloss.Style
-	var icon string
-	if pct >= 60 {
-		style = batteryHighStyle
-		icon = "🔋"
-	} else if pct >= 20 {
-		style = batteryMedStyle
-		icon = "🔋"
-	} else {
-		style = batteryLowStyle
-		icon = "🪫"
-	}
+	style := m.batteryStyle(pct)
 
 	status := ""
 	if m.lastReading.IsCharging {
@@ -344,127 +1058,533 @@ func (m Model) renderBatteryIndicator() string {
 		status = " ↓"
 	}
 
-	return fmt.Sprintf("%s %s%s", icon, style.Render(fmt.Sprintf("%.0f%%", pct)), status)
+	if temp := m.lastReading.Temperature; temp > 0 {
+		status += fmt.Sprintf("  🌡 %.1f°C", temp)
+	}
+
+	return fmt.Sprintf("%s %s%s", batteryIcon(pct), style.Render(fmt.Sprintf("%.0f%%", pct)), status)
+}
+
+// batteryStyle returns the style for a battery percentage, going by the
+// configured high and low thresholds.
+func (m Model) batteryStyle(pct float64) lipgloss.Style {
+	switch {
+	case pct >= m.batteryHigh:
+		return m.theme.batteryHigh
+	case pct >= m.batteryLow:
+		return m.theme.batteryMed
+	default:
+		return m.theme.batteryLow
+	}
 }
 
 // renderGraph renders the power consumption graph.
 func (m Model) renderGraph() string {
 	readings := m.history.Readings()
 	if len(readings) == 0 {
-		return graphAxisStyle.Render("Waiting for data...")
+		return m.theme.graphAxis.Render("Waiting for data...")
 	}
 
-	// Calculate min/max for scaling
-	minVal := m.history.Min()
-	maxVal := m.history.Max()
+	// Only plot the graph window; stats still cover the whole history
+	start := m.graphStart(readings)
+	readings = readings[start:]
+
+	// Pick the series to plot and its scale
+	offset := m.powerOffset()
+	var values []float64
+	var header string
+	var minVal, maxVal float64
+	if m.graphMode == graphModeEnergy {
+		values = m.history.CumulativeEnergy()[start:]
+
+		// Cumulative energy starts at zero and only grows, so the scale does too
+		maxVal = values[len(values)-1]
+		if maxVal <= 0 {
+			maxVal = 0.01
+		}
+		maxVal *= 1.1
+		header = fmt.Sprintf("Energy (%.2f - %.2f Wh)", minVal, maxVal)
+	} else {
+		values = make([]float64, len(readings))
+		minVal, maxVal = readings[0].Watts-offset, readings[0].Watts-offset
+		for i, r := range readings {
+			values[i] = r.Watts - offset
+			minVal = min(minVal, values[i])
+			maxVal = max(maxVal, values[i])
+		}
 
-	// Add padding to range
-	rangeVal := maxVal - minVal
-	if rangeVal < 1.0 {
-		rangeVal = 1.0
+		// Add padding to range. Power can't go below zero, but its
+		// difference from a baseline can.
+		rangeVal := maxVal - minVal
+		if rangeVal < 1.0 {
+			rangeVal = 1.0
+		}
+		minVal -= rangeVal * 0.1
+		if !m.baselineSet {
+			minVal = math.Max(0, minVal)
+		}
+		maxVal += rangeVal * 0.1
+		if m.baselineSet {
+			header = fmt.Sprintf("Power vs %s baseline (%s - %s %s)", formatWatts(m.baseline, m.unit),
+				signedWatts(minVal, m.unit), signedWatts(maxVal, m.unit), m.unit)
+		} else {
+			header = fmt.Sprintf("Power (%s - %s %s)", m.unit.number(minVal), m.unit.number(maxVal), m.unit)
+		}
+		if m.focus != "" {
+			header += ", " + m.focus + " only"
+		}
 	}
-	minVal = math.Max(0, minVal-rangeVal*0.1)
-	maxVal += rangeVal * 0.1
 
 	// Build the graph
-	var lines []string
+	var b strings.Builder
 
 	// Graph header
-	lines = append(lines, graphAxisStyle.Render(fmt.Sprintf("Power (%.1f - %.1f W)", minVal, maxVal)))
-
-	// Create graph rows
-	blockChars := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
-
-	// Sample readings to fit graph width
-	numPoints := min(m.graphWidth, len(readings))
-	if numPoints < 1 {
-		numPoints = 1
+	header += ", last " + formatDuration(m.shownWindow()) + ", every " + m.refreshInterval.String()
+	if m.graphScale == GraphScaleLog {
+		header += ", log scale"
 	}
-	sampledReadings := make([]float64, numPoints)
+	b.WriteString(m.theme.graphAxis.Render(header))
+	b.WriteString("\n")
 
-	if numPoints == 1 {
-		// Single point: use the latest reading
-		sampledReadings[0] = readings[len(readings)-1].Watts
-	} else if numPoints < len(readings) {
-		// Sample evenly across all readings
-		for i := 0; i < numPoints; i++ {
-			idx := i * (len(readings) - 1) / (numPoints - 1)
-			sampledReadings[i] = readings[idx].Watts
+	// Sample values to fit graph width, always drawing at least one bar
+	sampled := downsample(values, max(1, m.graphWidth))
+	sampled = smoothValues(sampled, m.smoothWindow)
+	numPoints := len(sampled)
+
+	// Highlighting compares power against the average, so it doesn't apply
+	// to the cumulative energy graph. Otherwise colored themes shade each
+	// bar by its height between the lowest and highest shown.
+	highlight := m.highlightAvg && m.graphMode == graphModePower
+	gradient := !highlight && m.theme.graphGradient
+	var columnStyle []int
+	switch {
+	case highlight:
+		avg := m.history.Average() - offset
+		columnStyle = make([]int, numPoints)
+		for i, val := range sampled {
+			if val > avg {
+				columnStyle[i] = 1
+			}
 		}
-	} else {
-		// Use all readings
-		for i := 0; i < len(readings); i++ {
-			sampledReadings[i] = readings[i].Watts
+	case gradient:
+		lo, hi := slices.Min(sampled), slices.Max(sampled)
+		columnStyle = make([]int, numPoints)
+		for i, val := range sampled {
+			columnStyle[i] = gradientStep(val, lo, hi)
 		}
 	}
 
-	// Build sparkline-style graph
+	// Each column fills from one step of a cell up to the full graph height
+	rows := max(1, m.graphHeight)
+	steps := len(m.graphChars)
+	levels := make([]int, numPoints)
+	for i, val := range sampled {
+		levels[i] = graphLevel(val, minVal, maxVal, m.graphScale, rows*steps)
+	}
+	valueRow := (levels[numPoints-1] - 1) / steps
+
+	// Reference lines mark the row their value's bar would reach, in the
+	// cells no bar covers
+	avgRow, alertRow := -1, -1
+	if m.referenceLines && m.graphMode == graphModePower && rows > 1 {
+		avgRow = (graphLevel(m.history.AverageOver(m.statsWindow)-offset, minVal, maxVal, m.graphScale, rows*steps) - 1) / steps
+		alert := m.alertThreshold - offset
+		if m.alertThreshold > 0 && alert >= minVal && alert <= maxVal {
+			alertRow = (graphLevel(alert, minVal, maxVal, m.graphScale, rows*steps) - 1) / steps
+		}
+	}
+
+	// Build the graph top row first. Each run of bars on the same side of
+	// the average is rendered with one style; otherwise rows are batched so
+	// the whole graph takes as few renders as possible, with gradient colors
+	// switched by escapes rendered once up front.
 	var graphLine strings.Builder
-	for _, val := range sampledReadings {
-		// Normalize value to 0-1 range
-		normalized := (val - minVal) / (maxVal - minVal)
-		if normalized < 0 {
-			normalized = 0
+	size := numPoints*utf8.UTFMax + 1
+	if gradient {
+		escapes := len(m.static.gradientOpen[0]) + len(m.static.gradientClose)
+		for i := range columnStyle {
+			if i == 0 || columnStyle[i] != columnStyle[i-1] {
+				size += escapes
+			}
+		}
+	}
+	graphLine.Grow(rows * size)
+	for row := rows - 1; row >= 0; row-- {
+		if row < rows-1 {
+			if graphLine.Len() > 0 {
+				graphLine.WriteByte('\n')
+			} else {
+				b.WriteString("\n")
+			}
 		}
-		if normalized > 1 {
-			normalized = 1
+		run := -1
+		for i := range sampled {
+			switch {
+			case highlight:
+				if i > 0 && columnStyle[i] != run {
+					b.WriteString(m.barStyle(run == 1).Render(graphLine.String()))
+					graphLine.Reset()
+				}
+				run = columnStyle[i]
+			case gradient && columnStyle[i] != run:
+				if run >= 0 {
+					graphLine.WriteString(m.static.gradientClose)
+				}
+				graphLine.WriteString(m.static.gradientOpen[columnStyle[i]])
+				run = columnStyle[i]
+			}
+			cell := graphCell(m.graphChars, levels[i], row)
+			if cell == ' ' {
+				switch row {
+				case alertRow:
+					cell = alertLineChar
+				case avgRow:
+					cell = avgLineChar
+				}
+			}
+			graphLine.WriteRune(cell)
 		}
 
-		// Map to block character
-		charIdx := int(normalized * float64(len(blockChars)-1))
-		graphLine.WriteRune(blockChars[charIdx])
+		showValue := m.graphValue && row == valueRow
+		if gradient {
+			graphLine.WriteString(m.static.gradientClose)
+		}
+		if !highlight && !showValue && row > 0 {
+			continue
+		}
+		switch {
+		case highlight:
+			b.WriteString(m.barStyle(run == 1).Render(graphLine.String()))
+		case gradient:
+			b.WriteString(graphLine.String())
+		default:
+			b.WriteString(m.theme.graphBar.Render(graphLine.String()))
+		}
+		graphLine.Reset()
+
+		// Inline value next to the top of the last bar, so screenshots are
+		// self-describing
+		if showValue {
+			latest := values[len(values)-1]
+			text := formatWatts(latest, m.unit)
+			switch {
+			case m.graphMode == graphModeEnergy:
+				text = fmt.Sprintf("%.2fWh", latest)
+			case m.baselineSet:
+				text = signedWatts(latest, m.unit) + m.unit.String()
+			}
+			b.WriteString(" ")
+			b.WriteString(m.theme.value.Render(text))
+		}
 	}
 
-	lines = append(lines, graphBarStyle.Render(graphLine.String()))
-
 	// Time axis
-	if len(readings) > 0 {
-		oldest := readings[0].Timestamp
-		newest := readings[len(readings)-1].Timestamp
-		duration := newest.Sub(oldest)
-		timeLabel := fmt.Sprintf("← %s ago", formatDuration(duration))
-		lines = append(lines, graphAxisStyle.Render(timeLabel))
-	}
+	oldest := readings[0].Timestamp
+	newest := readings[len(readings)-1].Timestamp
+	b.WriteString("\n")
+	b.WriteString(m.theme.graphAxis.Render("← " + formatDuration(newest.Sub(oldest)) + " ago"))
 
-	return strings.Join(lines, "\n")
+	return b.String()
 }
 
-// renderStats renders the statistics section.
+// compactStatsWidth is the terminal width below which the stats shorten
+// some labels and leave out the sample count, so they take fewer lines.
+const compactStatsWidth = 80
+
+// renderStats renders the statistics section. Each line's fields wrap onto
+// further lines rather than running past the edge of the box.
 func (m Model) renderStats() string {
 	var b strings.Builder
+	f := fieldWriter{b: &b, width: m.contentWidth()}
+	compact := m.width > 0 && m.width < compactStatsWidth
 
-	avg := m.history.Average()
-	minVal := m.history.Min()
-	maxVal := m.history.Max()
+	stats := m.history.StatsOver(m.statsWindow)
 
 	// Stats row
-	b.WriteString(labelStyle.Render("Avg: "))
-	b.WriteString(valueStyle.Render(fmt.Sprintf("%.1fW", avg)))
-	b.WriteString("  ")
-	b.WriteString(labelStyle.Render("Min: "))
-	b.WriteString(valueStyle.Render(fmt.Sprintf("%.1fW", minVal)))
-	b.WriteString("  ")
-	b.WriteString(labelStyle.Render("Max: "))
-	b.WriteString(valueStyle.Render(fmt.Sprintf("%.1fW", maxVal)))
-	b.WriteString("  ")
-	b.WriteString(labelStyle.Render("Samples: "))
-	b.WriteString(valueStyle.Render(fmt.Sprintf("%d", m.history.Len())))
-
-	// Power source
-	b.WriteString("\n")
-	b.WriteString(labelStyle.Render("Source: "))
+	f.field(m.static.avgLabel, m.theme.value.Render(formatWatts(stats.Average, m.unit)))
+	f.field(m.static.minLabel, m.theme.value.Render(formatWatts(stats.Min, m.unit)))
+	f.field(m.static.maxLabel, m.theme.value.Render(formatWatts(stats.Max, m.unit)))
+	if m.peakWindow > 0 {
+		label := m.static.peakLabel
+		if compact {
+			label = m.static.peakShort
+		}
+		f.field(label, m.theme.value.Render(formatWatts(m.history.MaxOver(m.peakWindow), m.unit)))
+	}
+	if !compact {
+		f.field(m.static.samplesLabel, m.theme.value.Render(strconv.Itoa(m.history.Len())))
+	}
+	energyLabel := m.static.energyLabel
+	if compact {
+		energyLabel = m.static.energyShort
+	}
+	f.field(energyLabel, m.theme.value.Render(fmt.Sprintf("%.2fWh", m.history.EnergyWattHours())))
+	if m.statsWindow > 0 {
+		over := "avg/min/max over "
+		if compact {
+			over = "over "
+		}
+		f.field(m.theme.note.Render(over + formatDuration(m.statsWindow)))
+	}
+
+	// Power source, leaving out the labels when compact since the values
+	// speak for themselves
+	f.line()
+	sourceLabel, monitorLabel := m.static.sourceLabel, m.static.monitorLabel
+	if compact {
+		sourceLabel, monitorLabel = "", ""
+	}
 	if m.lastReading.IsOnBattery {
-		b.WriteString(valueStyle.Render("Battery"))
+		if left := formatTimeRemaining(m.lastReading); left != "" {
+			f.field(sourceLabel, m.static.onBattery, " ", m.theme.note.Render(left))
+		} else {
+			f.field(sourceLabel, m.static.onBattery)
+		}
 	} else {
-		b.WriteString(valueStyle.Render("AC Power"))
+		f.field(sourceLabel, m.static.onAC)
+		if adapter := m.lastReading.AdapterMaxWatts; adapter > 0 {
+			// The charger's rating, to compare the draw against
+			f.field(m.static.adapterLabel, m.theme.value.Render(strconv.FormatFloat(adapter, 'f', -1, 64)+"W"))
+		}
+	}
+	if drain := m.history.BatteryDrainRatePerHour(); drain > 0 && m.lastReading.IsOnBattery {
+		f.field(m.static.drainLabel, m.theme.value.Render(formatDrainRate(drain)))
+	}
+	f.field(monitorLabel, m.theme.value.Render(m.monitor.Name()))
+	if m.overhead > 0 {
+		f.field(m.theme.note.Render("tool overhead ~" + formatWatts(m.overhead, m.unit)))
+	}
+
+	// Apparent power and power factor from smart plugs and PDUs
+	if ac := formatACPower(m.lastReading); ac != "" {
+		f.line()
+		f.field(m.static.acLabel, m.theme.value.Render(ac))
+	}
+
+	// Battery capacity, health and wear
+	capacity := formatCapacity(m.lastReading)
+	cycles := m.lastReading.CycleCount
+	if capacity != "" || cycles > 0 {
+		f.line()
+	}
+	if capacity != "" {
+		f.field(m.static.batteryLabel, m.theme.value.Render(capacity))
+		if health := m.lastReading.BatteryHealthPercent; health > 0 {
+			f.field(m.static.healthLabel, m.theme.value.Render(formatHealth(health)))
+		}
+	}
+	if cycles > 0 {
+		f.field(m.static.cyclesLabel, m.theme.value.Render(strconv.Itoa(cycles)))
 	}
-	b.WriteString("  ")
-	b.WriteString(labelStyle.Render("Monitor: "))
-	b.WriteString(valueStyle.Render(m.monitor.Name()))
 
 	return b.String()
 }
 
+// contentWidth returns how many columns fit inside the box at the current
+// terminal width, or 0 before the width is known.
+func (m Model) contentWidth() int {
+	if m.width <= 0 {
+		return 0
+	}
+	return max(1, m.width-m.theme.box.GetHorizontalFrameSize())
+}
+
+// fieldWriter writes fields separated by two spaces, moving to a new line
+// instead of separating when the next field would run past width. A width
+// of zero or less never wraps.
+type fieldWriter struct {
+	b     *strings.Builder
+	width int
+	col   int
+}
+
+// field writes one field made of parts, which stay together on one line.
+func (f *fieldWriter) field(parts ...string) {
+	w := 0
+	for _, p := range parts {
+		w += lipgloss.Width(p)
+	}
+	if f.col > 0 {
+		if f.width > 0 && f.col+2+w > f.width {
+			f.b.WriteString("\n")
+			f.col = 0
+		} else {
+			f.b.WriteString("  ")
+			f.col += 2
+		}
+	}
+	for _, p := range parts {
+		f.b.WriteString(p)
+	}
+	f.col += w
+}
+
+// line ends the current line, so the next field starts a new one.
+func (f *fieldWriter) line() {
+	f.b.WriteString("\n")
+	f.col = 0
+}
+
+// formatError formats a read error for display. Timeouts and the power
+// package's sentinel errors get a plain explanation instead of the raw
+// error, e.g. "context deadline exceeded".
+func (m Model) formatError(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Sprintf("⚠ Reading timed out after %s; the monitor may be overloaded", m.readTimeout)
+	case errors.Is(err, power.ErrNeedsPrivilege):
+		return "🔒 No power data: this monitor needs elevated privileges (try sudo)"
+	case errors.Is(err, power.ErrUnsupported):
+		return "⚠ Power monitoring isn't supported on this system"
+	case errors.Is(err, power.ErrNoData):
+		return "⏳ Waiting for power data..."
+	}
+	return fmt.Sprintf("⚠ Error: %v", err)
+}
+
+// normalizeValue maps val within [lo, hi] to the 0-1 range, clamping values
+// outside it. The log scale works on log10 of the values, with anything below
+// logScaleFloor treated as the floor.
+func normalizeValue(val, lo, hi float64, scale GraphScale) float64 {
+	if scale == GraphScaleLog {
+		val = math.Log10(max(val, logScaleFloor))
+		lo = math.Log10(max(lo, logScaleFloor))
+		hi = math.Log10(max(hi, logScaleFloor))
+	}
+	if hi <= lo {
+		return 0
+	}
+	return min(1, max(0, (val-lo)/(hi-lo)))
+}
+
+// graphLevel returns how many of a column's cells steps a bar for val
+// fills, from 1 at lo up to cells at hi.
+func graphLevel(val, lo, hi float64, scale GraphScale, cells int) int {
+	return int(normalizeValue(val, lo, hi, scale)*float64(cells-1)) + 1
+}
+
+// graphStart returns the index of the first reading inside the graph window,
+// measured back from the latest reading. Without a window every reading is
+// plotted.
+func (m Model) graphStart(readings []power.Reading) int {
+	if m.graphWindow <= 0 || len(readings) == 0 {
+		return 0
+	}
+	cutoff := readings[len(readings)-1].Timestamp.Add(-m.graphWindow)
+	return sort.Search(len(readings), func(i int) bool {
+		return !readings[i].Timestamp.Before(cutoff)
+	})
+}
+
+// shownWindow returns how much time the graph covers: the graph window if
+// one is set and shorter than the history window, or else the history window.
+func (m Model) shownWindow() time.Duration {
+	window := m.history.Window()
+	if m.graphWindow > 0 && m.graphWindow < window {
+		return m.graphWindow
+	}
+	return window
+}
+
+// barStyle returns the style for graph bars above or below the average.
+func (m Model) barStyle(above bool) lipgloss.Style {
+	if above {
+		return m.theme.graphAbove
+	}
+	return m.theme.graphBelow
+}
+
+// downsample picks n values evenly spaced across values, always keeping the
+// first and last. A single point is the latest value, and values with n or
+// fewer points are returned unchanged. n of 0 or less returns nil.
+func downsample(values []float64, n int) []float64 {
+	switch {
+	case n <= 0 || len(values) == 0:
+		return nil
+	case n >= len(values):
+		return values
+	case n == 1:
+		return values[len(values)-1:]
+	}
+
+	sampled := make([]float64, n)
+	for i := range sampled {
+		sampled[i] = values[i*(len(values)-1)/(n-1)]
+	}
+	return sampled
+}
+
+// smoothValues returns the centered simple moving average of values over
+// window points. Near the edges the window is truncated to the points that
+// exist. A window of 1 or less returns values unchanged.
+func smoothValues(values []float64, window int) []float64 {
+	if window <= 1 || len(values) == 0 {
+		return values
+	}
+
+	smoothed := make([]float64, len(values))
+	for i := range values {
+		lo := max(0, i-(window-1)/2)
+		hi := min(len(values)-1, i+window/2)
+
+		var sum float64
+		for _, v := range values[lo : hi+1] {
+			sum += v
+		}
+		smoothed[i] = sum / float64(hi-lo+1)
+	}
+	return smoothed
+}
+
+// formatACPower formats apparent power and power factor, e.g.
+// "120.0 VA, PF 0.92". Either part is left out if the source doesn't report
+// it; an empty string means neither is known.
+func formatACPower(r power.Reading) string {
+	var parts []string
+	if r.ApparentVA > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f VA", r.ApparentVA))
+	}
+	if r.PowerFactor > 0 {
+		parts = append(parts, fmt.Sprintf("PF %.2f", r.PowerFactor))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatCapacity formats full-charge and design capacity, e.g.
+// "4820/5100 mAh". Returns an empty string if either is unknown.
+func formatCapacity(r power.Reading) string {
+	if r.CapacityFull <= 0 || r.CapacityDesign <= 0 {
+		return ""
+	}
+	if r.CapacityUnit == "Wh" {
+		return fmt.Sprintf("%.1f/%.1f Wh", r.CapacityFull, r.CapacityDesign)
+	}
+	return fmt.Sprintf("%.0f/%.0f %s", r.CapacityFull, r.CapacityDesign, r.CapacityUnit)
+}
+
+// formatHealth formats battery health as a whole percentage, e.g. "92%".
+func formatHealth(percent float64) string {
+	return fmt.Sprintf("%.0f%%", percent)
+}
+
+// formatTimeRemaining formats how long the battery has left while
+// discharging, e.g. "~3h12m left". Returns an empty string otherwise.
+func formatTimeRemaining(r power.Reading) string {
+	if r.TimeRemaining <= 0 || !r.IsOnBattery || r.IsCharging {
+		return ""
+	}
+	return "~" + formatDuration(r.TimeRemaining) + " left"
+}
+
+// formatDrainRate formats a battery drain rate in percentage points per
+// hour, with a decimal place for slow drains, e.g. "12%/h" or "2.5%/h".
+func formatDrainRate(rate float64) string {
+	if rate < 10 {
+		return fmt.Sprintf("%.1f%%/h", rate)
+	}
+	return fmt.Sprintf("%.0f%%/h", rate)
+}
+
 // formatDuration formats a duration as a human-readable string.
 func formatDuration(d time.Duration) string {
 	if d < time.Second {
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+// trendThreshold is how steep a trend, in watts per minute, must be before
+// it counts as increasing or decreasing rather than stable.
+const trendThreshold = 30
+
+// trendDirection classifies a History.TrendPerMinute trend as 1
+// (increasing), -1 (decreasing) or 0 (stable).
+func trendDirection(trend float64) int {
+	switch {
+	case trend > trendThreshold:
+		return 1
+	case trend < -trendThreshold:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// powerText formats a reading's watts in unit, e.g. "18.3 W", or says they
+// couldn't be measured. Battery charge and discharge rates are labeled "in"
+// and "out", since they aren't the system's draw.
+func powerText(r power.Reading, unit wattUnit) string {
+	if !r.Timestamp.IsZero() && !r.WattsAvailable {
+		return fmt.Sprintf("— %s (unavailable)", unit)
+	}
+	return fmt.Sprintf("%s %s%s", unit.number(r.Watts), unit, powerKindLabel(r.PowerKind))
+}
+
+// powerKindLabel returns the suffix telling battery charge and discharge
+// rates apart from system or adapter power, which get none.
+func powerKindLabel(kind power.PowerKind) string {
+	switch kind {
+	case power.PowerKindCharge:
+		return " in"
+	case power.PowerKindDischarge:
+		return " out"
+	default:
+		return ""
+	}
+}
+
+// batteryIcon returns the icon for a battery percentage.
+func batteryIcon(pct float64) string {
+	if pct < 20 {
+		return "🪫"
+	}
+	return "🔋"
+}
+
+// StatusLine formats a reading and History.TrendPerMinute trend as a single
+// plain line for status bars such as tmux's, e.g. "⚡ 18.3W ▲ 🔋78%".
+func StatusLine(r power.Reading, trend float64) string {
+	var b strings.Builder
+
+	b.WriteString("⚡ ")
+	if r.WattsAvailable {
+		b.WriteString(formatWatts(r.Watts, unitWatts))
+	} else {
+		b.WriteString("—W")
+	}
+
+	switch trendDirection(trend) {
+	case 1:
+		b.WriteString(" ▲")
+	case -1:
+		b.WriteString(" ▼")
+	default:
+		b.WriteString(" ●")
+	}
+
+	if r.BatteryPercent >= 0 {
+		fmt.Fprintf(&b, " %s%.0f%%", batteryIcon(r.BatteryPercent), r.BatteryPercent)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+func TestStatusLine(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		reading power.Reading
+		trend   float64
+		want    string
+	}{
+		{
+			name:    "battery and rising power",
+			reading: power.Reading{Watts: 18.34, WattsAvailable: true, BatteryPercent: 78, Timestamp: now},
+			trend:   72,
+			want:    "⚡ 18.3W ▲ 🔋78%",
+		},
+		{
+			name:    "low battery and falling power",
+			reading: power.Reading{Watts: 7, WattsAvailable: true, BatteryPercent: 12, Timestamp: now},
+			trend:   -48,
+			want:    "⚡ 7.0W ▼ 🪫12%",
+		},
+		{
+			name:    "desktop without a battery",
+			reading: power.Reading{Watts: 95.5, WattsAvailable: true, BatteryPercent: -1, Timestamp: now},
+			want:    "⚡ 95.5W ●",
+		},
+		{
+			name:    "unavailable watts",
+			reading: power.Reading{BatteryPercent: 50, Timestamp: now},
+			trend:   18,
+			want:    "⚡ —W ● 🔋50%",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusLine(tt.reading, tt.trend); got != tt.want {
+				t.Errorf("StatusLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrendDirection(t *testing.T) {
+	tests := []struct {
+		trend float64
+		want  int
+	}{
+		{120, 1},
+		{30.5, 1},
+		{30, 0},
+		{0, 0},
+		{-30, 0},
+		{-30.5, -1},
+		{-180, -1},
+	}
+	for _, tt := range tests {
+		if got := trendDirection(tt.trend); got != tt.want {
+			t.Errorf("trendDirection(%v) = %d, want %d", tt.trend, got, tt.want)
+		}
+	}
+}
+
+func TestPowerText(t *testing.T) {
+	now := time.Now()
+	if got := powerText(power.Reading{Watts: 0.5, WattsAvailable: true, Timestamp: now}, unitMilliwatts); got != "500 mW" {
+		t.Errorf("powerText() = %q, want %q", got, "500 mW")
+	}
+	if got := powerText(power.Reading{Timestamp: now}, unitWatts); got != "— W (unavailable)" {
+		t.Errorf("powerText() = %q, want %q", got, "— W (unavailable)")
+	}
+	kinds := []struct {
+		kind  power.PowerKind
+		watts float64
+		want  string
+	}{
+		{power.PowerKindCharge, 18, "18.0 W in"},
+		{power.PowerKindDischarge, 22, "22.0 W out"},
+		{power.PowerKindSystem, 35, "35.0 W"},
+		{power.PowerKindAdapter, 35, "35.0 W"},
+		{"", 35, "35.0 W"},
+	}
+	for _, tt := range kinds {
+		r := power.Reading{Watts: tt.watts, WattsAvailable: true, PowerKind: tt.kind, Timestamp: now}
+		if got := powerText(r, unitWatts); got != tt.want {
+			t.Errorf("powerText(%q) = %q, want %q", tt.kind, got, tt.want)
+		}
+	}
+
+	// Before the first reading there's nothing to call unavailable yet
+	if got := powerText(power.Reading{}, unitWatts); got != "0.0 W" {
+		t.Errorf("powerText() = %q, want %q", got, "0.0 W")
+	}
+}
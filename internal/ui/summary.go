@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+// Summary formats the stats of every reading in h as plain "Label: value"
+// lines for scripts to parse, e.g. at the end of a -duration run. The peak is
+// the highest reading within peakWindow of the newest one; a zero
+// peakWindow leaves it out.
+func Summary(h *power.History, peakWindow time.Duration) string {
+	readings := h.Readings()
+	if len(readings) == 0 {
+		return "No readings recorded.\n"
+	}
+	span := readings[len(readings)-1].Timestamp.Sub(readings[0].Timestamp)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Duration: %s\n", formatDuration(span))
+	fmt.Fprintf(&b, "Samples: %d\n", len(readings))
+	fmt.Fprintf(&b, "Avg: %s\n", formatWatts(h.Average(), unitWatts))
+	fmt.Fprintf(&b, "Min: %s\n", formatWatts(h.Min(), unitWatts))
+	fmt.Fprintf(&b, "Max: %s\n", formatWatts(h.Max(), unitWatts))
+	if peakWindow > 0 {
+		fmt.Fprintf(&b, "Peak(%s): %s\n", formatDuration(peakWindow), formatWatts(h.MaxOver(peakWindow), unitWatts))
+	}
+	fmt.Fprintf(&b, "Energy: %.2fWh\n", h.EnergyWattHours())
+	return b.String()
+}
+
+// Summary describes the session in one line for printing once the UI exits,
+// e.g. "Monitored for 2m: 120 samples, avg 12.3W, min 5.0W, max 20.1W,
+// 0.41Wh total." It covers the readings in the model's history.
+func (m Model) Summary() string {
+	readings := m.history.Readings()
+	if len(readings) == 0 {
+		return "No readings recorded."
+	}
+	span := readings[len(readings)-1].Timestamp.Sub(readings[0].Timestamp)
+
+	return fmt.Sprintf("Monitored for %s: %d samples, avg %s, min %s, max %s, %.2fWh total.",
+		formatDuration(span), len(readings),
+		formatWatts(m.history.Average(), unitWatts),
+		formatWatts(m.history.Min(), unitWatts),
+		formatWatts(m.history.Max(), unitWatts),
+		m.history.EnergyWattHours())
+}
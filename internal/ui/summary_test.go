@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+func TestSummary(t *testing.T) {
+	// Ten readings a second apart, 10W to 28W
+	newHistory := func() *power.History {
+		h := power.NewHistory(100, time.Hour)
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			h.Add(power.Reading{Watts: 10 + 2*float64(i), Timestamp: now.Add(time.Duration(i) * time.Second)})
+		}
+		return h
+	}
+
+	t.Run("formats every stat", func(t *testing.T) {
+		want := `Duration: 9s
+Samples: 10
+Avg: 19.0W
+Min: 10.0W
+Max: 28.0W
+Peak(3s): 28.0W
+Energy: 0.05Wh
+`
+		if got := Summary(newHistory(), 3*time.Second); got != want {
+			t.Errorf("Summary() =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("leaves out the peak without a window", func(t *testing.T) {
+		want := `Duration: 9s
+Samples: 10
+Avg: 19.0W
+Min: 10.0W
+Max: 28.0W
+Energy: 0.05Wh
+`
+		if got := Summary(newHistory(), 0); got != want {
+			t.Errorf("Summary() =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("peak covers only the window", func(t *testing.T) {
+		h := power.NewHistory(100, time.Hour)
+		now := time.Now()
+		h.Add(power.Reading{Watts: 50, Timestamp: now})
+		h.Add(power.Reading{Watts: 5, Timestamp: now.Add(time.Minute)})
+
+		want := `Duration: 1m
+Samples: 2
+Avg: 27.5W
+Min: 5.0W
+Max: 50.0W
+Peak(10s): 5.0W
+Energy: 0.46Wh
+`
+		if got := Summary(h, 10*time.Second); got != want {
+			t.Errorf("Summary() =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("empty history", func(t *testing.T) {
+		h := power.NewHistory(100, time.Hour)
+		if got := Summary(h, 10*time.Second); got != "No readings recorded.\n" {
+			t.Errorf("Summary() = %q", got)
+		}
+	})
+}
+
+func TestModel_Summary(t *testing.T) {
+	t.Run("describes the history", func(t *testing.T) {
+		m := NewModel(DefaultConfig(power.NewMockMonitor()))
+		now := time.Now()
+		for i := 0; i <= 60; i++ {
+			m.history.Add(power.Reading{Watts: 10 + float64(i%2)*10, Timestamp: now.Add(time.Duration(i) * time.Second)})
+		}
+
+		want := "Monitored for 1m: 61 samples, avg 14.9W, min 10.0W, max 20.0W, 0.25Wh total."
+		if got := m.Summary(); got != want {
+			t.Errorf("Summary() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("ignores the display unit", func(t *testing.T) {
+		m := NewModel(DefaultConfig(power.NewMockMonitor()))
+		m.unit = unitMilliwatts
+		now := time.Now()
+		m.history.Add(power.Reading{Watts: 4, Timestamp: now})
+		m.history.Add(power.Reading{Watts: 6, Timestamp: now.Add(30 * time.Second)})
+
+		want := "Monitored for 30s: 2 samples, avg 5.0W, min 4.0W, max 6.0W, 0.04Wh total."
+		if got := m.Summary(); got != want {
+			t.Errorf("Summary() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("empty history", func(t *testing.T) {
+		m := NewModel(DefaultConfig(power.NewMockMonitor()))
+		if got := m.Summary(); got != "No readings recorded." {
+			t.Errorf("Summary() = %q", got)
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme holds the styles used to render the UI.
+type Theme struct {
+	accent lipgloss.Style // spinner
+
+	title lipgloss.Style
+	box   lipgloss.Style
+	power lipgloss.Style
+	alert lipgloss.Style // power above the alert threshold
+	label lipgloss.Style
+	value lipgloss.Style
+	help  lipgloss.Style
+	note  lipgloss.Style // dim text within a line, unlike help's own line
+	error lipgloss.Style
+
+	trendUp     lipgloss.Style
+	trendDown   lipgloss.Style
+	trendStable lipgloss.Style
+
+	graphBar   lipgloss.Style
+	graphAxis  lipgloss.Style
+	graphAbove lipgloss.Style // bars above the average, when highlighted
+	graphBelow lipgloss.Style // bars at or below the average, when highlighted
+
+	// graphGradient colors each bar along gradientColor by its height
+	// instead of with graphBar's single color
+	graphGradient bool
+
+	batteryHigh lipgloss.Style
+	batteryMed  lipgloss.Style
+	batteryLow  lipgloss.Style
+}
+
+// Default colors for bars above and below the average when they're
+// highlighted.
+const (
+	DefaultAboveAverageColor = "#FF5555"
+	DefaultBelowAverageColor = "#55FF55"
+)
+
+// Colors for the low, middle and high ends of the gradient graph bars are
+// colored along.
+const (
+	DefaultGraphLowColor  = "#55FF55"
+	DefaultGraphMidColor  = "#FFFF55"
+	DefaultGraphHighColor = "#FF5555"
+)
+
+// gradientSteps is how many distinct colors the graph gradient uses, so that
+// neighboring bars of similar height share a color and a render.
+const gradientSteps = 8
+
+// gradientColor maps t, from 0 for the lowest bar to 1 for the highest, to a
+// color that blends from DefaultGraphLowColor through DefaultGraphMidColor
+// to DefaultGraphHighColor. Values outside 0..1 are clamped.
+func gradientColor(t float64) lipgloss.Color {
+	t = math.Max(0, math.Min(1, t))
+	if t <= 0.5 {
+		return blendColors(DefaultGraphLowColor, DefaultGraphMidColor, t*2)
+	}
+	return blendColors(DefaultGraphMidColor, DefaultGraphHighColor, t*2-1)
+}
+
+// gradientStep returns which of the gradientSteps colors a bar of value v
+// gets on a scale from lo to hi. A flat scale puts every bar at the low end.
+func gradientStep(v, lo, hi float64) int {
+	if hi <= lo {
+		return 0
+	}
+	t := math.Max(0, math.Min(1, (v-lo)/(hi-lo)))
+	return int(math.Round(t * (gradientSteps - 1)))
+}
+
+// blendColors linearly interpolates between two "#RRGGBB" colors.
+func blendColors(from, to string, t float64) lipgloss.Color {
+	a, b := hexRGB(from), hexRGB(to)
+	var mixed [3]uint8
+	for i := range mixed {
+		mixed[i] = uint8(math.Round(float64(a[i]) + (float64(b[i])-float64(a[i]))*t))
+	}
+	return lipgloss.Color(fmt.Sprintf("#%02X%02X%02X", mixed[0], mixed[1], mixed[2]))
+}
+
+// hexRGB splits a "#RRGGBB" color into its components.
+func hexRGB(color string) [3]uint8 {
+	v, _ := strconv.ParseUint(color[1:], 16, 32)
+	return [3]uint8{uint8(v >> 16), uint8(v >> 8), uint8(v)}
+}
+
+// DefaultTheme returns the standard colored theme.
+func DefaultTheme() Theme {
+	return Theme{
+		accent: lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")),
+
+		title: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#7D56F4")).
+			MarginBottom(1),
+		box: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#7D56F4")).
+			Padding(1, 2),
+		power: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#00FF00")),
+		alert: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FF5555")),
+		label: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#888888")),
+		value: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FFFFFF")),
+		help: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#555555")).
+			MarginTop(1),
+		note: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#555555")),
+		error: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF5555")),
+
+		trendUp: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FF5555")),
+		trendDown: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#55FF55")),
+		trendStable: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFF55")),
+
+		graphBar: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#7D56F4")),
+		graphAxis: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#555555")),
+		graphAbove: lipgloss.NewStyle().
+			Foreground(lipgloss.Color(DefaultAboveAverageColor)),
+		graphBelow: lipgloss.NewStyle().
+			Foreground(lipgloss.Color(DefaultBelowAverageColor)),
+		graphGradient: true,
+
+		batteryHigh: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#55FF55")),
+		batteryMed: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FFFF55")),
+		batteryLow: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FF5555")),
+	}
+}
+
+// PlainTheme returns a theme without any colors, for screenshots, captured
+// logs, and terminals where color hurts readability. Layout and bold text
+// are kept.
+func PlainTheme() Theme {
+	return Theme{
+		accent: lipgloss.NewStyle(),
+
+		title: lipgloss.NewStyle().Bold(true).MarginBottom(1),
+		box:   lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(1, 2),
+		power: lipgloss.NewStyle().Bold(true),
+		alert: lipgloss.NewStyle().Bold(true).Reverse(true),
+		label: lipgloss.NewStyle(),
+		value: lipgloss.NewStyle().Bold(true),
+		help:  lipgloss.NewStyle().MarginTop(1),
+		note:  lipgloss.NewStyle(),
+		error: lipgloss.NewStyle(),
+
+		trendUp:     lipgloss.NewStyle().Bold(true),
+		trendDown:   lipgloss.NewStyle().Bold(true),
+		trendStable: lipgloss.NewStyle(),
+
+		graphBar:   lipgloss.NewStyle(),
+		graphAxis:  lipgloss.NewStyle(),
+		graphAbove: lipgloss.NewStyle().Bold(true),
+		graphBelow: lipgloss.NewStyle(),
+
+		batteryHigh: lipgloss.NewStyle().Bold(true),
+		batteryMed:  lipgloss.NewStyle().Bold(true),
+		batteryLow:  lipgloss.NewStyle().Bold(true),
+	}
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+func TestPlainTheme(t *testing.T) {
+	theme := PlainTheme()
+	styles := map[string]lipgloss.Style{
+		"accent":      theme.accent,
+		"title":       theme.title,
+		"power":       theme.power,
+		"alert":       theme.alert,
+		"label":       theme.label,
+		"value":       theme.value,
+		"help":        theme.help,
+		"note":        theme.note,
+		"error":       theme.error,
+		"trendUp":     theme.trendUp,
+		"trendDown":   theme.trendDown,
+		"trendStable": theme.trendStable,
+		"graphBar":    theme.graphBar,
+		"graphAxis":   theme.graphAxis,
+		"batteryHigh": theme.batteryHigh,
+		"batteryMed":  theme.batteryMed,
+		"batteryLow":  theme.batteryLow,
+	}
+
+	for name, style := range styles {
+		if _, ok := style.GetForeground().(lipgloss.NoColor); !ok {
+			t.Errorf("%s: expected no foreground color, got %v", name, style.GetForeground())
+		}
+	}
+	if _, ok := theme.box.GetBorderTopForeground().(lipgloss.NoColor); !ok {
+		t.Errorf("box: expected no border color, got %v", theme.box.GetBorderTopForeground())
+	}
+	if theme.graphGradient {
+		t.Error("expected no graph gradient")
+	}
+}
+
+func TestGradientColor(t *testing.T) {
+	tests := []struct {
+		t    float64
+		want lipgloss.Color
+	}{
+		{0, DefaultGraphLowColor},
+		{0.5, DefaultGraphMidColor},
+		{1, DefaultGraphHighColor},
+		{0.25, "#AAFF55"},
+		{-1, DefaultGraphLowColor},
+		{2, DefaultGraphHighColor},
+	}
+	for _, tt := range tests {
+		if got := gradientColor(tt.t); got != tt.want {
+			t.Errorf("gradientColor(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestGradientStep(t *testing.T) {
+	tests := []struct {
+		v, lo, hi float64
+		want      int
+	}{
+		{5, 5, 20, 0},
+		{20, 5, 20, gradientSteps - 1},
+		{12.5, 0, 25, 4},
+		{30, 5, 20, gradientSteps - 1},
+		{7, 7, 7, 0},
+	}
+	for _, tt := range tests {
+		if got := gradientStep(tt.v, tt.lo, tt.hi); got != tt.want {
+			t.Errorf("gradientStep(%v, %v, %v) = %d, want %d", tt.v, tt.lo, tt.hi, got, tt.want)
+		}
+	}
+}
+
+func TestNewModel_Theme(t *testing.T) {
+	t.Run("colored by default", func(t *testing.T) {
+		m := NewModel(DefaultConfig(power.NewMockMonitor()))
+
+		if _, ok := m.theme.power.GetForeground().(lipgloss.NoColor); ok {
+			t.Error("expected default theme to use colors")
+		}
+	})
+
+	t.Run("plain with NoColor", func(t *testing.T) {
+		cfg := DefaultConfig(power.NewMockMonitor())
+		cfg.NoColor = true
+		m := NewModel(cfg)
+
+		if _, ok := m.theme.power.GetForeground().(lipgloss.NoColor); !ok {
+			t.Error("expected NoColor to use the plain theme")
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+// titleMetric is a secondary value that can be shown next to the title.
+type titleMetric struct {
+	label  string
+	format func(m Model) string
+}
+
+// titleMetrics maps -title-metric names to how they are rendered.
+var titleMetrics = map[string]titleMetric{
+	"energy": {"Energy", func(m Model) string {
+		return fmt.Sprintf("%.2fWh", m.history.EnergyWattHours())
+	}},
+	"avg": {"Avg", func(m Model) string {
+		return fmt.Sprintf("%.1fW", m.history.AverageOver(m.statsWindow))
+	}},
+	"min": {"Min", func(m Model) string {
+		return fmt.Sprintf("%.1fW", m.history.MinOver(m.statsWindow))
+	}},
+	"max": {"Max", func(m Model) string {
+		return fmt.Sprintf("%.1fW", m.history.MaxOver(m.statsWindow))
+	}},
+	"ema": {"EMA", func(m Model) string {
+		return fmt.Sprintf("%.1fW", m.history.EMA())
+	}},
+	"battery": {"Battery", func(m Model) string {
+		if m.lastReading.BatteryPercent < 0 {
+			return "n/a"
+		}
+		return fmt.Sprintf("%.0f%%", m.lastReading.BatteryPercent)
+	}},
+	"capacity": {"Capacity", func(m Model) string {
+		if capacity := formatCapacity(m.lastReading); capacity != "" {
+			return capacity
+		}
+		return "n/a"
+	}},
+	"health": {"Health", func(m Model) string {
+		if health := m.lastReading.BatteryHealthPercent; health > 0 {
+			return formatHealth(health)
+		}
+		return "n/a"
+	}},
+	"temperature": {"Temp", func(m Model) string {
+		if temp := m.lastReading.Temperature; temp > 0 {
+			return fmt.Sprintf("%.1f°C", temp)
+		}
+		return "n/a"
+	}},
+	"drain": {"Drain", func(m Model) string {
+		if drain := m.history.BatteryDrainRatePerHour(); drain > 0 && m.lastReading.IsOnBattery {
+			return formatDrainRate(drain)
+		}
+		return "n/a"
+	}},
+	"cpu":  componentMetric("CPU", power.ComponentCPU),
+	"gpu":  componentMetric("GPU", power.ComponentGPU),
+	"ane":  componentMetric("ANE", power.ComponentANE),
+	"dgpu": componentMetric("dGPU", power.ComponentDiscreteGPU),
+}
+
+// componentMetric shows the latest reading's power for one component, for
+// sources that break power down.
+func componentMetric(label, component string) titleMetric {
+	return titleMetric{label, func(m Model) string {
+		if watts, ok := m.lastReading.Components[component]; ok {
+			return fmt.Sprintf("%.1fW", watts)
+		}
+		return "n/a"
+	}}
+}
+
+// TitleMetrics returns the names accepted for Config.TitleMetric, sorted.
+func TitleMetrics() []string {
+	names := make([]string, 0, len(titleMetrics))
+	for name := range titleMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsTitleMetric reports whether name is a known title metric.
+func IsTitleMetric(name string) bool {
+	_, ok := titleMetrics[name]
+	return ok
+}
+
+// renderTitleMetric renders the configured title metric, or an empty string
+// if none is set.
+func (m Model) renderTitleMetric() string {
+	metric, ok := titleMetrics[m.titleMetric]
+	if !ok {
+		return ""
+	}
+	return m.theme.label.Render(metric.label+": ") + m.theme.value.Render(metric.format(m))
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+func TestTitleMetrics(t *testing.T) {
+	t.Run("returns sorted names", func(t *testing.T) {
+		names := TitleMetrics()
+		if len(names) != len(titleMetrics) {
+			t.Fatalf("expected %d names, got %d", len(titleMetrics), len(names))
+		}
+		for i := 1; i < len(names); i++ {
+			if names[i-1] > names[i] {
+				t.Errorf("expected sorted names, got %v", names)
+			}
+		}
+	})
+
+	t.Run("validates names", func(t *testing.T) {
+		if !IsTitleMetric("energy") {
+			t.Error("expected energy to be a title metric")
+		}
+		if IsTitleMetric("bogus") {
+			t.Error("expected bogus to not be a title metric")
+		}
+	})
+}
+
+func TestModel_ViewTitleMetric(t *testing.T) {
+	tests := []struct {
+		metric   string
+		expected string
+	}{
+		{"energy", "Energy: 0.83Wh"},
+		{"battery", "Battery: 75%"},
+		{"max", "Max: 40.0W"},
+		{"health", "Health: 92%"},
+		{"ema", "EMA: 26.0W"},
+		{"temperature", "Temp: 31.5°C"},
+		{"drain", "Drain: 36%/h"},
+		{"cpu", "CPU: 12.5W"},
+		{"gpu", "GPU: 3.0W"},
+		{"ane", "ANE: n/a"},
+		{"dgpu", "dGPU: n/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			cfg := DefaultConfig(power.NewMockMonitor())
+			cfg.TitleMetric = tt.metric
+			m := NewModel(cfg)
+			m.ready = true
+
+			now := time.Now()
+			m.history.Add(power.Reading{Watts: 20.0, Timestamp: now, BatteryPercent: 76, IsOnBattery: true})
+			m.history.Add(power.Reading{Watts: 40.0, Timestamp: now.Add(100 * time.Second), BatteryPercent: 75, IsOnBattery: true})
+			m.lastReading = power.Reading{
+				Watts:                40.0,
+				Timestamp:            now.Add(100 * time.Second),
+				BatteryPercent:       75.0,
+				IsOnBattery:          true,
+				BatteryHealthPercent: 92,
+				Temperature:          31.5,
+				Components:           map[string]float64{power.ComponentCPU: 12.5, power.ComponentGPU: 3},
+			}
+
+			view := m.View()
+
+			titleLine := strings.Split(view, "\n")[2]
+			if !strings.Contains(titleLine, "Power Monitor") || !strings.Contains(titleLine, tt.expected) {
+				t.Errorf("expected title line to contain %q, got %q", tt.expected, titleLine)
+			}
+		})
+	}
+
+	t.Run("shows nothing without a title metric", func(t *testing.T) {
+		m := NewModel(DefaultConfig(power.NewMockMonitor()))
+		m.ready = true
+
+		if m.renderTitleMetric() != "" {
+			t.Error("expected empty title metric")
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// wattUnit selects the unit power values are displayed in. Readings are
+// always stored in watts; the unit only affects formatting.
+type wattUnit int
+
+const (
+	unitWatts wattUnit = iota
+	unitMilliwatts
+	unitKilowatts
+)
+
+// next returns the unit that follows u when cycling W → mW → kW.
+func (u wattUnit) next() wattUnit {
+	switch u {
+	case unitWatts:
+		return unitMilliwatts
+	case unitMilliwatts:
+		return unitKilowatts
+	default:
+		return unitWatts
+	}
+}
+
+// String returns the unit's symbol.
+func (u wattUnit) String() string {
+	switch u {
+	case unitMilliwatts:
+		return "mW"
+	case unitKilowatts:
+		return "kW"
+	default:
+		return "W"
+	}
+}
+
+// number formats w, given in watts, in unit u without a suffix. Precision
+// is chosen so each unit shows roughly the same resolution.
+func (u wattUnit) number(w float64) string {
+	switch u {
+	case unitMilliwatts:
+		return fmt.Sprintf("%.0f", w*1000)
+	case unitKilowatts:
+		return fmt.Sprintf("%.3f", w/1000)
+	default:
+		return fmt.Sprintf("%.1f", w)
+	}
+}
+
+// signedWatts formats a difference in watts as a number in the given unit,
+// without the suffix, always with a sign, e.g. "+1.5" or "-0.3".
+func signedWatts(w float64, unit wattUnit) string {
+	s := unit.number(w)
+	if !strings.HasPrefix(s, "-") {
+		s = "+" + s
+	}
+	return s
+}
+
+// formatWatts formats w, given in watts, in the given unit with its suffix,
+// e.g. 0.5 as "500mW".
+func formatWatts(w float64, unit wattUnit) string {
+	return unit.number(w) + unit.String()
+}
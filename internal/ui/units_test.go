@@ -0,0 +1,40 @@
+package ui
+
+import "testing"
+
+func TestFormatWatts(t *testing.T) {
+	tests := []struct {
+		name     string
+		watts    float64
+		unit     wattUnit
+		expected string
+	}{
+		{"watts", 12.34, unitWatts, "12.3W"},
+		{"sub-watt in watts", 0.5, unitWatts, "0.5W"},
+		{"half watt in milliwatts", 0.5, unitMilliwatts, "500mW"},
+		{"one watt in milliwatts", 1, unitMilliwatts, "1000mW"},
+		{"rounds milliwatts", 0.0004, unitMilliwatts, "0mW"},
+		{"kilowatts", 350, unitKilowatts, "0.350kW"},
+		{"one kilowatt", 1000, unitKilowatts, "1.000kW"},
+		{"zero", 0, unitWatts, "0.0W"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatWatts(tt.watts, tt.unit); got != tt.expected {
+				t.Errorf("formatWatts(%v, %s) = %q, want %q", tt.watts, tt.unit, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWattUnit_Next(t *testing.T) {
+	u := unitWatts
+	want := []wattUnit{unitMilliwatts, unitKilowatts, unitWatts}
+	for i, w := range want {
+		u = u.next()
+		if u != w {
+			t.Errorf("step %d: got %s, want %s", i, u, w)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package web serves a minimal HTML dashboard of recent power readings, for
+// glancing at a headless machine from a browser.
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+// Sparkline dimensions, in SVG user units.
+const (
+	sparklineWidth  = 600
+	sparklineHeight = 120
+)
+
+// page is the dashboard's HTML. It reloads itself every Refresh seconds.
+var page = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .HasReading}}{{.Watts}} - {{end}}powermon</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #111; color: #eee; margin: 2em; }
+h1 { font-size: 1em; color: #7D56F4; }
+.watts { font-size: 3em; font-weight: bold; }
+.dim { color: #888; }
+svg { width: 100%; max-width: {{.Width}}px; height: auto; background: #1a1a1a; }
+polyline { fill: none; stroke: #04B575; stroke-width: 2; }
+</style>
+</head>
+<body>
+<h1>powermon</h1>
+{{if .HasReading -}}
+<div class="watts">{{.Watts}}</div>
+<p>{{.Battery}}</p>
+<p>Avg: {{.Average}} &middot; Min: {{.Min}} &middot; Max: {{.Max}} &middot; Samples: {{.Samples}}</p>
+{{if .Points}}<svg viewBox="0 0 {{.Width}} {{.Height}}" role="img" aria-label="Power over the last {{.Window}}"><polyline points="{{.Points}}"/></svg>{{end}}
+<p class="dim">{{with .Source}}{{.}} &middot; {{end}}updated {{.Updated}}</p>
+{{- else -}}
+<p class="dim">Waiting for data...</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// pageData is what the page template shows.
+type pageData struct {
+	Refresh    int
+	HasReading bool
+	Watts      string
+	Battery    string
+	Average    string
+	Min        string
+	Max        string
+	Samples    int
+	Points     string
+	Width      int
+	Height     int
+	Window     time.Duration
+	Source     string
+	Updated    string
+}
+
+// Dashboard serves the latest reading in a History and a sparkline of it as
+// an HTML page that refreshes itself. It only reads the history, so the page
+// shows whatever the UI or engine filling it kept: readings past -max-watts
+// clamped, outliers dropped, and nothing after the history is cleared.
+type Dashboard struct {
+	history *power.History
+	refresh time.Duration
+}
+
+// NewDashboard creates a dashboard showing history. The page reloads every
+// refresh, rounded up to a second.
+func NewDashboard(history *power.History, refresh time.Duration) *Dashboard {
+	return &Dashboard{history: history, refresh: refresh}
+}
+
+// ServeHTTP writes the dashboard page. Before the first reading it shows a
+// placeholder that keeps refreshing until one arrives.
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	data := pageData{
+		Refresh: max(1, int(math.Ceil(d.refresh.Seconds()))),
+		Width:   sparklineWidth,
+		Height:  sparklineHeight,
+		Window:  d.history.Window(),
+	}
+
+	readings := d.history.Readings()
+	if len(readings) > 0 {
+		latest := readings[len(readings)-1]
+		stats := d.history.StatsOver(0)
+		data.HasReading = true
+		data.Watts = formatWatts(latest)
+		data.Battery = formatBattery(latest)
+		data.Average = fmt.Sprintf("%.1f W", stats.Average)
+		data.Min = fmt.Sprintf("%.1f W", stats.Min)
+		data.Max = fmt.Sprintf("%.1f W", stats.Max)
+		data.Samples = stats.Samples
+		data.Points = sparklinePoints(readings, sparklineWidth, sparklineHeight)
+		data.Source = latest.Source
+		data.Updated = latest.Timestamp.Format(time.TimeOnly)
+	}
+
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// NewServeMux returns a mux serving the dashboard at /.
+func NewServeMux(d *Dashboard) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", d)
+	return mux
+}
+
+// formatWatts formats a reading's power, e.g. "12.3 W".
+func formatWatts(r power.Reading) string {
+	if !r.WattsAvailable {
+		return "— W (unavailable)"
+	}
+	return fmt.Sprintf("%.1f W", r.Watts)
+}
+
+// formatBattery describes the battery and power source, e.g.
+// "Battery 78%, discharging".
+func formatBattery(r power.Reading) string {
+	if r.BatteryPercent < 0 {
+		if r.IsOnBattery {
+			return "On battery"
+		}
+		return "On AC power"
+	}
+
+	state := "on AC power"
+	switch {
+	case r.IsCharging:
+		state = "charging"
+	case r.IsOnBattery:
+		state = "discharging"
+	}
+	return fmt.Sprintf("Battery %.0f%%, %s", r.BatteryPercent, state)
+}
+
+// sparklinePoints returns an SVG polyline's points plotting the readings'
+// watts across width and height, with the highest reading at the top and
+// zero at the bottom. It returns "" with fewer than two readings.
+func sparklinePoints(readings []power.Reading, width, height int) string {
+	if len(readings) < 2 {
+		return ""
+	}
+
+	maxWatts := 0.0
+	for _, r := range readings {
+		maxWatts = max(maxWatts, r.Watts)
+	}
+	if maxWatts <= 0 {
+		maxWatts = 1
+	}
+
+	var b strings.Builder
+	step := float64(width) / float64(len(readings)-1)
+	for i, r := range readings {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		y := float64(height) * (1 - max(0, r.Watts)/maxWatts)
+		fmt.Fprintf(&b, "%.1f,%.1f", float64(i)*step, y)
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rdegges/powermon/internal/power"
+)
+
+// get requests path from a server for the dashboard and returns the
+// response and its body.
+func get(t *testing.T, d *Dashboard, path string) (*http.Response, string) {
+	t.Helper()
+	srv := httptest.NewServer(NewServeMux(d))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestDashboard(t *testing.T) {
+	t.Run("waits for the first reading", func(t *testing.T) {
+		d := NewDashboard(power.NewHistory(10, time.Minute), 2*time.Second)
+
+		resp, body := get(t, d, "/")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		if !strings.Contains(body, "Waiting for data...") {
+			t.Errorf("expected a placeholder, got %q", body)
+		}
+		if !strings.Contains(body, `<meta http-equiv="refresh" content="2">`) {
+			t.Errorf("expected the page to refresh every 2s, got %q", body)
+		}
+	})
+
+	t.Run("shows the latest reading", func(t *testing.T) {
+		history := power.NewHistory(10, time.Minute)
+		now := time.Now()
+		history.Add(power.Reading{Watts: 5, WattsAvailable: true, BatteryPercent: 50, Timestamp: now})
+		history.Add(power.Reading{Watts: 15.25, WattsAvailable: true, BatteryPercent: 49, IsOnBattery: true, Timestamp: now.Add(time.Second)})
+		d := NewDashboard(history, time.Second)
+
+		resp, body := get(t, d, "/")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+			t.Errorf("expected an HTML content type, got %q", resp.Header.Get("Content-Type"))
+		}
+		for _, want := range []string{"15.2 W", "Battery 49%, discharging", "Avg: 10.1 W", "Samples: 2", "<polyline points="} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected page to contain %q, got %q", want, body)
+			}
+		}
+	})
+
+	t.Run("follows a cleared history", func(t *testing.T) {
+		history := power.NewHistory(10, time.Minute)
+		history.Add(power.Reading{Watts: 5, WattsAvailable: true, BatteryPercent: -1, Timestamp: time.Now()})
+		d := NewDashboard(history, time.Second)
+
+		history.Clear()
+		if _, body := get(t, d, "/"); !strings.Contains(body, "Waiting for data...") {
+			t.Errorf("expected the placeholder after clearing, got %q", body)
+		}
+	})
+
+	t.Run("serves nothing else", func(t *testing.T) {
+		d := NewDashboard(power.NewHistory(10, time.Minute), time.Second)
+		if resp, _ := get(t, d, "/other"); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestSparklinePoints(t *testing.T) {
+	readings := []power.Reading{{Watts: 10}, {Watts: 20}, {Watts: 0}}
+	if got, want := sparklinePoints(readings, 100, 50), "0.0,25.0 50.0,0.0 100.0,50.0"; got != want {
+		t.Errorf("sparklinePoints() = %q, want %q", got, want)
+	}
+	if got := sparklinePoints(readings[:1], 100, 50); got != "" {
+		t.Errorf("expected no sparkline for one reading, got %q", got)
+	}
+}
+
+func TestFormatBattery(t *testing.T) {
+	tests := []struct {
+		name     string
+		reading  power.Reading
+		expected string
+	}{
+		{"discharging", power.Reading{BatteryPercent: 78, IsOnBattery: true}, "Battery 78%, discharging"},
+		{"charging", power.Reading{BatteryPercent: 40, IsCharging: true}, "Battery 40%, charging"},
+		{"full on AC", power.Reading{BatteryPercent: 100}, "Battery 100%, on AC power"},
+		{"no battery", power.Reading{BatteryPercent: -1}, "On AC power"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatBattery(tt.reading); got != tt.expected {
+				t.Errorf("formatBattery() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}